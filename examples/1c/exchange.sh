@@ -0,0 +1,34 @@
+#!/bin/sh
+# Проверка обмена с 1С на примерах import.xml и offers.xml.
+# Повторяет запросы, которые отправляет 1С: авторизация, загрузка каталога
+# и цен, выгрузка новых заказов.
+#
+# Использование: ./exchange.sh [адрес API] [email администратора] [пароль]
+set -e
+
+API=${1:-http://localhost:8080/api/v1}
+LOGIN=${2:-admin@texnousta.com}
+PASSWORD=${3:-password}
+DIR=$(dirname "$0")
+URL="$API/1c/exchange"
+
+echo "== checkauth"
+AUTH=$(curl -s -u "$LOGIN:$PASSWORD" "$URL?type=catalog&mode=checkauth")
+echo "$AUTH"
+COOKIE="$(echo "$AUTH" | sed -n 2p)=$(echo "$AUTH" | sed -n 3p)"
+
+echo "== init"
+curl -s -b "$COOKIE" "$URL?type=catalog&mode=init"
+
+for FILE in import.xml offers.xml; do
+	echo "== file $FILE"
+	curl -s -b "$COOKIE" --data-binary "@$DIR/$FILE" "$URL?type=catalog&mode=file&filename=$FILE"
+	echo "== import $FILE"
+	curl -s -b "$COOKIE" "$URL?type=catalog&mode=import&filename=$FILE"
+done
+
+echo "== sale query"
+curl -s -b "$COOKIE" "$URL?type=sale&mode=query"
+echo
+echo "== sale success"
+curl -s -b "$COOKIE" "$URL?type=sale&mode=success"
@@ -0,0 +1,192 @@
+package analytics
+
+import (
+	"math"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	"texnousta-backend/internal/config"
+	"texnousta-backend/internal/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// searchEngines - домены поисковых систем: переходы с них считаются органикой
+var searchEngines = []string{"google.", "yandex.", "bing.com", "duckduckgo.com", "mail.ru"}
+
+// CleanPath - путь страницы без query string и фрагмента
+func CleanPath(path string) string {
+	if i := strings.IndexAny(path, "?#"); i >= 0 {
+		path = path[:i]
+	}
+	if path == "" {
+		return "/"
+	}
+	return path
+}
+
+// Attribute определяет источник визита по UTM-меткам, а без них - по сайту,
+// с которого пришел посетитель. Переходы внутри сайта источником не считаются.
+func Attribute(req *models.PageViewRequest) models.SessionAttribution {
+	utm := url.Values{}
+	if i := strings.Index(req.Path, "?"); i >= 0 {
+		utm, _ = url.ParseQuery(req.Path[i+1:])
+	}
+	pick := func(explicit, key string) string {
+		if explicit != "" {
+			return strings.TrimSpace(explicit)
+		}
+		return strings.TrimSpace(utm.Get(key))
+	}
+
+	a := models.SessionAttribution{
+		Source:      strings.ToLower(pick(req.UTMSource, "utm_source")),
+		Medium:      strings.ToLower(pick(req.UTMMedium, "utm_medium")),
+		Campaign:    pick(req.UTMCampaign, "utm_campaign"),
+		Term:        pick(req.UTMTerm, "utm_term"),
+		Content:     pick(req.UTMContent, "utm_content"),
+		LandingPath: CleanPath(req.Path),
+	}
+
+	a.ReferrerDomain = referrerDomain(req.Referrer)
+	if a.Source == "" {
+		switch {
+		case a.ReferrerDomain == "":
+			a.Source, a.Medium = "direct", "none"
+		case isSearchEngine(a.ReferrerDomain):
+			a.Source, a.Medium = a.ReferrerDomain, "organic"
+		default:
+			a.Source, a.Medium = a.ReferrerDomain, "referral"
+		}
+	}
+	return a
+}
+
+// SaveAttribution сохраняет источник сессии, если он еще не записан:
+// атрибуция - по первому визиту
+func SaveAttribution(db *gorm.DB, attribution *models.SessionAttribution) error {
+	return db.Clauses(clause.OnConflict{Columns: []clause.Column{{Name: "session_id"}}, DoNothing: true}).
+		Create(attribution).Error
+}
+
+// referrerDomain - домен источника без www; пусто для своего сайта
+func referrerDomain(referrer string) string {
+	if referrer == "" {
+		return ""
+	}
+	u, err := url.Parse(referrer)
+	if err != nil || u.Hostname() == "" {
+		return ""
+	}
+	host := strings.TrimPrefix(strings.ToLower(u.Hostname()), "www.")
+
+	if site, err := url.Parse(config.SiteURL()); err == nil {
+		if host == strings.TrimPrefix(strings.ToLower(site.Hostname()), "www.") {
+			return ""
+		}
+	}
+	return host
+}
+
+func isSearchEngine(domain string) bool {
+	for _, engine := range searchEngines {
+		if strings.HasPrefix(domain, engine) || strings.Contains(domain, "."+engine) {
+			return true
+		}
+	}
+	return false
+}
+
+// AttributionStats строит отчет по источникам: сессии, обращения, звонки
+// и заказы. group=source группирует только по источнику, campaign - по
+// источнику, каналу и кампании. Конверсии сессий без атрибуции попадают
+// в источник "unknown".
+func AttributionStats(db *gorm.DB, since time.Time, group string) ([]models.AttributionStat, error) {
+	dims := "COALESCE(a.source, 'unknown') AS source, '' AS medium, '' AS campaign"
+	groupBy := "COALESCE(a.source, 'unknown')"
+	if group == "campaign" {
+		dims = "COALESCE(a.source, 'unknown') AS source, COALESCE(a.medium, '') AS medium, COALESCE(a.campaign, '') AS campaign"
+		groupBy = "COALESCE(a.source, 'unknown'), COALESCE(a.medium, ''), COALESCE(a.campaign, '')"
+	}
+
+	type row struct {
+		Source   string
+		Medium   string
+		Campaign string
+		Count    int64
+		Sum      float64
+	}
+	stats := make(map[string]*models.AttributionStat)
+	collect := func(query string, args []interface{}, set func(*models.AttributionStat, row)) error {
+		var rows []row
+		if err := db.Raw(query, args...).Scan(&rows).Error; err != nil {
+			return err
+		}
+		for _, r := range rows {
+			key := r.Source + "\x00" + r.Medium + "\x00" + r.Campaign
+			s, ok := stats[key]
+			if !ok {
+				s = &models.AttributionStat{Source: r.Source, Medium: r.Medium, Campaign: r.Campaign}
+				stats[key] = s
+			}
+			set(s, r)
+		}
+		return nil
+	}
+
+	// Сессии, начавшиеся в периоде
+	if err := collect(`SELECT `+dims+`, COUNT(*) AS count
+		FROM session_attributions a WHERE a.created_at >= ? GROUP BY `+groupBy,
+		[]interface{}{since},
+		func(s *models.AttributionStat, r row) { s.Sessions = r.Count }); err != nil {
+		return nil, err
+	}
+
+	conversions := []struct {
+		table string
+		set   func(*models.AttributionStat, row)
+	}{
+		{"contact_forms", func(s *models.AttributionStat, r row) { s.ContactForms = r.Count }},
+		{"phone_contacts", func(s *models.AttributionStat, r row) { s.PhoneContacts = r.Count }},
+		{"phone_click_stats", func(s *models.AttributionStat, r row) { s.PhoneClicks = r.Count }},
+	}
+	for _, conv := range conversions {
+		if err := collect(`SELECT `+dims+`, COUNT(*) AS count
+			FROM `+conv.table+` t
+			LEFT JOIN session_attributions a ON a.session_id = t.session_id AND t.session_id <> ''
+			WHERE t.created_at >= ? GROUP BY `+groupBy,
+			[]interface{}{since}, conv.set); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := collect(`SELECT `+dims+`, COUNT(*) AS count, COALESCE(SUM(t.total), 0) AS sum
+		FROM orders t
+		LEFT JOIN session_attributions a ON a.session_id = t.session_id AND t.session_id <> ''
+		WHERE t.created_at >= ? AND t.status <> ? GROUP BY `+groupBy,
+		[]interface{}{since, "cancelled"},
+		func(s *models.AttributionStat, r row) { s.Orders, s.Revenue = r.Count, r.Sum }); err != nil {
+		return nil, err
+	}
+
+	result := make([]models.AttributionStat, 0, len(stats))
+	for _, s := range stats {
+		if s.Sessions > 0 {
+			s.ConversionRate = math.Round(float64(s.Orders)/float64(s.Sessions)*10000) / 100
+		}
+		result = append(result, *s)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Sessions != result[j].Sessions {
+			return result[i].Sessions > result[j].Sessions
+		}
+		if result[i].Source != result[j].Source {
+			return result[i].Source < result[j].Source
+		}
+		return result[i].Campaign < result[j].Campaign
+	})
+	return result, nil
+}
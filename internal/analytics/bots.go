@@ -0,0 +1,94 @@
+package analytics
+
+import (
+	"net"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+
+	"texnousta-backend/internal/useragent"
+)
+
+// Причины, по которым запрос считается ботом
+const (
+	BotReasonUserAgent = "user_agent" // известный робот или HTTP-библиотека
+	BotReasonHeadless  = "headless"   // нет заголовков, которые отправляет любой браузер
+	BotReasonDenylist  = "denylist"   // IP из BOT_IP_DENYLIST
+)
+
+var (
+	denylistOnce sync.Once
+	denylist     []*net.IPNet
+)
+
+// DetectBot проверяет, сделан ли запрос ботом: по User-Agent, по признакам
+// headless-браузера и по списку адресов BOT_IP_DENYLIST (IP или подсети
+// через запятую). Возвращает причину или пустую строку для человека.
+func DetectBot(ip, userAgent string, header http.Header) string {
+	if ipDenied(ip) {
+		return BotReasonDenylist
+	}
+	if strings.TrimSpace(userAgent) == "" || useragent.IsBot(userAgent) {
+		return BotReasonUserAgent
+	}
+	// Браузеры всегда отправляют Accept-Language, в том числе из fetch;
+	// скрипты и headless-браузеры с настройками по умолчанию - нет
+	if header.Get("Accept-Language") == "" {
+		return BotReasonHeadless
+	}
+	if strings.Contains(strings.ToLower(header.Get("Sec-CH-UA")), "headless") {
+		return BotReasonHeadless
+	}
+	return ""
+}
+
+// DropBotHits - не сохранять обращения ботов (BOT_HITS=drop); по умолчанию
+// они сохраняются с пометкой is_bot и исключаются из отчетов
+func DropBotHits() bool {
+	return os.Getenv("BOT_HITS") == "drop"
+}
+
+// HumansOnly разбирает параметр traffic отчетов: human (по умолчанию) или all
+func HumansOnly(traffic string) (humansOnly bool, ok bool) {
+	switch traffic {
+	case "", "human":
+		return true, true
+	case "all":
+		return false, true
+	}
+	return false, false
+}
+
+func ipDenied(ip string) bool {
+	denylistOnce.Do(loadDenylist)
+	parsed := net.ParseIP(ip)
+	if parsed == nil {
+		return false
+	}
+	for _, network := range denylist {
+		if network.Contains(parsed) {
+			return true
+		}
+	}
+	return false
+}
+
+func loadDenylist() {
+	for _, entry := range strings.Split(os.Getenv("BOT_IP_DENYLIST"), ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		if !strings.Contains(entry, "/") {
+			if strings.Contains(entry, ":") {
+				entry += "/128"
+			} else {
+				entry += "/32"
+			}
+		}
+		if _, network, err := net.ParseCIDR(entry); err == nil {
+			denylist = append(denylist, network)
+		}
+	}
+}
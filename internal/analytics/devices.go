@@ -0,0 +1,124 @@
+package analytics
+
+import (
+	"log"
+	"math"
+
+	"texnousta-backend/internal/models"
+	"texnousta-backend/internal/useragent"
+
+	"gorm.io/gorm"
+)
+
+// backfillBatch - сколько записей разбирается за один проход
+const backfillBatch = 500
+
+// DeviceTables - таблицы с User-Agent, по которым строится разбивка по устройствам
+var DeviceTables = map[string]string{
+	"visitors":     "visitor_stats",
+	"phone-clicks": "phone_click_stats",
+}
+
+// BackfillUserAgents разбирает User-Agent у записей, сохраненных до появления
+// полей устройства, ОС и браузера
+func BackfillUserAgents(db *gorm.DB) error {
+	for _, model := range []interface{}{&models.VisitorStat{}, &models.PhoneClickStat{}} {
+		total := 0
+		for {
+			var rows []struct {
+				ID        uint
+				UserAgent string
+			}
+			if err := db.Model(model).Select("id, user_agent").
+				Where("device_type IS NULL OR device_type = ''").
+				Order("id ASC").
+				Limit(backfillBatch).
+				Scan(&rows).Error; err != nil {
+				return err
+			}
+			if len(rows) == 0 {
+				break
+			}
+
+			err := db.Transaction(func(tx *gorm.DB) error {
+				for _, row := range rows {
+					info := useragent.Parse(row.UserAgent)
+					if err := tx.Model(model).Where("id = ?", row.ID).Updates(map[string]interface{}{
+						"device_type": info.DeviceType,
+						"os":          info.OS,
+						"browser":     info.Browser,
+						"is_bot":      info.IsBot,
+					}).Error; err != nil {
+						return err
+					}
+				}
+				return nil
+			})
+			if err != nil {
+				return err
+			}
+			total += len(rows)
+		}
+		if total > 0 {
+			log.Printf("User-Agent разобран у %d записей (%T)", total, model)
+		}
+	}
+	return nil
+}
+
+// DeviceStats строит разбивку записей таблицы по устройствам, ОС и браузерам.
+// ОС и браузеры считаются только у людей; типы устройств - с ботами,
+// если humansOnly не задан.
+func DeviceStats(db *gorm.DB, table, startDate string, humansOnly bool) (*models.DeviceStatsResponse, error) {
+	report := &models.DeviceStatsResponse{}
+
+	var err error
+	if report.Devices, err = breakdown(db, table, "device_type", startDate, humansOnly); err != nil {
+		return nil, err
+	}
+	if report.OS, err = breakdown(db, table, "os", startDate, true); err != nil {
+		return nil, err
+	}
+	if report.Browsers, err = breakdown(db, table, "browser", startDate, true); err != nil {
+		return nil, err
+	}
+
+	for _, d := range report.Devices {
+		report.Total += d.Count
+		if d.Name == useragent.Bot && !humansOnly {
+			report.Bots = d.Count
+		}
+	}
+	return report, nil
+}
+
+// breakdown - число записей по значению столбца с долей от общего числа
+func breakdown(db *gorm.DB, table, column, startDate string, humansOnly bool) ([]models.DeviceStat, error) {
+	// Одно и то же выражение в SELECT и GROUP BY: PostgreSQL не сопоставляет
+	// выражение с параметром и выражение с литералом
+	name := "COALESCE(NULLIF(" + column + ", ''), '" + useragent.Unknown + "')"
+	query := db.Table(table).
+		Select(name+" AS name, COUNT(*) AS count").
+		Where("date >= ?", startDate)
+	if humansOnly {
+		query = query.Where("is_bot = ?", false)
+	}
+
+	var stats []models.DeviceStat
+	if err := query.Group(name).
+		Order("count DESC").
+		Scan(&stats).Error; err != nil {
+		return nil, err
+	}
+
+	var total int64
+	for _, s := range stats {
+		total += s.Count
+	}
+	for i := range stats {
+		if total > 0 {
+			stats[i].Share = math.Round(float64(stats[i].Count)/float64(total)*10000) / 100
+		}
+	}
+	return stats, nil
+}
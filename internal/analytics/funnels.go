@@ -0,0 +1,261 @@
+package analytics
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"texnousta-backend/internal/events"
+	"texnousta-backend/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// maxFunnelSteps - ограничение длины воронки
+const maxFunnelSteps = 10
+
+// maxFunnelDays - самый длинный период воронки
+const maxFunnelDays = 366
+
+// ErrInvalidFunnel - некорректное описание воронки
+var ErrInvalidFunnel = errors.New("Некорректная воронка")
+
+// FunnelPresets - готовые воронки. Шаг может состоять из нескольких
+// альтернатив через |: сессия проходит шаг, если выполнила любую из них.
+var FunnelPresets = map[string][]string{
+	"leads":    {"visit", "phone_click|contact|phone_contact|order"},
+	"calls":    {"visit", "phone_click"},
+	"contacts": {"visit", "event:form_start", "contact|phone_contact"},
+	"orders":   {"visit", "event:add_to_cart", "order"},
+}
+
+// funnelSource - откуда берутся сессии, выполнившие шаг
+type funnelSource struct {
+	table   string
+	where   string
+	args    []interface{}
+	hasDate bool // есть колонка date (YYYY-MM-DD) с индексом
+	hasBot  bool // есть колонка is_bot
+}
+
+// Шаги воронки. Посещения берутся из page_views: в visitor_stats нет
+// сессии, а просмотр страницы записывается при каждом посещении.
+var funnelSources = map[string]funnelSource{
+	"visit":         {table: "page_views", hasDate: true, hasBot: true},
+	"phone_click":   {table: "phone_click_stats", hasDate: true, hasBot: true},
+	"contact":       {table: "contact_forms"},
+	"phone_contact": {table: "phone_contacts"},
+	"order":         {table: "orders", where: "status <> ?", args: []interface{}{"cancelled"}},
+}
+
+// parseFunnelStep разбирает шаг: visit, phone_click, contact, phone_contact,
+// order, page:<путь>, event:<событие> или их комбинацию через |
+func parseFunnelStep(step string) ([]funnelSource, error) {
+	var sources []funnelSource
+	for _, alt := range strings.Split(step, "|") {
+		alt = strings.TrimSpace(alt)
+		switch {
+		case strings.HasPrefix(alt, "page:"):
+			sources = append(sources, funnelSource{
+				table: "page_views", where: "path = ?", args: []interface{}{CleanPath(strings.TrimPrefix(alt, "page:"))},
+				hasDate: true, hasBot: true,
+			})
+		case strings.HasPrefix(alt, "event:"):
+			name := strings.TrimPrefix(alt, "event:")
+			if _, ok := events.Lookup(name); !ok {
+				return nil, fmt.Errorf("%w: неизвестное событие %s", ErrInvalidFunnel, name)
+			}
+			sources = append(sources, funnelSource{
+				table: "client_events", where: "name = ?", args: []interface{}{name},
+				hasDate: true, hasBot: true,
+			})
+		default:
+			source, ok := funnelSources[alt]
+			if !ok {
+				return nil, fmt.Errorf("%w: неизвестный шаг %q", ErrInvalidFunnel, alt)
+			}
+			sources = append(sources, source)
+		}
+	}
+	return sources, nil
+}
+
+// Funnel считает, сколько сессий прошло каждый шаг воронки за период
+// from..to (YYYY-MM-DD включительно). Если ordered, шаг засчитывается
+// только когда он случился не раньше предыдущего.
+func Funnel(db *gorm.DB, steps []string, from, to string, ordered, humansOnly bool) ([]models.FunnelStep, error) {
+	if len(steps) < 2 || len(steps) > maxFunnelSteps {
+		return nil, fmt.Errorf("%w: нужно от 2 до %d шагов", ErrInvalidFunnel, maxFunnelSteps)
+	}
+	start, err := time.ParseInLocation("2006-01-02", from, time.Local)
+	if err != nil {
+		return nil, fmt.Errorf("%w: дата from должна быть в формате YYYY-MM-DD", ErrInvalidFunnel)
+	}
+	end, err := time.ParseInLocation("2006-01-02", to, time.Local)
+	if err != nil {
+		return nil, fmt.Errorf("%w: дата to должна быть в формате YYYY-MM-DD", ErrInvalidFunnel)
+	}
+	if end.Before(start) {
+		return nil, fmt.Errorf("%w: дата to раньше from", ErrInvalidFunnel)
+	}
+	if end.After(start.AddDate(0, 0, maxFunnelDays-1)) {
+		return nil, fmt.Errorf("%w: период не может быть длиннее %d дней", ErrInvalidFunnel, maxFunnelDays)
+	}
+	end = end.AddDate(0, 0, 1)
+
+	parsed := make([][]funnelSource, len(steps))
+	for i, step := range steps {
+		if parsed[i], err = parseFunnelStep(step); err != nil {
+			return nil, err
+		}
+	}
+
+	// reached - сессии, прошедшие предыдущий шаг, и время прохождения
+	var reached map[string]time.Time
+	result := make([]models.FunnelStep, 0, len(steps))
+	for i, sources := range parsed {
+		// Для первого шага и без учета порядка нужно только первое выполнение
+		firstOnly := i == 0 || !ordered
+		occurrences, err := funnelOccurrences(db, sources, from, to, start, end, humansOnly, firstOnly)
+		if err != nil {
+			return nil, err
+		}
+
+		next := make(map[string]time.Time)
+		for session, times := range occurrences {
+			if i == 0 {
+				next[session] = times[0]
+				continue
+			}
+			prev, ok := reached[session]
+			if !ok {
+				continue
+			}
+			if !ordered {
+				next[session] = prev
+				continue
+			}
+			// Первое выполнение шага не раньше предыдущего
+			j := sort.Search(len(times), func(k int) bool { return !times[k].Before(prev) })
+			if j < len(times) {
+				next[session] = times[j]
+			}
+		}
+		reached = next
+		result = append(result, models.FunnelStep{Step: steps[i], Sessions: int64(len(reached))})
+	}
+
+	first := result[0].Sessions
+	for i := range result {
+		step := &result[i]
+		step.ConversionRate = percent(step.Sessions, first)
+		if i == 0 {
+			step.StepConversionRate = 100
+			continue
+		}
+		prev := result[i-1].Sessions
+		step.StepConversionRate = percent(step.Sessions, prev)
+		step.DropOff = prev - step.Sessions
+		step.DropOffRate = percent(step.DropOff, prev)
+	}
+	return result, nil
+}
+
+// funnelOccurrences - время выполнения шага по сессиям, по возрастанию.
+// Если firstOnly, из базы берется только первое выполнение в каждой сессии.
+func funnelOccurrences(db *gorm.DB, sources []funnelSource, from, to string, start, end time.Time, humansOnly, firstOnly bool) (map[string][]time.Time, error) {
+	occurrences := make(map[string][]time.Time)
+	for _, source := range sources {
+		query := db.Table(source.table).Where("session_id <> ''")
+		if firstOnly {
+			query = query.Select("session_id, MIN(created_at) AS created_at").Group("session_id")
+		} else {
+			query = query.Select("session_id, created_at")
+		}
+		if source.hasDate {
+			query = query.Where("date BETWEEN ? AND ?", from, to)
+		} else {
+			query = query.Where("created_at >= ? AND created_at < ?", start, end)
+		}
+		if source.where != "" {
+			query = query.Where(source.where, source.args...)
+		}
+		if humansOnly && source.hasBot {
+			query = query.Where("is_bot = ?", false)
+		}
+
+		rows, err := query.Rows()
+		if err != nil {
+			return nil, err
+		}
+		for rows.Next() {
+			var session string
+			var createdAt sqlTime
+			if err := rows.Scan(&session, &createdAt); err != nil {
+				rows.Close()
+				return nil, err
+			}
+			occurrences[session] = append(occurrences[session], createdAt.Time)
+		}
+		err = rows.Err()
+		rows.Close()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	for _, times := range occurrences {
+		sort.Slice(times, func(i, j int) bool { return times[i].Before(times[j]) })
+	}
+	return occurrences, nil
+}
+
+// sqlTimeLayouts - форматы времени, в которых SQLite хранит created_at
+var sqlTimeLayouts = []string{
+	"2006-01-02 15:04:05.999999999-07:00",
+	"2006-01-02T15:04:05.999999999-07:00",
+	"2006-01-02 15:04:05.999999999",
+	"2006-01-02T15:04:05.999999999",
+	"2006-01-02",
+}
+
+// sqlTime - время из базы, в том числе результат MIN(created_at): SQLite
+// возвращает агрегат строкой, а не временем
+type sqlTime struct {
+	time.Time
+}
+
+// Scan реализует sql.Scanner
+func (t *sqlTime) Scan(value interface{}) error {
+	switch v := value.(type) {
+	case time.Time:
+		t.Time = v
+		return nil
+	case []byte:
+		return t.parse(string(v))
+	case string:
+		return t.parse(v)
+	}
+	return fmt.Errorf("время в неподдерживаемом формате: %T", value)
+}
+
+func (t *sqlTime) parse(s string) error {
+	for _, layout := range sqlTimeLayouts {
+		if parsed, err := time.Parse(layout, s); err == nil {
+			t.Time = parsed
+			return nil
+		}
+	}
+	return fmt.Errorf("время в неподдерживаемом формате: %q", s)
+}
+
+// percent - доля part от total в процентах с двумя знаками
+func percent(part, total int64) float64 {
+	if total == 0 {
+		return 0
+	}
+	return math.Round(float64(part)/float64(total)*10000) / 100
+}
@@ -0,0 +1,79 @@
+package analytics
+
+import (
+	"math"
+	"strings"
+
+	"texnousta-backend/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// SessionID - сессия просмотра: идентификатор от фронтенда или,
+// если его нет, IP посетителя в пределах дня (как раньше считались посетители)
+func SessionID(clientSession, ip, date string) string {
+	clientSession = strings.TrimSpace(clientSession)
+	if clientSession != "" && len(clientSession) <= 64 {
+		return clientSession
+	}
+	return "ip:" + date + ":" + ip
+}
+
+// PageStats строит отчет по страницам: популярные страницы, страницы входа
+// и выхода, число сессий и среднюю глубину просмотра
+func PageStats(db *gorm.DB, startDate string, limit int, humansOnly bool) (*models.PageStatsResponse, error) {
+	report := &models.PageStatsResponse{}
+	views := func() *gorm.DB {
+		query := db.Table("page_views").Where("page_views.date >= ?", startDate)
+		if humansOnly {
+			query = query.Where("page_views.is_bot = ?", false)
+		}
+		return query
+	}
+
+	var totals struct {
+		Views    int64
+		Sessions int64
+	}
+	if err := views().Select("COUNT(*) AS views, COUNT(DISTINCT session_id) AS sessions").
+		Scan(&totals).Error; err != nil {
+		return nil, err
+	}
+	report.TotalViews = totals.Views
+	report.Sessions = totals.Sessions
+	if totals.Sessions > 0 {
+		report.AvgPagesPerSession = math.Round(float64(totals.Views)/float64(totals.Sessions)*100) / 100
+	}
+
+	if err := views().Select("path, COUNT(*) AS views, COUNT(DISTINCT session_id) AS sessions").
+		Group("path").
+		Order("views DESC, path ASC").
+		Limit(limit).
+		Scan(&report.TopPages).Error; err != nil {
+		return nil, err
+	}
+
+	// Первый и последний просмотр сессии - по порядку записи
+	var err error
+	if report.EntryPages, err = boundaryPages(views, "MIN", limit); err != nil {
+		return nil, err
+	}
+	if report.ExitPages, err = boundaryPages(views, "MAX", limit); err != nil {
+		return nil, err
+	}
+	return report, nil
+}
+
+// boundaryPages - страницы, с которых сессии начинаются (MIN) или на которых заканчиваются (MAX)
+func boundaryPages(views func() *gorm.DB, aggregate string, limit int) ([]models.PageStat, error) {
+	boundary := views().Select(aggregate + "(page_views.id) AS id").Group("session_id")
+
+	var pages []models.PageStat
+	err := views().Select("page_views.path AS path, COUNT(*) AS views, COUNT(*) AS sessions").
+		Joins("JOIN (?) boundary ON boundary.id = page_views.id", boundary).
+		Group("page_views.path").
+		Order("views DESC, path ASC").
+		Limit(limit).
+		Scan(&pages).Error
+	return pages, err
+}
@@ -0,0 +1,83 @@
+package analytics
+
+import (
+	"net/url"
+	"strings"
+
+	"texnousta-backend/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// PlacementUnknown - расположение кнопки не передано (старый фронтенд)
+const PlacementUnknown = "unknown"
+
+// NormalizePlacement приводит расположение кнопки к единому виду: header,
+// Header и " header " считаются одним расположением
+func NormalizePlacement(placement string) string {
+	placement = strings.ToLower(strings.TrimSpace(placement))
+	if placement == "" {
+		return PlacementUnknown
+	}
+	if len(placement) > 50 {
+		placement = placement[:50]
+	}
+	return placement
+}
+
+// ReferrerPath - путь страницы из заголовка Referer
+func ReferrerPath(referrer string) string {
+	if referrer == "" {
+		return ""
+	}
+	u, err := url.Parse(referrer)
+	if err != nil {
+		return ""
+	}
+	return CleanPath(u.Path)
+}
+
+// PhoneClickBreakdown - клики по телефону по расположению кнопки, страницам
+// и товарам. Считается по сырым записям, поэтому покрывает только срок
+// хранения ANALYTICS_RETENTION_DAYS.
+func PhoneClickBreakdown(db *gorm.DB, startDate string, limit int, humansOnly bool) (placements, pages []models.PhoneClickGroup, products []models.PhoneClickProduct, err error) {
+	base := func() *gorm.DB {
+		query := db.Table("phone_click_stats").Where("phone_click_stats.date >= ?", startDate)
+		if humansOnly {
+			query = query.Where("phone_click_stats.is_bot = ?", false)
+		}
+		return query
+	}
+
+	// У записей до появления расположения кнопки поле пустое
+	placement := "COALESCE(NULLIF(placement, ''), '" + PlacementUnknown + "')"
+	err = base().
+		Select(placement + " AS key, COUNT(*) AS clicks, COUNT(DISTINCT session_id) AS sessions").
+		Group(placement).
+		Order("clicks DESC").
+		Scan(&placements).Error
+	if err != nil {
+		return
+	}
+
+	err = base().
+		Select("path AS key, COUNT(*) AS clicks, COUNT(DISTINCT session_id) AS sessions").
+		Where("path <> ''").
+		Group("path").
+		Order("clicks DESC").
+		Limit(limit).
+		Scan(&pages).Error
+	if err != nil {
+		return
+	}
+
+	err = base().
+		Select("phone_click_stats.product_id, COALESCE(products.name, '') AS name, COUNT(*) AS clicks, COUNT(DISTINCT phone_click_stats.session_id) AS sessions").
+		Joins("LEFT JOIN products ON products.id = phone_click_stats.product_id").
+		Where("phone_click_stats.product_id IS NOT NULL").
+		Group("phone_click_stats.product_id, products.name").
+		Order("clicks DESC").
+		Limit(limit).
+		Scan(&products).Error
+	return
+}
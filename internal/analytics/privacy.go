@@ -0,0 +1,332 @@
+package analytics
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"log"
+	"net"
+	"os"
+	"sync"
+	"time"
+
+	"texnousta-backend/internal/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Режимы приватности (ANALYTICS_PRIVACY_MODE)
+const (
+	PrivacyOff      = "off"      // IP хранится как есть
+	PrivacyHash     = "hash"     // хеш IP с солью, которая меняется каждый день (ключ посетителя - каждый месяц)
+	PrivacyTruncate = "truncate" // IPv4 без последнего октета, IPv6 - первые 48 бит (посетители различаются по хешу, как в hash)
+)
+
+// hashPrefix отличает хеш от IP
+const hashPrefix = "h:"
+
+// ipSessionPrefix - начало идентификатора сессии, построенного по IP (см. SessionID)
+const ipSessionPrefix = "ip:"
+
+// anonymizeBatch - сколько пар (IP, дата) обезличивается в одной транзакции
+const anonymizeBatch = 500
+
+// cachedSalt - соль текущего дня или месяца
+type cachedSalt struct {
+	key  string
+	salt string
+}
+
+var (
+	saltMu sync.Mutex
+	salts  = make(map[int]cachedSalt) // по длине ключа: день YYYY-MM-DD или месяц YYYY-MM
+)
+
+// PrivacyMode - текущий режим хранения IP
+func PrivacyMode() string {
+	switch mode := os.Getenv("ANALYTICS_PRIVACY_MODE"); mode {
+	case PrivacyHash, PrivacyTruncate:
+		return mode
+	}
+	return PrivacyOff
+}
+
+// StoredIP - IP в том виде, в котором он сохраняется в статистике.
+// Хеш в пределах дня одинаков для одного IP, поэтому уникальные посетители
+// за день считаются как раньше; между днями хеши не совпадают.
+func StoredIP(db *gorm.DB, ip string, now time.Time) string {
+	return protectIP(db, ip, now.Format("2006-01-02"))
+}
+
+// VisitorKey - ключ посетителя для подсчета уникальных и отсева повторных
+// визитов за день. В режимах hash и truncate это хеш IP с солью текущего
+// месяца: посещения одного IP в разные дни месяца совпадают, а после смены
+// месяца соль удаляется. Уникальные за несколько месяцев в этих режимах
+// считаются отдельно по каждому месяцу.
+func VisitorKey(db *gorm.DB, ip string, now time.Time) string {
+	if PrivacyMode() == PrivacyOff {
+		return ip
+	}
+	return saltedIP(db, ip, now.Format("2006-01"))
+}
+
+// SessionIP - IP, по которому строится сессия без идентификатора от клиента
+// (см. SessionID). В режиме truncate обрезанный адрес общий для соседних IP,
+// поэтому сессия строится по хешу с дневной солью, как в режиме hash.
+func SessionIP(db *gorm.DB, ip string, now time.Time) string {
+	if PrivacyMode() == PrivacyTruncate {
+		return saltedIP(db, ip, now.Format("2006-01-02"))
+	}
+	return StoredIP(db, ip, now)
+}
+
+// protectIP применяет режим приватности к IP; в режиме hash используется
+// соль периода saltKey
+func protectIP(db *gorm.DB, ip, saltKey string) string {
+	switch PrivacyMode() {
+	case PrivacyTruncate:
+		return TruncateIP(ip)
+	case PrivacyHash:
+		return saltedIP(db, ip, saltKey)
+	}
+	return ip
+}
+
+// saltedIP - хеш IP с солью периода saltKey
+func saltedIP(db *gorm.DB, ip, saltKey string) string {
+	salt, err := periodSalt(db, saltKey)
+	if err != nil {
+		// Без соли IP не сохраняем даже временно
+		log.Printf("Ошибка получения соли для IP: %v", err)
+		return TruncateIP(ip)
+	}
+	return HashIP(salt, ip)
+}
+
+// HashIP - хеш IP с солью
+func HashIP(salt, ip string) string {
+	sum := sha256.Sum256([]byte(salt + "|" + ip))
+	return hashPrefix + hex.EncodeToString(sum[:16])
+}
+
+// TruncateIP обнуляет последний октет IPv4 и все, кроме первых 48 бит, у IPv6
+func TruncateIP(ip string) string {
+	parsed := net.ParseIP(ip)
+	if parsed == nil {
+		return ""
+	}
+	if v4 := parsed.To4(); v4 != nil {
+		return v4.Mask(net.CIDRMask(24, 32)).String()
+	}
+	return parsed.Mask(net.CIDRMask(48, 128)).String()
+}
+
+// periodSalt возвращает соль на день (YYYY-MM-DD) или месяц (YYYY-MM),
+// создавая ее при первом обращении. Соль хранится в базе, чтобы все
+// экземпляры сервера хешировали одинаково; соли прошлых периодов удаляются.
+func periodSalt(db *gorm.DB, key string) (string, error) {
+	saltMu.Lock()
+	defer saltMu.Unlock()
+	if cached := salts[len(key)]; cached.key == key {
+		return cached.salt, nil
+	}
+
+	salt, err := randomSalt()
+	if err != nil {
+		return "", err
+	}
+	if err := db.Clauses(clause.OnConflict{Columns: []clause.Column{{Name: "date"}}, DoNothing: true}).
+		Create(&models.AnalyticsSalt{Date: key, Salt: salt}).Error; err != nil {
+		return "", err
+	}
+
+	var stored models.AnalyticsSalt
+	if err := db.Where("date = ?", key).First(&stored).Error; err != nil {
+		return "", err
+	}
+	// Длина отличает дневные соли от месячных ("2024-05" < "2024-05-10")
+	if err := db.Where("LENGTH(date) = ? AND date < ?", len(key), key).Delete(&models.AnalyticsSalt{}).Error; err != nil {
+		log.Printf("Ошибка удаления старых солей: %v", err)
+	}
+
+	salts[len(key)] = cachedSalt{key: key, salt: stored.Salt}
+	return stored.Salt, nil
+}
+
+func randomSalt() (string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(buf), nil
+}
+
+// ErrInvalidDate - дата не в формате YYYY-MM-DD
+var ErrInvalidDate = errors.New("Дата должна быть в формате YYYY-MM-DD")
+
+// AnonymizeResult - сколько записей изменено
+type AnonymizeResult struct {
+	Action  string `json:"action"`
+	Method  string `json:"method,omitempty"`
+	Before  string `json:"before,omitempty"`
+	Updated int64  `json:"updated"`
+}
+
+// ipTables - таблицы с IP: дата записи берется из столбца date или created_at;
+// visitorKey - в таблице есть ключ посетителя (см. VisitorKey)
+var ipTables = []struct {
+	name       string
+	dateCol    string
+	visitorKey bool
+}{
+	{"visitor_stats", "date", true},
+	{"phone_click_stats", "date", true},
+	{"page_views", "date", false},
+	{"product_views", "SUBSTR(CAST(created_at AS TEXT), 1, 10)", false},
+}
+
+// sessionTables - таблицы, где сессия может быть построена по IP
+var sessionTables = []string{
+	"page_views", "phone_click_stats", "contact_forms", "phone_contacts", "orders", "session_attributions", "client_events",
+}
+
+// Anonymize обезличивает IP в сохраненной статистике до даты before (пусто - все записи).
+// Для хеширования используется одноразовая соль: уникальные посетители за любой
+// период считаются как раньше, но восстановить IP после завершения нельзя.
+func Anonymize(db *gorm.DB, method, before string) (*AnonymizeResult, error) {
+	if method == "" {
+		method = PrivacyHash
+	}
+	if before != "" {
+		if _, err := time.Parse("2006-01-02", before); err != nil {
+			return nil, ErrInvalidDate
+		}
+	}
+
+	convert := TruncateIP
+	if method == PrivacyHash {
+		salt, err := randomSalt()
+		if err != nil {
+			return nil, err
+		}
+		convert = func(ip string) string { return HashIP(salt, ip) }
+	}
+
+	result := &AnonymizeResult{Action: "anonymize", Method: method, Before: before}
+	for _, table := range ipTables {
+		updated, err := anonymizeTable(db, table.name, table.dateCol, table.visitorKey, before, convert)
+		if err != nil {
+			return nil, err
+		}
+		result.Updated += updated
+	}
+	return result, nil
+}
+
+// anonymizeTable заменяет IP, ключи посетителей и построенные по IP сессии
+// в одной таблице. Ключ, совпадающий с IP (записи без режима приватности),
+// заменяется тем же значением, что и IP: соль одна на весь запуск, поэтому
+// уникальные за месяц сохраняются. Ключи-хеши режима truncate не меняются.
+func anonymizeTable(db *gorm.DB, table, dateCol string, visitorKey bool, before string, convert func(string) string) (int64, error) {
+	query := db.Table(table).
+		Select("DISTINCT ip_address AS ip, "+dateCol+" AS date").
+		Where("ip_address <> '' AND ip_address NOT LIKE ?", hashPrefix+"%")
+	if before != "" {
+		query = query.Where(dateCol+" < ?", before)
+	}
+
+	var pairs []struct {
+		IP   string
+		Date string
+	}
+	if err := query.Scan(&pairs).Error; err != nil {
+		return 0, err
+	}
+
+	var updated int64
+	for start := 0; start < len(pairs); start += anonymizeBatch {
+		end := start + anonymizeBatch
+		if end > len(pairs) {
+			end = len(pairs)
+		}
+
+		err := db.Transaction(func(tx *gorm.DB) error {
+			for _, p := range pairs[start:end] {
+				anonymous := convert(p.IP)
+				if anonymous == p.IP {
+					continue // уже обрезан
+				}
+
+				if visitorKey {
+					if err := tx.Table(table).
+						Where("ip_address = ? AND visitor_key = ? AND "+dateCol+" = ?", p.IP, p.IP, p.Date).
+						Update("visitor_key", anonymous).Error; err != nil {
+						return err
+					}
+				}
+				result := tx.Table(table).
+					Where("ip_address = ? AND "+dateCol+" = ?", p.IP, p.Date).
+					Update("ip_address", anonymous)
+				if result.Error != nil {
+					return result.Error
+				}
+				updated += result.RowsAffected
+
+				// Сессии вида ip:<дата>:<IP> во всех таблицах
+				oldSession := ipSessionPrefix + p.Date + ":" + p.IP
+				newSession := ipSessionPrefix + p.Date + ":" + anonymous
+				for _, sessionTable := range sessionTables {
+					if err := tx.Table(sessionTable).
+						Where("session_id = ?", oldSession).
+						Update("session_id", newSession).Error; err != nil {
+						return err
+					}
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			return updated, err
+		}
+	}
+	return updated, nil
+}
+
+// Purge удаляет сырые записи статистики до даты before (пусто - все записи).
+// Перед удалением сводные таблицы пересчитываются за всю историю, поэтому
+// отчеты по дням и месяцам сохраняются.
+func Purge(db *gorm.DB, before string) (*AnonymizeResult, error) {
+	cutoff := time.Now().AddDate(0, 0, 1)
+	if before != "" {
+		parsed, err := time.ParseInLocation("2006-01-02", before, time.Local)
+		if err != nil {
+			return nil, ErrInvalidDate
+		}
+		cutoff = parsed
+	}
+
+	for metric := range rollupSources {
+		for _, period := range []string{PeriodDay, PeriodMonth} {
+			if err := refreshRollup(db, metric, period, "", time.Now()); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	purged, err := purgeBefore(db, cutoff)
+	if err != nil {
+		return nil, err
+	}
+
+	for metric := range rollupSources {
+		if err := refreshWindows(db, metric, time.Now()); err != nil {
+			return nil, err
+		}
+		if err := refreshAllTime(db, metric, time.Now()); err != nil {
+			return nil, err
+		}
+	}
+	return &AnonymizeResult{Action: "purge", Before: before, Updated: purged}, nil
+}
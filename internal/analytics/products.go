@@ -0,0 +1,112 @@
+// Package analytics строит отчеты по событиям на сайте.
+package analytics
+
+import (
+	"math"
+	"sort"
+	"time"
+
+	"texnousta-backend/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// PopularWindow - период просмотров для сортировки каталога по популярности
+const PopularWindow = 30 * 24 * time.Hour
+
+// viewerKey - посетитель: сессия, а без нее IP
+const viewerKey = "COALESCE(NULLIF(session_id, ''), ip_address)"
+
+// ProductPopularity возвращает самые просматриваемые и самые заказываемые
+// товары с конверсией просмотров в заказы
+func ProductPopularity(db *gorm.DB, since time.Time, limit int) (viewed, ordered []models.ProductPopularity, err error) {
+	var views []models.ProductPopularity
+	if err := db.Raw(`SELECT product_id, COUNT(*) AS views, COUNT(DISTINCT `+viewerKey+`) AS unique_viewers
+		FROM product_views
+		WHERE created_at >= ?
+		GROUP BY product_id`, since).
+		Scan(&views).Error; err != nil {
+		return nil, nil, err
+	}
+
+	var orders []models.ProductPopularity
+	if err := db.Raw(`SELECT order_items.product_id AS product_id, COUNT(DISTINCT order_items.order_id) AS orders, SUM(order_items.quantity) AS units_sold
+		FROM order_items
+		JOIN orders ON orders.id = order_items.order_id
+		WHERE orders.created_at >= ? AND orders.status <> ?
+		GROUP BY order_items.product_id`, since, "cancelled").
+		Scan(&orders).Error; err != nil {
+		return nil, nil, err
+	}
+
+	stats := make(map[uint]*models.ProductPopularity)
+	for i := range views {
+		stats[views[i].ProductID] = &views[i]
+	}
+	for _, o := range orders {
+		s, ok := stats[o.ProductID]
+		if !ok {
+			s = &models.ProductPopularity{ProductID: o.ProductID}
+			stats[o.ProductID] = s
+		}
+		s.Orders = o.Orders
+		s.UnitsSold = o.UnitsSold
+	}
+
+	all := make([]models.ProductPopularity, 0, len(stats))
+	ids := make([]uint, 0, len(stats))
+	for _, s := range stats {
+		if s.UniqueViewers > 0 {
+			s.ConversionRate = math.Round(float64(s.Orders)/float64(s.UniqueViewers)*10000) / 100
+		}
+		all = append(all, *s)
+		ids = append(ids, s.ProductID)
+	}
+
+	if len(ids) > 0 {
+		var products []models.Product
+		if err := db.Select("id, name").Where("id IN ?", ids).Find(&products).Error; err != nil {
+			return nil, nil, err
+		}
+		names := make(map[uint]string, len(products))
+		for _, p := range products {
+			names[p.ID] = p.Name
+		}
+		for i := range all {
+			all[i].Name = names[all[i].ProductID]
+		}
+	}
+
+	viewed = topBy(all, limit, func(p models.ProductPopularity) int64 { return p.Views })
+	ordered = topBy(all, limit, func(p models.ProductPopularity) int64 { return p.Orders })
+	return viewed, ordered, nil
+}
+
+// topBy - первые limit товаров с ненулевым значением по убыванию
+func topBy(all []models.ProductPopularity, limit int, value func(models.ProductPopularity) int64) []models.ProductPopularity {
+	top := make([]models.ProductPopularity, 0, limit)
+	for _, p := range all {
+		if value(p) > 0 {
+			top = append(top, p)
+		}
+	}
+	sort.Slice(top, func(i, j int) bool {
+		if value(top[i]) != value(top[j]) {
+			return value(top[i]) > value(top[j])
+		}
+		return top[i].ProductID < top[j].ProductID
+	})
+	if len(top) > limit {
+		top = top[:limit]
+	}
+	return top
+}
+
+// OrderByPopularity сортирует выборку товаров по числу просмотров
+// за последние PopularWindow
+func OrderByPopularity(query *gorm.DB) *gorm.DB {
+	return query.
+		Joins(`LEFT JOIN (SELECT product_id, COUNT(*) AS views FROM product_views WHERE created_at >= ? GROUP BY product_id) popularity
+			ON popularity.product_id = products.id`, time.Now().Add(-PopularWindow)).
+		Order("COALESCE(popularity.views, 0) DESC, products.id DESC")
+}
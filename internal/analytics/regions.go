@@ -0,0 +1,48 @@
+package analytics
+
+import (
+	"math"
+
+	"texnousta-backend/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// RegionUnknown - регион не определен (нет базы GeoIP, частный IP или старая запись)
+const RegionUnknown = "unknown"
+
+// RegionStats - число записей таблицы по странам и регионам с долей от общего
+// числа. Для visitor_stats это посетители по дням, для phone_click_stats - клики.
+func RegionStats(db *gorm.DB, table, startDate string, humansOnly bool, limit int) ([]models.RegionStat, error) {
+	country := "COALESCE(NULLIF(country, ''), '" + RegionUnknown + "')"
+	region := "COALESCE(NULLIF(region, ''), '" + RegionUnknown + "')"
+
+	base := func() *gorm.DB {
+		query := db.Table(table).Where("date >= ?", startDate)
+		if humansOnly {
+			query = query.Where("is_bot = ?", false)
+		}
+		return query
+	}
+
+	var stats []models.RegionStat
+	if err := base().
+		Select(country + " AS country, " + region + " AS region, COUNT(*) AS count").
+		Group(country + ", " + region).
+		Order("count DESC").
+		Limit(limit).
+		Scan(&stats).Error; err != nil {
+		return nil, err
+	}
+
+	var total int64
+	if err := base().Count(&total).Error; err != nil {
+		return nil, err
+	}
+	for i := range stats {
+		if total > 0 {
+			stats[i].Share = math.Round(float64(stats[i].Count)/float64(total)*10000) / 100
+		}
+	}
+	return stats, nil
+}
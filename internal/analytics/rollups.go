@@ -0,0 +1,396 @@
+package analytics
+
+import (
+	"database/sql"
+	"log"
+	"os"
+	"strconv"
+	"time"
+
+	"texnousta-backend/internal/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Показатели предрассчитанной статистики
+const (
+	MetricVisitors    = "visitors"     // уникальные IP за период и просмотры страниц
+	MetricPhoneClicks = "phone_clicks" // уникальные IP и клики по телефону
+)
+
+// Периоды предрассчитанной статистики
+const (
+	PeriodDay    = "day"
+	PeriodMonth  = "month"
+	PeriodWindow = "window" // последние N дней, ключ - число дней
+	PeriodAll    = "all"    // за все время, ключ - "all"
+)
+
+// RollupWindows - за сколько последних дней уникальные посетители
+// считаются заранее (см. WindowDays)
+var RollupWindows = []int{1, 7, 14, 30, 90, 180, 365}
+
+// uniqueVisitorKey - по чему считаются уникальные посетители. Ключ посетителя
+// совпадает для одного IP в пределах месяца и в режиме hash, где IP
+// хешируется с дневной солью (см. VisitorKey); записи, сохраненные до
+// появления ключа, считаются по IP.
+const uniqueVisitorKey = "COALESCE(NULLIF(visitor_key, ''), ip_address)"
+
+// rollupSource - откуда считаются уникальные посетители и общее число событий
+type rollupSource struct {
+	uniques string
+	totals  string
+}
+
+var rollupSources = map[string]rollupSource{
+	MetricVisitors:    {uniques: "visitor_stats", totals: "page_views"},
+	MetricPhoneClicks: {uniques: "phone_click_stats", totals: "phone_click_stats"},
+}
+
+// StartMaintenance запускает фоновое обслуживание статистики: разбор
+// User-Agent у старых записей, затем с интервалом ANALYTICS_ROLLUP_INTERVAL
+// (по умолчанию 10 минут) пересчет сводных таблиц и удаление сырых записей
+// старше ANALYTICS_RETENTION_DAYS
+func StartMaintenance(db *gorm.DB) {
+	interval := 10 * time.Minute
+	if v := os.Getenv("ANALYTICS_ROLLUP_INTERVAL"); v != "" {
+		if d, err := time.ParseDuration(v); err == nil && d > 0 {
+			interval = d
+		} else {
+			log.Printf("Некорректный ANALYTICS_ROLLUP_INTERVAL %q, используется %s", v, interval)
+		}
+	}
+
+	go func() {
+		if err := BackfillUserAgents(db); err != nil {
+			log.Printf("Ошибка разбора User-Agent: %v", err)
+		}
+		for {
+			if err := RefreshRollups(db, time.Now()); err != nil {
+				log.Printf("Ошибка пересчета сводной статистики: %v", err)
+			} else if err := PurgeRaw(db, time.Now()); err != nil {
+				log.Printf("Ошибка удаления старой статистики: %v", err)
+			}
+			time.Sleep(interval)
+		}
+	}()
+}
+
+// RefreshRollups пересчитывает сводные таблицы. При первом запуске
+// считается вся история, далее - вчера и сегодня по дням и прошлый и
+// текущий месяц по месяцам. Уникальные посетители за последние дни
+// (RollupWindows) и за все время пересчитываются каждый раз.
+func RefreshRollups(db *gorm.DB, now time.Time) error {
+	var existing int64
+	if err := db.Model(&models.AnalyticsRollup{}).Count(&existing).Error; err != nil {
+		return err
+	}
+
+	daySince, monthSince := "", ""
+	if existing > 0 {
+		daySince = now.AddDate(0, 0, -1).Format("2006-01-02")
+		monthSince = previousMonthStart(now).Format("2006-01")
+	}
+
+	for metric := range rollupSources {
+		if err := refreshRollup(db, metric, PeriodDay, daySince, now); err != nil {
+			return err
+		}
+		if err := refreshRollup(db, metric, PeriodMonth, monthSince, now); err != nil {
+			return err
+		}
+		if err := refreshWindows(db, metric, now); err != nil {
+			return err
+		}
+		if err := refreshAllTime(db, metric, now); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// RefreshSince пересчитывает дневные и месячные сводные данные начиная с
+// даты since, не дожидаясь фоновой задачи. Отчет, построенный сразу после
+// окончания периода, иначе не увидел бы последние минуты периода.
+func RefreshSince(db *gorm.DB, since, now time.Time) error {
+	for metric := range rollupSources {
+		if err := refreshRollup(db, metric, PeriodDay, since.Format("2006-01-02"), now); err != nil {
+			return err
+		}
+		if err := refreshRollup(db, metric, PeriodMonth, since.Format("2006-01"), now); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func refreshRollup(db *gorm.DB, metric, period, since string, now time.Time) error {
+	source := rollupSources[metric]
+	key := "date"
+	if period == PeriodMonth {
+		key = "SUBSTR(date, 1, 7)"
+	}
+
+	var uniques []struct {
+		PeriodKey   string
+		UniqueAll   int64
+		UniqueHuman int64
+	}
+	if err := db.Raw(`SELECT `+key+` AS period_key, COUNT(DISTINCT `+uniqueVisitorKey+`) AS unique_all,
+			COUNT(DISTINCT CASE WHEN is_bot = ? THEN `+uniqueVisitorKey+` END) AS unique_human
+		FROM `+source.uniques+` WHERE date >= ? GROUP BY `+key, false, since).
+		Scan(&uniques).Error; err != nil {
+		return err
+	}
+
+	var totals []struct {
+		PeriodKey  string
+		TotalAll   int64
+		TotalHuman int64
+	}
+	if err := db.Raw(`SELECT `+key+` AS period_key, COUNT(*) AS total_all,
+			COALESCE(SUM(CASE WHEN is_bot = ? THEN 1 ELSE 0 END), 0) AS total_human
+		FROM `+source.totals+` WHERE date >= ? GROUP BY `+key, false, since).
+		Scan(&totals).Error; err != nil {
+		return err
+	}
+
+	rollups := make(map[string]*models.AnalyticsRollup)
+	get := func(periodKey string) *models.AnalyticsRollup {
+		r, ok := rollups[periodKey]
+		if !ok {
+			r = &models.AnalyticsRollup{Metric: metric, Period: period, PeriodKey: periodKey, UpdatedAt: now}
+			rollups[periodKey] = r
+		}
+		return r
+	}
+	for _, u := range uniques {
+		r := get(u.PeriodKey)
+		r.UniqueAll, r.UniqueHuman = u.UniqueAll, u.UniqueHuman
+	}
+	for _, t := range totals {
+		r := get(t.PeriodKey)
+		r.TotalAll, r.TotalHuman = t.TotalAll, t.TotalHuman
+	}
+	rows := make([]models.AnalyticsRollup, 0, len(rollups))
+	for _, r := range rollups {
+		rows = append(rows, *r)
+	}
+	return saveRollups(db, rows)
+}
+
+// refreshWindows пересчитывает уникальных посетителей и число событий за
+// последние дни из RollupWindows
+func refreshWindows(db *gorm.DB, metric string, now time.Time) error {
+	source := rollupSources[metric]
+	rows := make([]models.AnalyticsRollup, 0, len(RollupWindows))
+	for _, days := range RollupWindows {
+		since := now.AddDate(0, 0, -days).Format("2006-01-02")
+		r := models.AnalyticsRollup{Metric: metric, Period: PeriodWindow, PeriodKey: strconv.Itoa(days), UpdatedAt: now}
+		if err := countUniques(db, source.uniques, since, &r); err != nil {
+			return err
+		}
+		if err := db.Raw(`SELECT COUNT(*) AS total_all, COALESCE(SUM(CASE WHEN is_bot = ? THEN 1 ELSE 0 END), 0) AS total_human
+			FROM `+source.totals+` WHERE date >= ?`, false, since).
+			Row().Scan(&r.TotalAll, &r.TotalHuman); err != nil {
+			return err
+		}
+		rows = append(rows, r)
+	}
+	return saveRollups(db, rows)
+}
+
+// refreshAllTime пересчитывает итог за все время. Уникальные посетители
+// считаются по сырым записям, а за месяцы, сырые записи которых уже удалены
+// (ANALYTICS_RETENTION_DAYS), - по месячным сводным данным, поэтому итог не
+// уменьшается после удаления; посетитель, приходивший в несколько удаленных
+// месяцев, учитывается в каждом из них.
+func refreshAllTime(db *gorm.DB, metric string, now time.Time) error {
+	source := rollupSources[metric]
+
+	var oldest sql.NullString
+	if err := db.Table(source.uniques).Select("MIN(date)").Row().Scan(&oldest); err != nil {
+		return err
+	}
+	// Без сырых записей все месяцы берутся из сводных данных
+	firstRawMonth := "9999-12"
+	if oldest.Valid && len(oldest.String) >= 7 {
+		firstRawMonth = oldest.String[:7]
+	}
+
+	r := models.AnalyticsRollup{Metric: metric, Period: PeriodAll, PeriodKey: PeriodAll, UpdatedAt: now}
+	if err := countUniques(db, source.uniques, "", &r); err != nil {
+		return err
+	}
+
+	var purged, months struct {
+		UniqueAll   int64
+		UniqueHuman int64
+		TotalAll    int64
+		TotalHuman  int64
+	}
+	if err := db.Model(&models.AnalyticsRollup{}).
+		Select("COALESCE(SUM(unique_all), 0) AS unique_all, COALESCE(SUM(unique_human), 0) AS unique_human").
+		Where("metric = ? AND period = ? AND period_key < ?", metric, PeriodMonth, firstRawMonth).
+		Scan(&purged).Error; err != nil {
+		return err
+	}
+	if err := db.Model(&models.AnalyticsRollup{}).
+		Select("COALESCE(SUM(total_all), 0) AS total_all, COALESCE(SUM(total_human), 0) AS total_human").
+		Where("metric = ? AND period = ?", metric, PeriodMonth).
+		Scan(&months).Error; err != nil {
+		return err
+	}
+	r.UniqueAll += purged.UniqueAll
+	r.UniqueHuman += purged.UniqueHuman
+	r.TotalAll, r.TotalHuman = months.TotalAll, months.TotalHuman
+
+	return saveRollups(db, []models.AnalyticsRollup{r})
+}
+
+// countUniques считает уникальных посетителей в таблице с даты since
+func countUniques(db *gorm.DB, table, since string, r *models.AnalyticsRollup) error {
+	return db.Raw(`SELECT COUNT(DISTINCT `+uniqueVisitorKey+`),
+			COUNT(DISTINCT CASE WHEN is_bot = ? THEN `+uniqueVisitorKey+` END)
+		FROM `+table+` WHERE date >= ?`, false, since).
+		Row().Scan(&r.UniqueAll, &r.UniqueHuman)
+}
+
+// saveRollups записывает сводные данные, заменяя уже посчитанные
+func saveRollups(db *gorm.DB, rows []models.AnalyticsRollup) error {
+	if len(rows) == 0 {
+		return nil
+	}
+	return db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "metric"}, {Name: "period"}, {Name: "period_key"}},
+		DoUpdates: clause.AssignmentColumns([]string{"unique_all", "total_all", "unique_human", "total_human", "updated_at"}),
+	}).CreateInBatches(rows, 500).Error
+}
+
+// RetentionDays - сколько дней хранить сырые записи с IP (ANALYTICS_RETENTION_DAYS, 0 - бессрочно)
+func RetentionDays() int {
+	days, err := strconv.Atoi(os.Getenv("ANALYTICS_RETENTION_DAYS"))
+	if err != nil || days < 0 {
+		return 0
+	}
+	return days
+}
+
+// PurgeRaw удаляет сырые записи старше срока хранения. Удаляются только
+// целые месяцы, чтобы итог за все время (refreshAllTime) брал удаленные
+// месяцы из сводных данных без потерь. Записи прошлого и текущего месяца
+// не удаляются никогда: по ним пересчитываются месячные сводные данные.
+func PurgeRaw(db *gorm.DB, now time.Time) error {
+	days := RetentionDays()
+	if days == 0 {
+		return nil
+	}
+
+	cutoff := now.AddDate(0, 0, -days)
+	cutoff = time.Date(cutoff.Year(), cutoff.Month(), 1, 0, 0, 0, 0, cutoff.Location())
+	if keep := previousMonthStart(now); cutoff.After(keep) {
+		cutoff = keep
+	}
+
+	purged, err := purgeBefore(db, cutoff)
+	if err != nil {
+		return err
+	}
+	if purged > 0 {
+		log.Printf("Удалено %d записей статистики старше %s", purged, cutoff.Format("2006-01-02"))
+	}
+	return nil
+}
+
+// purgeBefore удаляет сырые записи с IP раньше cutoff
+func purgeBefore(db *gorm.DB, cutoff time.Time) (int64, error) {
+	cutoffDate := cutoff.Format("2006-01-02")
+
+	var purged int64
+	for _, table := range []string{"visitor_stats", "phone_click_stats", "page_views"} {
+		result := db.Exec("DELETE FROM "+table+" WHERE date < ?", cutoffDate)
+		if result.Error != nil {
+			return purged, result.Error
+		}
+		purged += result.RowsAffected
+	}
+	result := db.Where("created_at < ?", cutoff).Delete(&models.ProductView{})
+	if result.Error != nil {
+		return purged, result.Error
+	}
+	return purged + result.RowsAffected, nil
+}
+
+func previousMonthStart(now time.Time) time.Time {
+	return time.Date(now.Year(), now.Month()-1, 1, 0, 0, 0, 0, now.Location())
+}
+
+// WindowDays округляет число дней вверх до ближайшего периода из
+// RollupWindows (не больше самого длинного)
+func WindowDays(days int) int {
+	for _, window := range RollupWindows {
+		if days <= window {
+			return window
+		}
+	}
+	return RollupWindows[len(RollupWindows)-1]
+}
+
+// UniqueVisitors - уникальные посетители из сводной таблицы за последние
+// days дней (значение из RollupWindows) или за все время (days = 0).
+// До первого пересчета сводных данных возвращает 0.
+func UniqueVisitors(db *gorm.DB, metric string, days int, humansOnly bool) (int64, error) {
+	period, key := PeriodAll, PeriodAll
+	if days > 0 {
+		period, key = PeriodWindow, strconv.Itoa(days)
+	}
+	column := "unique_all"
+	if humansOnly {
+		column = "unique_human"
+	}
+
+	var counts []int64
+	err := db.Model(&models.AnalyticsRollup{}).
+		Where("metric = ? AND period = ? AND period_key = ?", metric, period, key).
+		Pluck(column, &counts).Error
+	if err != nil || len(counts) == 0 {
+		return 0, err
+	}
+	return counts[0], nil
+}
+
+// DailyStats - статистика по дням из сводной таблицы, новые дни первыми
+func DailyStats(db *gorm.DB, metric, startDate string, humansOnly bool) ([]models.DailyStat, error) {
+	var stats []models.DailyStat
+	err := rollupQuery(db, metric, PeriodDay, startDate, humansOnly).
+		Select("period_key AS date, " + rollupColumns(humansOnly)).
+		Scan(&stats).Error
+	return stats, err
+}
+
+// MonthlyStats - статистика по месяцам из сводной таблицы, новые месяцы первыми
+func MonthlyStats(db *gorm.DB, metric, startMonth string, humansOnly bool) ([]models.MonthlyStat, error) {
+	var stats []models.MonthlyStat
+	err := rollupQuery(db, metric, PeriodMonth, startMonth, humansOnly).
+		Select("period_key AS month, " + rollupColumns(humansOnly)).
+		Scan(&stats).Error
+	return stats, err
+}
+
+func rollupQuery(db *gorm.DB, metric, period, since string, humansOnly bool) *gorm.DB {
+	query := db.Model(&models.AnalyticsRollup{}).
+		Where("metric = ? AND period = ? AND period_key >= ?", metric, period, since).
+		Order("period_key DESC")
+	if humansOnly {
+		query = query.Where("unique_human > 0 OR total_human > 0")
+	}
+	return query
+}
+
+func rollupColumns(humansOnly bool) string {
+	if humansOnly {
+		return "unique_human AS unique_views, total_human AS total_views"
+	}
+	return "unique_all AS unique_views, total_all AS total_views"
+}
@@ -0,0 +1,144 @@
+// Package commerceml реализует обмен с 1С по протоколу CommerceML 2:
+// загрузку групп, товаров, цен и остатков (import.xml, offers.xml)
+// и выгрузку заказов сайта в 1С.
+//
+// Протокол обмена (type=catalog|sale):
+//
+//	checkauth - авторизация, в ответ имя и значение cookie сессии
+//	init      - параметры обмена (zip, file_limit)
+//	file      - прием файла, большие файлы приходят частями
+//	import    - загрузка принятого файла в базу
+//	query     - выгрузка новых заказов (type=sale)
+//	success   - подтверждение получения заказов (type=sale)
+package commerceml
+
+import (
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+// FileLimit - максимальный размер части файла, который 1С передает за один запрос
+const FileLimit = 10 << 20
+
+// ErrInvalidFilename - имя файла выходит за каталог обмена
+var ErrInvalidFilename = errors.New("Некорректное имя файла")
+
+// Dir - каталог для файлов обмена (ONEC_EXCHANGE_DIR)
+func Dir() string {
+	if dir := os.Getenv("ONEC_EXCHANGE_DIR"); dir != "" {
+		return dir
+	}
+	return filepath.Join(os.TempDir(), "texnousta-1c")
+}
+
+// Reset очищает каталог обмена перед новым сеансом
+func Reset() error {
+	dir := Dir()
+	if err := os.RemoveAll(dir); err != nil {
+		return err
+	}
+	return os.MkdirAll(dir, 0o755)
+}
+
+// SaveFile дописывает часть файла в каталог обмена
+func SaveFile(filename string, r io.Reader) error {
+	path, err := resolve(Dir(), filename)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(f, r); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// ImportFile загружает принятый файл в базу
+func ImportFile(db *gorm.DB, filename string, opts Options) (*Result, error) {
+	path, err := resolve(Dir(), filename)
+	if err != nil {
+		return nil, err
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	doc, err := Decode(f)
+	if err != nil {
+		return nil, err
+	}
+
+	if opts.Pictures == nil {
+		opts.Pictures = copyPicture
+	}
+	return Import(db, doc, opts)
+}
+
+// copyPicture переносит картинку из каталога обмена в загрузки сайта
+func copyPicture(name string) (string, error) {
+	src, err := resolve(Dir(), name)
+	if err != nil {
+		return "", err
+	}
+	if _, err := os.Stat(src); err != nil {
+		// Картинка не была передана в этом сеансе - оставляем прежнюю
+		if errors.Is(err, os.ErrNotExist) {
+			return "", nil
+		}
+		return "", err
+	}
+
+	uploads := os.Getenv("UPLOAD_PATH")
+	if uploads == "" {
+		uploads = "./uploads"
+	}
+	rel := filepath.ToSlash(filepath.Join("1c", filepath.Clean(name)))
+	dst := filepath.Join(uploads, filepath.FromSlash(rel))
+	if err := os.MkdirAll(filepath.Dir(dst), 0o755); err != nil {
+		return "", err
+	}
+
+	in, err := os.Open(src)
+	if err != nil {
+		return "", err
+	}
+	defer in.Close()
+	out, err := os.Create(dst)
+	if err != nil {
+		return "", err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return "", err
+	}
+	if err := out.Close(); err != nil {
+		return "", err
+	}
+	return "/uploads/" + rel, nil
+}
+
+// resolve возвращает путь к файлу внутри каталога обмена
+func resolve(dir, filename string) (string, error) {
+	filename = strings.ReplaceAll(filename, "\\", "/")
+	clean := filepath.Clean(filepath.FromSlash(filename))
+	if filename == "" || filepath.IsAbs(clean) || clean == ".." || strings.HasPrefix(clean, ".."+string(filepath.Separator)) {
+		return "", ErrInvalidFilename
+	}
+	return filepath.Join(dir, clean), nil
+}
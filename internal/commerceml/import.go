@@ -0,0 +1,365 @@
+package commerceml
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"texnousta-backend/internal/inventory"
+	"texnousta-backend/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// stockReason - основание движений остатка, проведенных обменом
+const stockReason = "Обмен с 1С"
+
+// defaultCategoryName - категория для товаров 1С без группы
+const defaultCategoryName = "Без категории"
+
+// Result - итог загрузки файла
+type Result struct {
+	CategoriesCreated int
+	CategoriesUpdated int
+	ProductsCreated   int
+	ProductsUpdated   int
+	ProductsDisabled  int
+	OffersApplied     int
+	OffersSkipped     int
+
+	// ProductIDs - товары, у которых мог измениться остаток
+	ProductIDs []uint
+}
+
+// Options - параметры загрузки
+type Options struct {
+	// PriceType - Ид или наименование типа цены 1С; пусто - первая цена предложения
+	PriceType string
+	// Pictures сохраняет картинку товара из каталога обмена и возвращает путь для Product.Image
+	Pictures  func(path string) (string, error)
+	ActorID   *uint
+	ActorName string
+}
+
+// Import загружает классификатор, каталог и пакет предложений из документа
+func Import(db *gorm.DB, doc *Document, opts Options) (*Result, error) {
+	result := &Result{}
+	err := db.Transaction(func(tx *gorm.DB) error {
+		categories := make(map[string]uint)
+		if doc.Classifier != nil {
+			if err := importGroups(tx, doc.Classifier.Groups, categories, result); err != nil {
+				return err
+			}
+		}
+		if doc.Catalog != nil {
+			if err := importItems(tx, doc.Catalog.Items, categories, opts, result); err != nil {
+				return err
+			}
+		}
+		if doc.OffersPackage != nil {
+			if err := importOffers(tx, doc.OffersPackage, opts, result); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// importGroups создает или переименовывает категории по группам 1С.
+// Вложенные группы становятся отдельными категориями.
+func importGroups(tx *gorm.DB, groups []Group, categories map[string]uint, result *Result) error {
+	for _, group := range groups {
+		if group.ID == "" {
+			continue
+		}
+
+		var category models.Category
+		err := tx.Where("external_id = ?", group.ID).First(&category).Error
+		switch {
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			category = models.Category{Name: group.Name, ExternalID: group.ID, IsActive: true}
+			if err := tx.Create(&category).Error; err != nil {
+				return err
+			}
+			result.CategoriesCreated++
+		case err != nil:
+			return err
+		case category.Name != group.Name:
+			if err := tx.Model(&category).Update("name", group.Name).Error; err != nil {
+				return err
+			}
+			result.CategoriesUpdated++
+		}
+		categories[group.ID] = category.ID
+
+		if err := importGroups(tx, group.Groups, categories, result); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// importItems создает или обновляет товары по Ид 1С. Цена и остаток
+// приходят отдельно в пакете предложений, поэтому новый товар создается
+// неактивным и включается при первой загрузке цены.
+func importItems(tx *gorm.DB, items []Item, categories map[string]uint, opts Options, result *Result) error {
+	for _, item := range items {
+		id := productID(item.ID)
+		if id == "" {
+			continue
+		}
+
+		categoryID, err := itemCategory(tx, item, categories)
+		if err != nil {
+			return err
+		}
+
+		image := ""
+		if len(item.Pictures) > 0 && opts.Pictures != nil {
+			image, err = opts.Pictures(item.Pictures[0])
+			if err != nil {
+				return fmt.Errorf("картинка товара %s: %w", item.ID, err)
+			}
+		}
+
+		deleted := item.Status == "Удален" || item.Deleted == "true"
+
+		var product models.Product
+		err = tx.Where("external_id = ?", id).First(&product).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			if deleted {
+				continue
+			}
+			product = models.Product{
+				Name:        item.Name,
+				Description: item.Description,
+				Image:       image,
+				CategoryID:  categoryID,
+				Brand:       item.Manufacturer,
+				SKU:         item.Article,
+				ExternalID:  id,
+			}
+			if err := tx.Create(&product).Error; err != nil {
+				return err
+			}
+			// is_active по умолчанию true в схеме - выключаем до загрузки цены
+			if err := tx.Model(&product).Update("is_active", false).Error; err != nil {
+				return err
+			}
+			result.ProductsCreated++
+			continue
+		}
+		if err != nil {
+			return err
+		}
+
+		if deleted {
+			if err := tx.Model(&product).Update("is_active", false).Error; err != nil {
+				return err
+			}
+			result.ProductsDisabled++
+			continue
+		}
+
+		updates := map[string]interface{}{
+			"name":        item.Name,
+			"description": item.Description,
+			"category_id": categoryID,
+			"brand":       item.Manufacturer,
+			"sku":         item.Article,
+		}
+		if image != "" {
+			updates["image"] = image
+		}
+		if err := tx.Model(&product).Updates(updates).Error; err != nil {
+			return err
+		}
+		result.ProductsUpdated++
+	}
+	return nil
+}
+
+// itemCategory находит категорию товара по первой группе 1С
+func itemCategory(tx *gorm.DB, item Item, categories map[string]uint) (uint, error) {
+	for _, groupID := range item.GroupIDs {
+		if id, ok := categories[groupID]; ok {
+			return id, nil
+		}
+
+		// Каталог может прийти без классификатора (только изменения)
+		var category models.Category
+		err := tx.Select("id").Where("external_id = ?", groupID).First(&category).Error
+		if err == nil {
+			categories[groupID] = category.ID
+			return category.ID, nil
+		}
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return 0, err
+		}
+	}
+
+	var category models.Category
+	err := tx.Where(models.Category{Name: defaultCategoryName}).
+		Attrs(models.Category{IsActive: true}).
+		FirstOrCreate(&category).Error
+	return category.ID, err
+}
+
+// importOffers обновляет цены и остатки товаров. Предложения характеристик
+// одного товара ("товар#характеристика") сводятся в одно: остатки
+// складываются, а ценой товара становится наименьшая цена характеристик.
+func importOffers(tx *gorm.DB, pkg *OffersPackage, opts Options, result *Result) error {
+	priceTypeID := resolvePriceType(pkg.PriceTypes, opts.PriceType)
+
+	type productOffer struct {
+		offers      int
+		price       float64
+		hasPrice    bool
+		quantity    int
+		hasQuantity bool
+	}
+	var order []string
+	byProduct := make(map[string]*productOffer)
+	for _, offer := range pkg.Offers {
+		id := productID(offer.ID)
+		if id == "" {
+			result.OffersSkipped++
+			continue
+		}
+		merged, ok := byProduct[id]
+		if !ok {
+			merged = &productOffer{}
+			byProduct[id] = merged
+			order = append(order, id)
+		}
+		merged.offers++
+		if price, ok := offerPrice(offer, priceTypeID); ok && (!merged.hasPrice || price < merged.price) {
+			merged.price, merged.hasPrice = price, true
+		}
+		if quantity, ok := offerQuantity(offer); ok {
+			merged.quantity += quantity
+			merged.hasQuantity = true
+		}
+	}
+
+	for _, id := range order {
+		merged := byProduct[id]
+
+		var product models.Product
+		err := tx.Where("external_id = ?", id).First(&product).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			result.OffersSkipped += merged.offers
+			continue
+		}
+		if err != nil {
+			return err
+		}
+
+		updates := make(map[string]interface{})
+		if merged.hasPrice {
+			updates["price"] = merged.price
+			// Товар, созданный обменом, включается при первой цене
+			if product.Price == 0 && merged.price > 0 {
+				updates["is_active"] = true
+			}
+		}
+		if len(updates) > 0 {
+			if err := tx.Model(&product).Updates(updates).Error; err != nil {
+				return err
+			}
+		}
+
+		if merged.hasQuantity {
+			_, err := inventory.SetLevel(tx, product.ID, merged.quantity, models.StockMovement{
+				Reason:    stockReason,
+				ActorID:   opts.ActorID,
+				ActorName: opts.ActorName,
+			})
+			if err != nil {
+				return fmt.Errorf("остаток товара %s: %w", id, err)
+			}
+			result.ProductIDs = append(result.ProductIDs, product.ID)
+		}
+		result.OffersApplied += merged.offers
+	}
+	return nil
+}
+
+// resolvePriceType находит Ид типа цены по Ид или наименованию из настроек.
+// Если такого типа в пакете нет, берется первая цена предложения.
+func resolvePriceType(types []PriceType, wanted string) string {
+	if wanted == "" {
+		return ""
+	}
+	for _, t := range types {
+		if t.ID == wanted || strings.EqualFold(t.Name, wanted) {
+			return t.ID
+		}
+	}
+	return ""
+}
+
+func offerPrice(offer Offer, priceTypeID string) (float64, bool) {
+	for _, price := range offer.Prices {
+		if priceTypeID != "" && price.PriceTypeID != priceTypeID {
+			continue
+		}
+		value, err := parseNumber(price.Value)
+		if err != nil {
+			return 0, false
+		}
+		return value, true
+	}
+	return 0, false
+}
+
+// offerQuantity - общий остаток: Количество или сумма по складам
+func offerQuantity(offer Offer) (int, bool) {
+	if offer.Quantity != "" {
+		quantity, err := parseNumber(offer.Quantity)
+		if err != nil {
+			return 0, false
+		}
+		return nonNegative(quantity), true
+	}
+	if len(offer.Stocks) == 0 {
+		return 0, false
+	}
+
+	total := 0.0
+	for _, stock := range offer.Stocks {
+		quantity, err := parseNumber(stock.Quantity)
+		if err != nil {
+			return 0, false
+		}
+		total += quantity
+	}
+	return nonNegative(total), true
+}
+
+// nonNegative округляет остаток вниз; отрицательный остаток 1С считается нулем
+func nonNegative(v float64) int {
+	if v < 0 {
+		return 0
+	}
+	return int(v)
+}
+
+// productID отбрасывает Ид характеристики: "товар#характеристика"
+func productID(id string) string {
+	id = strings.TrimSpace(id)
+	if i := strings.IndexByte(id, '#'); i >= 0 {
+		return id[:i]
+	}
+	return id
+}
+
+func parseNumber(v string) (float64, error) {
+	v = strings.NewReplacer(" ", "", " ", "", ",", ".").Replace(strings.TrimSpace(v))
+	return strconv.ParseFloat(v, 64)
+}
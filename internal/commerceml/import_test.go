@@ -0,0 +1,174 @@
+package commerceml
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"texnousta-backend/internal/inventory"
+	"texnousta-backend/internal/models"
+
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// examplesDir - примеры файлов обмена из examples/1c
+const examplesDir = "../../examples/1c"
+
+// newTestDB - база в памяти с таблицами каталога и складского учета
+func newTestDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// У каждого соединения своя база в памяти
+	sqlDB, err := db.DB()
+	if err != nil {
+		t.Fatal(err)
+	}
+	sqlDB.SetMaxOpenConns(1)
+	t.Cleanup(func() { sqlDB.Close() })
+
+	if err := db.AutoMigrate(&models.Category{}, &models.Product{}, &models.Warehouse{},
+		&models.ProductStock{}, &models.StockMovement{}); err != nil {
+		t.Fatal(err)
+	}
+	if err := inventory.EnsureOpeningBalances(db); err != nil {
+		t.Fatal(err)
+	}
+	return db
+}
+
+func decodeFile(t *testing.T, name string) *Document {
+	t.Helper()
+	file, err := os.Open(filepath.Join(examplesDir, name))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+
+	doc, err := Decode(file)
+	if err != nil {
+		t.Fatalf("Decode %s: %v", name, err)
+	}
+	return doc
+}
+
+func importDoc(t *testing.T, db *gorm.DB, doc *Document, opts Options) *Result {
+	t.Helper()
+	result, err := Import(db, doc, opts)
+	if err != nil {
+		t.Fatalf("Import: %v", err)
+	}
+	return result
+}
+
+func productByExternalID(t *testing.T, db *gorm.DB, id string) models.Product {
+	t.Helper()
+	var product models.Product
+	if err := db.Preload("Category").Where("external_id = ?", id).First(&product).Error; err != nil {
+		t.Fatalf("товар %s: %v", id, err)
+	}
+	return product
+}
+
+func TestDecodeExamples(t *testing.T) {
+	catalog := decodeFile(t, "import.xml")
+	if catalog.Classifier == nil || len(catalog.Classifier.Groups) != 2 {
+		t.Fatalf("классификатор = %+v", catalog.Classifier)
+	}
+	if catalog.Catalog == nil || len(catalog.Catalog.Items) != 2 {
+		t.Fatalf("каталог = %+v", catalog.Catalog)
+	}
+	item := catalog.Catalog.Items[0]
+	if item.ID != "a1b2c3d4-0001" || item.Article != "XM-14-256" || item.Manufacturer != "Xiaomi" {
+		t.Errorf("товар = %+v", item)
+	}
+
+	offers := decodeFile(t, "offers.xml")
+	if offers.OffersPackage == nil || len(offers.OffersPackage.Offers) != 2 || len(offers.OffersPackage.PriceTypes) != 2 {
+		t.Fatalf("пакет предложений = %+v", offers.OffersPackage)
+	}
+}
+
+func TestImportExamples(t *testing.T) {
+	db := newTestDB(t)
+
+	result := importDoc(t, db, decodeFile(t, "import.xml"), Options{})
+	if result.CategoriesCreated != 3 || result.ProductsCreated != 2 {
+		t.Errorf("каталог: %+v", result)
+	}
+
+	// До загрузки цены товар выключен
+	phone := productByExternalID(t, db, "a1b2c3d4-0001")
+	if phone.IsActive || phone.Category.Name != "Смартфоны" || phone.Brand != "Xiaomi" || phone.SKU != "XM-14-256" {
+		t.Errorf("товар после каталога = %+v", phone)
+	}
+
+	result = importDoc(t, db, decodeFile(t, "offers.xml"), Options{PriceType: "Розничная"})
+	if result.OffersApplied != 2 || result.OffersSkipped != 0 {
+		t.Errorf("предложения: %+v", result)
+	}
+
+	phone = productByExternalID(t, db, "a1b2c3d4-0001")
+	if !phone.IsActive || phone.Price != 9990000 || phone.Stock != 12 {
+		t.Errorf("смартфон = цена %v, остаток %d, активен %v", phone.Price, phone.Stock, phone.IsActive)
+	}
+	vacuum := productByExternalID(t, db, "a1b2c3d4-0002")
+	if vacuum.Price != 8450000 || vacuum.Stock != 5 || vacuum.Category.Name != "Пылесосы" {
+		t.Errorf("пылесос = цена %v, остаток %d, категория %q", vacuum.Price, vacuum.Stock, vacuum.Category.Name)
+	}
+
+	// Повторная загрузка тех же остатков не проводит движений
+	importDoc(t, db, decodeFile(t, "offers.xml"), Options{PriceType: "Розничная"})
+	var movements int64
+	db.Model(&models.StockMovement{}).Where("product_id = ?", phone.ID).Count(&movements)
+	if movements != 1 {
+		t.Errorf("движений по смартфону %d, ожидалось 1", movements)
+	}
+}
+
+func TestImportOfferCharacteristics(t *testing.T) {
+	db := newTestDB(t)
+	importDoc(t, db, decodeFile(t, "import.xml"), Options{})
+
+	const offers = `<?xml version="1.0" encoding="UTF-8"?>
+<КоммерческаяИнформация ВерсияСхемы="2.05">
+  <ПакетПредложений>
+    <Предложения>
+      <Предложение>
+        <Ид>a1b2c3d4-0001#black</Ид>
+        <Цены><Цена><ЦенаЗаЕдиницу>9990000</ЦенаЗаЕдиницу></Цена></Цены>
+        <Количество>4</Количество>
+      </Предложение>
+      <Предложение>
+        <Ид>a1b2c3d4-0001#white</Ид>
+        <Цены><Цена><ЦенаЗаЕдиницу>9490000</ЦенаЗаЕдиницу></Цена></Цены>
+        <Количество>3</Количество>
+      </Предложение>
+      <Предложение>
+        <Ид>unknown#1</Ид>
+        <Количество>1</Количество>
+      </Предложение>
+    </Предложения>
+  </ПакетПредложений>
+</КоммерческаяИнформация>`
+	doc, err := Decode(strings.NewReader(offers))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	result := importDoc(t, db, doc, Options{})
+	if result.OffersApplied != 2 || result.OffersSkipped != 1 {
+		t.Errorf("предложения: %+v", result)
+	}
+
+	// Остатки характеристик складываются, цена - наименьшая
+	phone := productByExternalID(t, db, "a1b2c3d4-0001")
+	if phone.Stock != 7 || phone.Price != 9490000 {
+		t.Errorf("смартфон = цена %v, остаток %d, ожидалось 9490000 и 7", phone.Price, phone.Stock)
+	}
+}
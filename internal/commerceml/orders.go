@@ -0,0 +1,139 @@
+package commerceml
+
+import (
+	"encoding/xml"
+	"io"
+	"strconv"
+	"time"
+
+	"texnousta-backend/internal/config"
+	"texnousta-backend/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// SchemaVersion - версия CommerceML, в которой выгружаются заказы
+const SchemaVersion = "2.05"
+
+// orderStatuses - статусы заказа для реквизита "Статус заказа"
+var orderStatuses = map[string]string{
+	"pending":   "Новый",
+	"confirmed": "Подтвержден",
+	"shipped":   "Отгружен",
+	"delivered": "Доставлен",
+	"cancelled": "Отменен",
+}
+
+// PendingOrders загружает заказы, еще не выгруженные в 1С
+func PendingOrders(db *gorm.DB) ([]models.Order, error) {
+	var orders []models.Order
+	err := db.Preload("User").
+		Preload("OrderItems.Product").
+		Preload("Warehouse").
+		Where("exported_at IS NULL").
+		Order("id ASC").
+		Find(&orders).Error
+	return orders, err
+}
+
+// MarkExported отмечает заказы как выгруженные
+func MarkExported(db *gorm.DB, ids []uint) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	return db.Model(&models.Order{}).
+		Where("id IN ? AND exported_at IS NULL", ids).
+		Update("exported_at", time.Now()).Error
+}
+
+// WriteOrders выгружает заказы в формате CommerceML
+func WriteOrders(w io.Writer, orders []models.Order) error {
+	doc := Document{
+		SchemaVersion: SchemaVersion,
+		Created:       time.Now().Format("2006-01-02T15:04:05"),
+	}
+	for _, order := range orders {
+		doc.OrderDocuments = append(doc.OrderDocuments, orderDocument(&order))
+	}
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	return enc.Encode(doc)
+}
+
+func orderDocument(order *models.Order) OrderDocument {
+	id := strconv.FormatUint(uint64(order.ID), 10)
+	doc := OrderDocument{
+		ID:        id,
+		Number:    id,
+		Date:      order.CreatedAt.Format("2006-01-02"),
+		Time:      order.CreatedAt.Format("15:04:05"),
+		Operation: "Заказ товара",
+		Role:      "Продавец",
+		Currency:  config.Currency(),
+		Rate:      "1",
+		Sum:       formatNumber(order.Total),
+		Comment:   order.Notes,
+		Counterparts: []Counterpart{{
+			ID:       "user-" + strconv.FormatUint(uint64(order.UserID), 10),
+			Name:     order.User.Name,
+			FullName: order.User.Name,
+			Role:     "Покупатель",
+			Address:  order.ShippingAddress,
+			Contacts: []Contact{
+				{Type: "Телефон рабочий", Value: order.Phone},
+				{Type: "Почта", Value: order.User.Email},
+			},
+		}},
+	}
+
+	for _, item := range order.OrderItems {
+		productID := item.Product.ExternalID
+		if productID == "" {
+			productID = "site-" + strconv.FormatUint(uint64(item.ProductID), 10)
+		}
+		doc.Items = append(doc.Items, OrderItem{
+			ID:        productID,
+			Article:   item.Product.SKU,
+			Name:      item.Product.Name,
+			Unit:      "шт",
+			UnitPrice: formatNumber(item.Price),
+			Quantity:  strconv.Itoa(item.Quantity),
+			Sum:       formatNumber(item.Price * float64(item.Quantity)),
+			Properties: []Value{
+				{Name: "ВидНоменклатуры", Value: "Товар"},
+				{Name: "ТипНоменклатуры", Value: "Товар"},
+			},
+		})
+	}
+
+	delivery := "Доставка"
+	if order.DeliveryType == "pickup" {
+		delivery = "Самовывоз"
+		if order.Warehouse != nil {
+			delivery += ": " + order.Warehouse.Name
+		}
+	}
+	status := orderStatuses[order.Status]
+	if status == "" {
+		status = order.Status
+	}
+	paid := "false"
+	if order.PaymentStatus == "paid" {
+		paid = "true"
+	}
+	doc.Properties = []Value{
+		{Name: "Статус заказа", Value: status},
+		{Name: "Способ доставки", Value: delivery},
+		{Name: "Заказ оплачен", Value: paid},
+		{Name: "Отменен", Value: strconv.FormatBool(order.Status == "cancelled")},
+	}
+	return doc
+}
+
+func formatNumber(v float64) string {
+	return strconv.FormatFloat(v, 'f', 2, 64)
+}
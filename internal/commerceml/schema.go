@@ -0,0 +1,170 @@
+package commerceml
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strings"
+
+	"golang.org/x/text/encoding/charmap"
+)
+
+// Document - корневой элемент файла обмена (import.xml, offers.xml, заказы)
+type Document struct {
+	XMLName        xml.Name        `xml:"КоммерческаяИнформация"`
+	SchemaVersion  string          `xml:"ВерсияСхемы,attr"`
+	Created        string          `xml:"ДатаФормирования,attr"`
+	Classifier     *Classifier     `xml:"Классификатор"`
+	Catalog        *Catalog        `xml:"Каталог"`
+	OffersPackage  *OffersPackage  `xml:"ПакетПредложений"`
+	OrderDocuments []OrderDocument `xml:"Документ"`
+}
+
+// Classifier - дерево групп товаров
+type Classifier struct {
+	ID     string  `xml:"Ид"`
+	Name   string  `xml:"Наименование"`
+	Groups []Group `xml:"Группы>Группа"`
+}
+
+// Group - группа товаров; соответствует категории
+type Group struct {
+	ID     string  `xml:"Ид"`
+	Name   string  `xml:"Наименование"`
+	Groups []Group `xml:"Группы>Группа"`
+}
+
+// Catalog - список товаров
+type Catalog struct {
+	ID                  string `xml:"Ид"`
+	ClassifierID        string `xml:"ИдКлассификатора"`
+	Name                string `xml:"Наименование"`
+	ContainsOnlyChanges string `xml:"СодержитТолькоИзменения,attr"`
+	Items               []Item `xml:"Товары>Товар"`
+}
+
+// Item - товар каталога
+type Item struct {
+	ID           string   `xml:"Ид"`
+	Article      string   `xml:"Артикул"`
+	Name         string   `xml:"Наименование"`
+	Description  string   `xml:"Описание"`
+	GroupIDs     []string `xml:"Группы>Ид"`
+	Manufacturer string   `xml:"Изготовитель>Наименование"`
+	Pictures     []string `xml:"Картинка"`
+	Status       string   `xml:"Статус,attr"`
+	Deleted      string   `xml:"ПометкаУдаления"`
+	Properties   []Value  `xml:"ЗначенияРеквизитов>ЗначениеРеквизита"`
+}
+
+// Value - значение реквизита
+type Value struct {
+	Name  string `xml:"Наименование"`
+	Value string `xml:"Значение"`
+}
+
+// OffersPackage - цены и остатки
+type OffersPackage struct {
+	ID                  string      `xml:"Ид"`
+	CatalogID           string      `xml:"ИдКаталога"`
+	ContainsOnlyChanges string      `xml:"СодержитТолькоИзменения,attr"`
+	PriceTypes          []PriceType `xml:"ТипыЦен>ТипЦены"`
+	Offers              []Offer     `xml:"Предложения>Предложение"`
+}
+
+// PriceType - тип цены (розничная, оптовая и т.п.)
+type PriceType struct {
+	ID       string `xml:"Ид"`
+	Name     string `xml:"Наименование"`
+	Currency string `xml:"Валюта"`
+}
+
+// Offer - предложение: цена и остаток товара
+type Offer struct {
+	ID       string       `xml:"Ид"`
+	Article  string       `xml:"Артикул"`
+	Name     string       `xml:"Наименование"`
+	Prices   []Price      `xml:"Цены>Цена"`
+	Quantity string       `xml:"Количество"`
+	Stocks   []OfferStock `xml:"Склад"`
+}
+
+// Price - цена предложения
+type Price struct {
+	PriceTypeID string `xml:"ИдТипаЦены"`
+	Value       string `xml:"ЦенаЗаЕдиницу"`
+	Currency    string `xml:"Валюта"`
+}
+
+// OfferStock - остаток на складе 1С
+type OfferStock struct {
+	WarehouseID string `xml:"ИдСклада,attr"`
+	Quantity    string `xml:"КоличествоНаСкладе,attr"`
+}
+
+// OrderDocument - заказ в формате CommerceML
+type OrderDocument struct {
+	ID           string        `xml:"Ид"`
+	Number       string        `xml:"Номер"`
+	Date         string        `xml:"Дата"`
+	Operation    string        `xml:"ХозОперация"`
+	Role         string        `xml:"Роль"`
+	Currency     string        `xml:"Валюта"`
+	Rate         string        `xml:"Курс"`
+	Sum          string        `xml:"Сумма"`
+	Counterparts []Counterpart `xml:"Контрагенты>Контрагент"`
+	Time         string        `xml:"Время"`
+	Comment      string        `xml:"Комментарий,omitempty"`
+	Items        []OrderItem   `xml:"Товары>Товар"`
+	Properties   []Value       `xml:"ЗначенияРеквизитов>ЗначениеРеквизита"`
+}
+
+// Counterpart - покупатель
+type Counterpart struct {
+	ID       string    `xml:"Ид"`
+	Name     string    `xml:"Наименование"`
+	Role     string    `xml:"Роль"`
+	FullName string    `xml:"ПолноеНаименование"`
+	Contacts []Contact `xml:"Контакты>Контакт"`
+	Address  string    `xml:"АдресРегистрации>Представление,omitempty"`
+}
+
+// Contact - контакт покупателя
+type Contact struct {
+	Type  string `xml:"Тип"`
+	Value string `xml:"Значение"`
+}
+
+// OrderItem - позиция заказа
+type OrderItem struct {
+	ID         string  `xml:"Ид"`
+	Article    string  `xml:"Артикул,omitempty"`
+	Name       string  `xml:"Наименование"`
+	Unit       string  `xml:"БазоваяЕдиница"`
+	UnitPrice  string  `xml:"ЦенаЗаЕдиницу"`
+	Quantity   string  `xml:"Количество"`
+	Sum        string  `xml:"Сумма"`
+	Properties []Value `xml:"ЗначенияРеквизитов>ЗначениеРеквизита"`
+}
+
+// Decode читает файл обмена. 1С может выгружать файлы в windows-1251.
+func Decode(r io.Reader) (*Document, error) {
+	decoder := xml.NewDecoder(r)
+	decoder.CharsetReader = charsetReader
+
+	var doc Document
+	if err := decoder.Decode(&doc); err != nil {
+		return nil, err
+	}
+	return &doc, nil
+}
+
+func charsetReader(label string, input io.Reader) (io.Reader, error) {
+	switch strings.ToLower(label) {
+	case "utf-8", "utf8":
+		return input, nil
+	case "windows-1251", "cp1251":
+		return charmap.Windows1251.NewDecoder().Reader(input), nil
+	}
+	return nil, fmt.Errorf("неподдерживаемая кодировка: %s", label)
+}
@@ -0,0 +1,89 @@
+// Package config содержит общие настройки магазина, которые берутся из
+// переменных окружения.
+package config
+
+import (
+	"os"
+	"strconv"
+	"strings"
+)
+
+// SiteURL - адрес сайта без завершающего слеша (SITE_URL, затем FRONTEND_URL)
+func SiteURL() string {
+	url := os.Getenv("SITE_URL")
+	if url == "" {
+		url = os.Getenv("FRONTEND_URL")
+	}
+	if url == "" {
+		url = "http://localhost:3000"
+	}
+	return strings.TrimRight(url, "/")
+}
+
+// ShopName - название магазина
+func ShopName() string {
+	return getenv("SHOP_NAME", "TexnoUsta")
+}
+
+// CompanyName - юридическое название компании
+func CompanyName() string {
+	return getenv("COMPANY_NAME", ShopName())
+}
+
+// Currency - код валюты цен в каталоге
+func Currency() string {
+	return getenv("CURRENCY", "UZS")
+}
+
+// ProductPath - путь карточки товара на сайте
+func ProductPath(id uint) string {
+	return "/products/" + strconv.FormatUint(uint64(id), 10)
+}
+
+// CategoryPath - путь страницы категории на сайте
+func CategoryPath(id uint) string {
+	return "/categories/" + strconv.FormatUint(uint64(id), 10)
+}
+
+// ProductURL - адрес карточки товара на сайте
+func ProductURL(id uint) string {
+	return SiteURL() + ProductPath(id)
+}
+
+// Locales - языки сайта (SITE_LOCALES через запятую); первый - основной
+func Locales() []string {
+	var locales []string
+	for _, locale := range strings.Split(getenv("SITE_LOCALES", "ru,uz"), ",") {
+		if locale = strings.TrimSpace(locale); locale != "" {
+			locales = append(locales, locale)
+		}
+	}
+	if len(locales) == 0 {
+		locales = []string{"ru"}
+	}
+	return locales
+}
+
+// LocalizedURL - адрес страницы на языке locale. Основной язык без префикса,
+// остальные - с префиксом: /uz/products/1
+func LocalizedURL(locale, path string) string {
+	if locale == "" || locale == Locales()[0] {
+		return SiteURL() + path
+	}
+	return SiteURL() + "/" + locale + path
+}
+
+// AbsoluteURL превращает путь вида /uploads/x.jpg в полный адрес на сайте
+func AbsoluteURL(path string) string {
+	if path == "" || strings.HasPrefix(path, "http://") || strings.HasPrefix(path, "https://") {
+		return path
+	}
+	return SiteURL() + "/" + strings.TrimLeft(path, "/")
+}
+
+func getenv(key, def string) string {
+	if v := os.Getenv(key); v != "" {
+		return v
+	}
+	return def
+}
@@ -3,6 +3,7 @@ package database
 import (
 	"log"
 	"os"
+	"texnousta-backend/internal/inventory"
 	"texnousta-backend/internal/models"
 
 	"gorm.io/driver/postgres"
@@ -56,6 +57,23 @@ func Init() {
 		&models.VisitorStat{},
 		&models.PhoneContact{},
 		&models.PhoneClickStat{},
+		&models.Promotion{},
+		&models.StockMovement{},
+		&models.Warehouse{},
+		&models.ProductStock{},
+		&models.StockSubscription{},
+		&models.AdminNotification{},
+		&models.MarketplaceCategory{},
+		&models.MarketplaceOrder{},
+		&models.MarketplaceOrderItem{},
+		&models.ProductView{},
+		&models.ProductRelation{},
+		&models.PageView{},
+		&models.SessionAttribution{},
+		&models.AnalyticsRollup{},
+		&models.AnalyticsSalt{},
+		&models.ClientEvent{},
+		&models.ReportSchedule{},
 	)
 
 	if err != nil {
@@ -66,6 +84,11 @@ func Init() {
 	
 	// Создание тестовых данных
 	createSeedData()
+
+	// Журнал движения товаров: основной склад и начальные остатки для товаров без движений
+	if err := inventory.EnsureOpeningBalances(DB); err != nil {
+		log.Printf("❌ Ошибка создания начальных остатков: %v", err)
+	}
 }
 
 // createSeedData создает начальные данные для тестирования
@@ -0,0 +1,190 @@
+// Package events принимает события с сайта и проверяет их по схемам.
+//
+// Каждое событие должно быть описано в реестре: какие свойства у него есть,
+// их типы и обязательность. Неизвестные события и свойства отклоняются,
+// чтобы в таблицу не попадал мусор и по свойствам можно было группировать.
+package events
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"regexp"
+	"sort"
+	"sync"
+)
+
+// Типы свойств
+const (
+	TypeString = "string"
+	TypeNumber = "number"
+	TypeBool   = "bool"
+)
+
+// maxStringLength - ограничение строкового свойства по умолчанию
+const maxStringLength = 500
+
+// ErrUnknownEvent - событие не описано в реестре
+var ErrUnknownEvent = errors.New("Неизвестное событие")
+
+// Property - описание свойства события
+type Property struct {
+	Type      string   `json:"type"`
+	Required  bool     `json:"required,omitempty"`
+	Enum      []string `json:"enum,omitempty"`       // допустимые значения строки
+	MaxLength int      `json:"max_length,omitempty"` // для строк, по умолчанию 500
+}
+
+// Schema - описание события
+type Schema struct {
+	Name        string              `json:"name"`
+	Description string              `json:"description"`
+	Properties  map[string]Property `json:"properties"`
+}
+
+var (
+	mu       sync.RWMutex
+	registry = make(map[string]Schema)
+)
+
+func init() {
+	Register(Schema{
+		Name:        "add_to_cart",
+		Description: "Товар добавлен в корзину",
+		Properties: map[string]Property{
+			"product_id": {Type: TypeNumber, Required: true},
+			"quantity":   {Type: TypeNumber},
+			"price":      {Type: TypeNumber},
+			"source":     {Type: TypeString, Enum: []string{"card", "catalog", "related", "search", "favorites"}},
+		},
+	})
+	Register(Schema{
+		Name:        "banner_click",
+		Description: "Клик по баннеру",
+		Properties: map[string]Property{
+			"banner_id":  {Type: TypeString, Required: true, MaxLength: 100},
+			"position":   {Type: TypeString, MaxLength: 50},
+			"target_url": {Type: TypeString},
+		},
+	})
+	Register(Schema{
+		Name:        "filter_used",
+		Description: "Применен фильтр каталога",
+		Properties: map[string]Property{
+			"filter":      {Type: TypeString, Required: true, MaxLength: 50},
+			"value":       {Type: TypeString, MaxLength: 200},
+			"category_id": {Type: TypeNumber},
+		},
+	})
+	Register(Schema{
+		Name:        "form_start",
+		Description: "Начато заполнение формы",
+		Properties: map[string]Property{
+			"form": {Type: TypeString, Required: true, Enum: []string{"contact", "quick_contact", "phone_contact", "order", "register"}},
+		},
+	})
+}
+
+// validName - допустимые имена событий и свойств. Имена свойств
+// подставляются в SQL при группировке, поэтому других символов быть не должно.
+var validName = regexp.MustCompile(`^[a-z][a-z0-9_]{0,49}$`)
+
+// Register добавляет или заменяет схему события
+func Register(schema Schema) {
+	if !validName.MatchString(schema.Name) {
+		panic("events: недопустимое имя события " + schema.Name)
+	}
+	for key := range schema.Properties {
+		if !validName.MatchString(key) {
+			panic("events: недопустимое имя свойства " + schema.Name + "." + key)
+		}
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	registry[schema.Name] = schema
+}
+
+// Lookup возвращает схему события
+func Lookup(name string) (Schema, bool) {
+	mu.RLock()
+	defer mu.RUnlock()
+	schema, ok := registry[name]
+	return schema, ok
+}
+
+// Schemas - все зарегистрированные схемы по имени
+func Schemas() []Schema {
+	mu.RLock()
+	defer mu.RUnlock()
+	schemas := make([]Schema, 0, len(registry))
+	for _, schema := range registry {
+		schemas = append(schemas, schema)
+	}
+	sort.Slice(schemas, func(i, j int) bool { return schemas[i].Name < schemas[j].Name })
+	return schemas
+}
+
+// Validate проверяет свойства события по схеме
+func Validate(name string, properties map[string]interface{}) error {
+	schema, ok := Lookup(name)
+	if !ok {
+		return fmt.Errorf("%w: %s", ErrUnknownEvent, name)
+	}
+
+	for key, prop := range schema.Properties {
+		if _, present := properties[key]; !present && prop.Required {
+			return fmt.Errorf("Не указано обязательное свойство %s", key)
+		}
+	}
+
+	for key, value := range properties {
+		prop, ok := schema.Properties[key]
+		if !ok {
+			return fmt.Errorf("Неизвестное свойство %s", key)
+		}
+		if err := prop.check(value); err != nil {
+			return fmt.Errorf("Свойство %s: %w", key, err)
+		}
+	}
+	return nil
+}
+
+func (p Property) check(value interface{}) error {
+	switch p.Type {
+	case TypeNumber:
+		n, ok := value.(float64)
+		if !ok || math.IsNaN(n) || math.IsInf(n, 0) {
+			return errors.New("ожидается число")
+		}
+	case TypeBool:
+		if _, ok := value.(bool); !ok {
+			return errors.New("ожидается true или false")
+		}
+	case TypeString:
+		s, ok := value.(string)
+		if !ok {
+			return errors.New("ожидается строка")
+		}
+		limit := p.MaxLength
+		if limit == 0 {
+			limit = maxStringLength
+		}
+		if len(s) > limit {
+			return fmt.Errorf("длиннее %d символов", limit)
+		}
+		if len(p.Enum) > 0 && !contains(p.Enum, s) {
+			return fmt.Errorf("допустимые значения: %v", p.Enum)
+		}
+	}
+	return nil
+}
+
+func contains(values []string, s string) bool {
+	for _, v := range values {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
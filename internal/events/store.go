@@ -0,0 +1,134 @@
+package events
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"texnousta-backend/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// Группировки отчета, кроме группировки по свойству
+const (
+	GroupName = "name"
+	GroupDate = "date"
+	GroupPath = "path"
+)
+
+// propertyPrefix - префикс группировки по свойству: property:product_id
+const propertyPrefix = "property:"
+
+// maxClockSkew - насколько время события на клиенте может расходиться с сервером
+const maxClockSkew = 24 * time.Hour
+
+// ErrInvalidGroup - группировка не поддерживается
+var ErrInvalidGroup = errors.New("Некорректная группировка")
+
+// NewEvent собирает запись события. Время клиента учитывается, если оно не
+// в будущем и не старше суток: пачка может отправиться с задержкой, но
+// подделать дату задним числом нельзя.
+func NewEvent(input models.ClientEventInput, sessionID, path string, isBot bool, now time.Time) (models.ClientEvent, error) {
+	properties := "{}"
+	if len(input.Properties) > 0 {
+		data, err := json.Marshal(input.Properties)
+		if err != nil {
+			return models.ClientEvent{}, err
+		}
+		properties = string(data)
+	}
+
+	at := now
+	if input.Timestamp != nil && !input.Timestamp.After(now) && now.Sub(*input.Timestamp) <= maxClockSkew {
+		at = input.Timestamp.In(now.Location())
+	}
+
+	return models.ClientEvent{
+		Name:       input.Name,
+		SessionID:  sessionID,
+		Path:       path,
+		Properties: properties,
+		IsBot:      isBot,
+		Date:       at.Format("2006-01-02"),
+		CreatedAt:  at,
+	}, nil
+}
+
+// Save сохраняет пачку событий одним запросом
+func Save(db *gorm.DB, events []models.ClientEvent) error {
+	if len(events) == 0 {
+		return nil
+	}
+	return db.CreateInBatches(events, 100).Error
+}
+
+// Query - параметры отчета по событиям
+type Query struct {
+	Name       string // пусто - все события
+	GroupBy    string // name, date, path или property:<свойство>
+	StartDate  string // YYYY-MM-DD
+	HumansOnly bool
+	Limit      int
+}
+
+// Stats считает события и уникальные сессии с группировкой. Группировать
+// по свойству можно только внутри одного события, и свойство должно быть
+// описано в его схеме - так имя свойства никогда не попадает в SQL от клиента.
+func Stats(db *gorm.DB, q Query) ([]models.ClientEventStat, error) {
+	key, err := groupExpression(db, q)
+	if err != nil {
+		return nil, err
+	}
+
+	query := db.Model(&models.ClientEvent{}).
+		Select(key+" AS key, COUNT(*) AS count, COUNT(DISTINCT session_id) AS sessions").
+		Where("date >= ?", q.StartDate).
+		Group(key).
+		Limit(q.Limit)
+	if q.Name != "" {
+		query = query.Where("name = ?", q.Name)
+	}
+	if q.HumansOnly {
+		query = query.Where("is_bot = ?", false)
+	}
+	if q.GroupBy == GroupDate {
+		query = query.Order("key DESC")
+	} else {
+		query = query.Order("count DESC")
+	}
+
+	var stats []models.ClientEventStat
+	err = query.Scan(&stats).Error
+	return stats, err
+}
+
+func groupExpression(db *gorm.DB, q Query) (string, error) {
+	switch q.GroupBy {
+	case GroupName, GroupDate, GroupPath:
+		return q.GroupBy, nil
+	}
+
+	property := strings.TrimPrefix(q.GroupBy, propertyPrefix)
+	if property == q.GroupBy || property == "" {
+		return "", fmt.Errorf("%w: %s", ErrInvalidGroup, q.GroupBy)
+	}
+	if q.Name == "" {
+		return "", fmt.Errorf("%w: для группировки по свойству укажите событие", ErrInvalidGroup)
+	}
+	schema, ok := Lookup(q.Name)
+	if !ok {
+		return "", fmt.Errorf("%w: %s", ErrUnknownEvent, q.Name)
+	}
+	if _, ok := schema.Properties[property]; !ok {
+		return "", fmt.Errorf("%w: у события %s нет свойства %s", ErrInvalidGroup, q.Name, property)
+	}
+
+	// Имя свойства взято из схемы, Register допускает только [a-z0-9_]
+	if db.Dialector.Name() == "postgres" {
+		return "CAST(properties AS jsonb) ->> '" + property + "'", nil
+	}
+	return "CAST(json_extract(properties, '$." + property + "') AS TEXT)", nil
+}
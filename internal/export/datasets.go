@@ -0,0 +1,321 @@
+package export
+
+import (
+	"math"
+	"sort"
+
+	"texnousta-backend/internal/analytics"
+	"texnousta-backend/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// timeLayout - формат даты и времени в выгрузках
+const timeLayout = "2006-01-02 15:04:05"
+
+// Datasets - доступные выгрузки
+var Datasets = []Dataset{
+	{
+		Name:    "visitors-daily",
+		Title:   "Посетители по дням",
+		Columns: []string{"Дата", "Уникальные посетители", "Просмотры страниц"},
+		Traffic: true,
+		Rows:    rollupRows(analytics.MetricVisitors, analytics.PeriodDay),
+		Summary: rollupSummary(analytics.MetricVisitors, analytics.PeriodDay, "Посетители (сумма по дням)", "Просмотры страниц"),
+	},
+	{
+		Name:    "visitors-monthly",
+		Title:   "Посетители по месяцам",
+		Columns: []string{"Месяц", "Уникальные посетители", "Просмотры страниц"},
+		Traffic: true,
+		Rows:    rollupRows(analytics.MetricVisitors, analytics.PeriodMonth),
+		Summary: rollupSummary(analytics.MetricVisitors, analytics.PeriodMonth, "Посетители (сумма по месяцам)", "Просмотры страниц"),
+	},
+	{
+		Name:  "phone-clicks",
+		Title: "Клики по телефону",
+		Columns: []string{
+			"Дата и время", "Расположение", "Кнопка", "Страница", "ID товара", "Товар",
+			"Страна", "Регион", "Город", "Устройство", "ОС", "Браузер", "Бот", "Сессия",
+		},
+		Traffic: true,
+		Rows:    phoneClickRows,
+		Summary: phoneClickSummary,
+	},
+	{
+		Name:    "phone-contacts",
+		Title:   "Оставленные телефоны",
+		Columns: []string{"ID", "Дата и время", "Телефон", "Сессия"},
+		Rows:    phoneContactRows,
+		Summary: phoneContactSummary,
+	},
+	{
+		Name:    "contacts",
+		Title:   "Обращения через контактные формы",
+		Columns: []string{"ID", "Дата и время", "Имя", "Телефон", "Email", "Тема", "Сообщение", "Прочитано", "Сессия"},
+		Rows:    contactRows,
+		Summary: contactSummary,
+	},
+}
+
+// eachRow читает результат запроса по одной записи в dest и вызывает fn
+func eachRow(query *gorm.DB, dest interface{}, fn func() error) error {
+	rows, err := query.Rows()
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		if err := query.ScanRows(rows, dest); err != nil {
+			return err
+		}
+		if err := fn(); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+// rollupPeriod - ключи периода сводной таблицы для фильтра
+func rollupPeriod(period string, f Filter) (string, string) {
+	if period == analytics.PeriodMonth {
+		return f.From[:7], f.To[:7]
+	}
+	return f.From, f.To
+}
+
+func rollupQuery(db *gorm.DB, metric, period string, f Filter) *gorm.DB {
+	unique, total := "unique_all", "total_all"
+	if f.HumansOnly {
+		unique, total = "unique_human", "total_human"
+	}
+	from, to := rollupPeriod(period, f)
+	return db.Model(&models.AnalyticsRollup{}).
+		Select("period_key, "+unique+" AS unique_views, "+total+" AS total_views").
+		Where("metric = ? AND period = ? AND period_key BETWEEN ? AND ?", metric, period, from, to).
+		Order("period_key ASC")
+}
+
+// rollupRows - строки из сводной таблицы. Просмотров не может быть меньше
+// посетителей: до появления просмотров страниц учитывался только первый визит.
+func rollupRows(metric, period string) func(*gorm.DB, Filter, func([]interface{}) error) error {
+	return func(db *gorm.DB, f Filter, emit func([]interface{}) error) error {
+		var row struct {
+			PeriodKey   string
+			UniqueViews int64
+			TotalViews  int64
+		}
+		return eachRow(rollupQuery(db, metric, period, f), &row, func() error {
+			total := row.TotalViews
+			if total < row.UniqueViews {
+				total = row.UniqueViews
+			}
+			return emit([]interface{}{row.PeriodKey, row.UniqueViews, total})
+		})
+	}
+}
+
+func rollupSummary(metric, period, uniqueTitle, totalTitle string) func(*gorm.DB, Filter) ([]Section, error) {
+	return func(db *gorm.DB, f Filter) ([]Section, error) {
+		var rows []struct {
+			UniqueViews int64
+			TotalViews  int64
+		}
+		if err := rollupQuery(db, metric, period, f).Scan(&rows).Error; err != nil {
+			return nil, err
+		}
+
+		var unique, total, best int64
+		for _, r := range rows {
+			views := r.TotalViews
+			if views < r.UniqueViews {
+				views = r.UniqueViews
+			}
+			unique += r.UniqueViews
+			total += views
+			if r.UniqueViews > best {
+				best = r.UniqueViews
+			}
+		}
+		var average float64
+		if len(rows) > 0 {
+			average = float64(unique) / float64(len(rows))
+		}
+
+		return []Section{{
+			Title:   "Итого",
+			Columns: []string{"Показатель", "Значение"},
+			Rows: [][]interface{}{
+				{uniqueTitle, unique},
+				{totalTitle, total},
+				{"Периодов с данными", len(rows)},
+				{"В среднем за период", round2(average)},
+				{"Максимум за период", best},
+			},
+		}}, nil
+	}
+}
+
+func phoneClickQuery(db *gorm.DB, f Filter) *gorm.DB {
+	query := db.Table("phone_click_stats").
+		Where("phone_click_stats.date BETWEEN ? AND ?", f.From, f.To)
+	if f.HumansOnly {
+		query = query.Where("phone_click_stats.is_bot = ?", false)
+	}
+	return query
+}
+
+func phoneClickRows(db *gorm.DB, f Filter, emit func([]interface{}) error) error {
+	var row struct {
+		models.PhoneClickStat
+		ProductName string
+	}
+	query := phoneClickQuery(db, f).
+		Select("phone_click_stats.*, COALESCE(products.name, '') AS product_name").
+		Joins("LEFT JOIN products ON products.id = phone_click_stats.product_id").
+		Order("phone_click_stats.id ASC")
+	return eachRow(query, &row, func() error {
+		click := row.PhoneClickStat
+		var productID interface{}
+		if click.ProductID != nil {
+			productID = *click.ProductID
+		}
+		err := emit([]interface{}{
+			click.CreatedAt.Local().Format(timeLayout), click.Placement, click.ButtonID, click.Path, productID, row.ProductName,
+			click.Country, click.Region, click.City, click.DeviceType, click.OS, click.Browser, click.IsBot, click.SessionID,
+		})
+		row.ProductID = nil
+		return err
+	})
+}
+
+func phoneClickSummary(db *gorm.DB, f Filter) ([]Section, error) {
+	daily, err := groupCounts(phoneClickQuery(db, f), "date", "date ASC")
+	if err != nil {
+		return nil, err
+	}
+	placements, err := groupCounts(phoneClickQuery(db, f), "COALESCE(NULLIF(placement, ''), '"+analytics.PlacementUnknown+"')", "count DESC")
+	if err != nil {
+		return nil, err
+	}
+	regions, err := groupCounts(phoneClickQuery(db, f), "COALESCE(NULLIF(region, ''), '"+analytics.RegionUnknown+"')", "count DESC")
+	if err != nil {
+		return nil, err
+	}
+
+	var total int64
+	for _, row := range daily {
+		total += row[1].(int64)
+	}
+	return []Section{
+		{Title: "Итого", Columns: []string{"Показатель", "Значение"}, Rows: [][]interface{}{{"Кликов по телефону", total}}},
+		{Title: "По дням", Columns: []string{"Дата", "Клики"}, Rows: daily},
+		{Title: "По расположению кнопки", Columns: []string{"Расположение", "Клики"}, Rows: placements},
+		{Title: "По регионам", Columns: []string{"Регион", "Клики"}, Rows: regions},
+	}, nil
+}
+
+func phoneContactQuery(db *gorm.DB, f Filter) *gorm.DB {
+	return db.Model(&models.PhoneContact{}).Where("created_at >= ? AND created_at < ?", f.start, f.end)
+}
+
+func phoneContactRows(db *gorm.DB, f Filter, emit func([]interface{}) error) error {
+	var row models.PhoneContact
+	return eachRow(phoneContactQuery(db, f).Order("id ASC"), &row, func() error {
+		return emit([]interface{}{row.ID, row.CreatedAt.Local().Format(timeLayout), row.Phone, row.SessionID})
+	})
+}
+
+func phoneContactSummary(db *gorm.DB, f Filter) ([]Section, error) {
+	var rows []models.PhoneContact
+	if err := phoneContactQuery(db, f).Select("created_at").Find(&rows).Error; err != nil {
+		return nil, err
+	}
+	daily := countByDay(len(rows), func(i int) string { return rows[i].CreatedAt.Local().Format("2006-01-02") })
+	return []Section{
+		{Title: "Итого", Columns: []string{"Показатель", "Значение"}, Rows: [][]interface{}{{"Оставлено телефонов", len(rows)}}},
+		{Title: "По дням", Columns: []string{"Дата", "Телефоны"}, Rows: daily},
+	}, nil
+}
+
+func contactQuery(db *gorm.DB, f Filter) *gorm.DB {
+	return db.Model(&models.ContactForm{}).Where("created_at >= ? AND created_at < ?", f.start, f.end)
+}
+
+func contactRows(db *gorm.DB, f Filter, emit func([]interface{}) error) error {
+	var row models.ContactForm
+	return eachRow(contactQuery(db, f).Order("id ASC"), &row, func() error {
+		return emit([]interface{}{
+			row.ID, row.CreatedAt.Local().Format(timeLayout), row.Name, row.Phone, row.Email,
+			row.Subject, row.Message, row.IsRead, row.SessionID,
+		})
+	})
+}
+
+func contactSummary(db *gorm.DB, f Filter) ([]Section, error) {
+	var rows []models.ContactForm
+	if err := contactQuery(db, f).Select("created_at, is_read").Find(&rows).Error; err != nil {
+		return nil, err
+	}
+	unread := 0
+	for _, r := range rows {
+		if !r.IsRead {
+			unread++
+		}
+	}
+	daily := countByDay(len(rows), func(i int) string { return rows[i].CreatedAt.Local().Format("2006-01-02") })
+
+	subjects, err := groupCounts(contactQuery(db, f), "subject", "count DESC")
+	if err != nil {
+		return nil, err
+	}
+	return []Section{
+		{Title: "Итого", Columns: []string{"Показатель", "Значение"}, Rows: [][]interface{}{
+			{"Обращений", len(rows)},
+			{"Непрочитанных", unread},
+		}},
+		{Title: "По дням", Columns: []string{"Дата", "Обращения"}, Rows: daily},
+		{Title: "По темам", Columns: []string{"Тема", "Обращения"}, Rows: subjects},
+	}, nil
+}
+
+// groupCounts - число записей по значению выражения key
+func groupCounts(query *gorm.DB, key, order string) ([][]interface{}, error) {
+	var rows []struct {
+		Key   string
+		Count int64
+	}
+	if err := query.Select(key + " AS key, COUNT(*) AS count").Group(key).Order(order).Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+	result := make([][]interface{}, len(rows))
+	for i, r := range rows {
+		result[i] = []interface{}{r.Key, r.Count}
+	}
+	return result, nil
+}
+
+// countByDay считает записи по дням. Дата берется из created_at в Go,
+// потому что SQLite и PostgreSQL по-разному извлекают дату из времени.
+func countByDay(n int, day func(i int) string) [][]interface{} {
+	counts := make(map[string]int64)
+	var days []string
+	for i := 0; i < n; i++ {
+		d := day(i)
+		if _, ok := counts[d]; !ok {
+			days = append(days, d)
+		}
+		counts[d]++
+	}
+	sort.Strings(days)
+	result := make([][]interface{}, len(days))
+	for i, d := range days {
+		result[i] = []interface{}{d, counts[d]}
+	}
+	return result
+}
+
+func round2(v float64) float64 {
+	return math.Round(v*100) / 100
+}
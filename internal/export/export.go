@@ -0,0 +1,283 @@
+// Package export выгружает статистику и обращения в CSV и XLSX.
+//
+// CSV пишется в ответ построчно по мере чтения из базы, поэтому подходит
+// для больших периодов. XLSX собирается потоковой записью excelize во
+// временный файл и кроме листа с данными содержит лист с итогами.
+package export
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/xuri/excelize/v2"
+	"gorm.io/gorm"
+)
+
+// Форматы выгрузки
+const (
+	FormatCSV  = "csv"
+	FormatXLSX = "xlsx"
+)
+
+// maxXLSXRows - ограничение строк на листе Excel (без заголовка)
+const maxXLSXRows = 1048575
+
+// csvFlushEvery - как часто отправлять накопленные строки CSV клиенту
+const csvFlushEvery = 500
+
+// Ошибки выгрузки
+var (
+	ErrInvalidFilter = errors.New("Некорректный период")
+	ErrTooManyRows   = errors.New("Слишком много строк для XLSX, выгрузите в CSV или сократите период")
+)
+
+// Filter - период выгрузки и фильтр ботов
+type Filter struct {
+	From       string // YYYY-MM-DD включительно
+	To         string // YYYY-MM-DD включительно
+	HumansOnly bool
+
+	start time.Time
+	end   time.Time // начало дня после To
+}
+
+// NewFilter проверяет период
+func NewFilter(from, to string, humansOnly bool) (Filter, error) {
+	start, err := time.ParseInLocation("2006-01-02", from, time.Local)
+	if err != nil {
+		return Filter{}, fmt.Errorf("%w: дата from должна быть в формате YYYY-MM-DD", ErrInvalidFilter)
+	}
+	end, err := time.ParseInLocation("2006-01-02", to, time.Local)
+	if err != nil {
+		return Filter{}, fmt.Errorf("%w: дата to должна быть в формате YYYY-MM-DD", ErrInvalidFilter)
+	}
+	if end.Before(start) {
+		return Filter{}, fmt.Errorf("%w: дата to раньше from", ErrInvalidFilter)
+	}
+	return Filter{From: from, To: to, HumansOnly: humansOnly, start: start, end: end.AddDate(0, 0, 1)}, nil
+}
+
+// Section - таблица на листе итогов
+type Section struct {
+	Title   string
+	Columns []string
+	Rows    [][]interface{}
+}
+
+// Dataset - выгружаемый отчет
+type Dataset struct {
+	Name    string
+	Title   string
+	Columns []string
+	Traffic bool // учитывает фильтр ботов
+	// Rows передает строки в emit по порядку, не загружая все в память
+	Rows func(db *gorm.DB, f Filter, emit func(row []interface{}) error) error
+	// Summary - итоги для XLSX
+	Summary func(db *gorm.DB, f Filter) ([]Section, error)
+}
+
+// Lookup возвращает отчет по имени
+func Lookup(name string) (Dataset, bool) {
+	for _, ds := range Datasets {
+		if ds.Name == name {
+			return ds, true
+		}
+	}
+	return Dataset{}, false
+}
+
+// Filename - имя файла выгрузки
+func Filename(ds Dataset, f Filter, format string) string {
+	return fmt.Sprintf("%s_%s_%s.%s", ds.Name, f.From, f.To, format)
+}
+
+// ContentType - MIME-тип формата
+func ContentType(format string) string {
+	if format == FormatXLSX {
+		return "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+	}
+	return "text/csv; charset=utf-8"
+}
+
+// WriteCSV пишет отчет в CSV. В начале пишется BOM, чтобы Excel открыл
+// кириллицу в UTF-8.
+func WriteCSV(w io.Writer, db *gorm.DB, ds Dataset, f Filter) error {
+	if _, err := io.WriteString(w, "\ufeff"); err != nil {
+		return err
+	}
+	writer := csv.NewWriter(w)
+	if err := writer.Write(ds.Columns); err != nil {
+		return err
+	}
+
+	record := make([]string, len(ds.Columns))
+	written := 0
+	err := ds.Rows(db, f, func(row []interface{}) error {
+		for i, v := range row {
+			record[i] = formatValue(v)
+		}
+		if err := writer.Write(record); err != nil {
+			return err
+		}
+		written++
+		if written%csvFlushEvery == 0 {
+			writer.Flush()
+			if flusher, ok := w.(http.Flusher); ok {
+				flusher.Flush()
+			}
+		}
+		return writer.Error()
+	})
+	if err != nil {
+		return err
+	}
+	writer.Flush()
+	return writer.Error()
+}
+
+// WriteXLSX пишет отчет в XLSX: лист итогов и лист с данными. Файл
+// собирается целиком до записи в w, поэтому при ошибке ответ еще не начат.
+func WriteXLSX(w io.Writer, db *gorm.DB, ds Dataset, f Filter) error {
+	book := excelize.NewFile()
+	defer book.Close()
+
+	bold, err := book.NewStyle(&excelize.Style{Font: &excelize.Font{Bold: true}})
+	if err != nil {
+		return err
+	}
+
+	const summarySheet, dataSheet = "Итоги", "Данные"
+	if err := book.SetSheetName(book.GetSheetName(0), summarySheet); err != nil {
+		return err
+	}
+	if _, err := book.NewSheet(dataSheet); err != nil {
+		return err
+	}
+
+	if err := writeSummary(book, summarySheet, bold, db, ds, f); err != nil {
+		return err
+	}
+
+	stream, err := book.NewStreamWriter(dataSheet)
+	if err != nil {
+		return err
+	}
+	header := make([]interface{}, len(ds.Columns))
+	for i, title := range ds.Columns {
+		header[i] = excelize.Cell{StyleID: bold, Value: title}
+	}
+	if err := stream.SetRow("A1", header); err != nil {
+		return err
+	}
+
+	rowNum := 1
+	err = ds.Rows(db, f, func(row []interface{}) error {
+		if rowNum > maxXLSXRows {
+			return ErrTooManyRows
+		}
+		rowNum++
+		values := make([]interface{}, len(row))
+		for i, v := range row {
+			if b, ok := v.(bool); ok {
+				v = formatValue(b)
+			}
+			values[i] = v
+		}
+		cell, err := excelize.CoordinatesToCellName(1, rowNum)
+		if err != nil {
+			return err
+		}
+		return stream.SetRow(cell, values)
+	})
+	if err != nil {
+		return err
+	}
+	if err := stream.Flush(); err != nil {
+		return err
+	}
+
+	_, err = book.WriteTo(w)
+	return err
+}
+
+func writeSummary(book *excelize.File, sheet string, bold int, db *gorm.DB, ds Dataset, f Filter) error {
+	rows := [][]interface{}{
+		{ds.Title},
+		{"Период", f.From + " — " + f.To},
+	}
+	if ds.Traffic {
+		traffic := "весь трафик"
+		if f.HumansOnly {
+			traffic = "без ботов"
+		}
+		rows = append(rows, []interface{}{"Трафик", traffic})
+	}
+	rows = append(rows, []interface{}{"Сформирован", time.Now().Format("2006-01-02 15:04")}, []interface{}{})
+	titleRows := map[int]bool{1: true}
+
+	if ds.Summary != nil {
+		sections, err := ds.Summary(db, f)
+		if err != nil {
+			return err
+		}
+		for _, section := range sections {
+			titleRows[len(rows)+1] = true
+			rows = append(rows, []interface{}{section.Title})
+			header := make([]interface{}, len(section.Columns))
+			for i, title := range section.Columns {
+				header[i] = title
+			}
+			titleRows[len(rows)+1] = true
+			rows = append(rows, header)
+			rows = append(rows, section.Rows...)
+			rows = append(rows, []interface{}{})
+		}
+	}
+
+	for i, row := range rows {
+		cell, err := excelize.CoordinatesToCellName(1, i+1)
+		if err != nil {
+			return err
+		}
+		if err := book.SetSheetRow(sheet, cell, &row); err != nil {
+			return err
+		}
+		if titleRows[i+1] {
+			if err := book.SetRowStyle(sheet, i+1, i+1, bold); err != nil {
+				return err
+			}
+		}
+	}
+	return book.SetColWidth(sheet, "A", "A", 30)
+}
+
+// formatValue - значение ячейки для CSV
+func formatValue(v interface{}) string {
+	switch v := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return escapeFormula(v)
+	case bool:
+		if v {
+			return "да"
+		}
+		return "нет"
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+// escapeFormula экранирует текст, который Excel принял бы за формулу
+// (поля форм заполняются посетителями сайта)
+func escapeFormula(s string) string {
+	if s != "" && strings.ContainsRune("=+-@\t\r", rune(s[0])) {
+		return "'" + s
+	}
+	return s
+}
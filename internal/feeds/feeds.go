@@ -0,0 +1,150 @@
+// Package feeds формирует товарные фиды для маркетплейсов и агрегаторов:
+// YML для Яндекс Маркета и RSS для Google Merchant Center.
+//
+// В фид попадают активные товары активных категорий, кроме исключенных
+// администратором (ExcludeFromFeeds у товара или категории). Готовый фид
+// хранится в памяти, пока не изменится каталог.
+package feeds
+
+import (
+	"bytes"
+	"database/sql"
+	"fmt"
+	"sync"
+	"time"
+
+	"texnousta-backend/internal/database"
+	"texnousta-backend/internal/models"
+	"texnousta-backend/internal/promotions"
+)
+
+// Форматы фидов
+const (
+	FormatYML    = "yml"
+	FormatGoogle = "google"
+)
+
+// Catalog - данные для фида
+type Catalog struct {
+	Categories []models.Category
+	Products   []models.Product
+	Generated  time.Time
+}
+
+type cached struct {
+	fingerprint string
+	data        []byte
+}
+
+var (
+	mu    sync.Mutex
+	cache = make(map[string]cached)
+)
+
+// Render возвращает фид в нужном формате, используя кэш, если каталог не менялся
+func Render(format string) ([]byte, error) {
+	write, ok := writers[format]
+	if !ok {
+		return nil, fmt.Errorf("неизвестный формат фида: %s", format)
+	}
+
+	fingerprint, err := Fingerprint()
+	if err != nil {
+		return nil, err
+	}
+
+	mu.Lock()
+	entry, hit := cache[format]
+	mu.Unlock()
+	if hit && entry.fingerprint == fingerprint {
+		return entry.data, nil
+	}
+
+	catalog, err := Load()
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	if err := write(&buf, catalog); err != nil {
+		return nil, err
+	}
+
+	mu.Lock()
+	cache[format] = cached{fingerprint: fingerprint, data: buf.Bytes()}
+	mu.Unlock()
+	return buf.Bytes(), nil
+}
+
+// Load загружает категории и товары, которые должны попасть в фид
+func Load() (*Catalog, error) {
+	var categories []models.Category
+	if err := database.DB.
+		Where("is_active = ? AND exclude_from_feeds = ?", true, false).
+		Order("id ASC").
+		Find(&categories).Error; err != nil {
+		return nil, err
+	}
+
+	ids := make([]uint, 0, len(categories))
+	for _, category := range categories {
+		ids = append(ids, category.ID)
+	}
+
+	var products []models.Product
+	if len(ids) > 0 {
+		if err := database.DB.
+			Where("is_active = ? AND exclude_from_feeds = ?", true, false).
+			Where("category_id IN ?", ids).
+			Order("id ASC").
+			Find(&products).Error; err != nil {
+			return nil, err
+		}
+	}
+
+	// В фид попадает цена с учетом действующих акций
+	promotions.ApplyToProducts(products)
+
+	return &Catalog{Categories: categories, Products: products, Generated: time.Now()}, nil
+}
+
+// Fingerprint - отпечаток состояния каталога. Меняется при любом изменении
+// товаров, категорий или набора действующих акций.
+func Fingerprint() (string, error) {
+	type stat struct {
+		Count     int64
+		UpdatedAt sql.NullString
+	}
+
+	var products, categories, promos stat
+	if err := database.DB.Model(&models.Product{}).
+		Select("COUNT(*) AS count, MAX(updated_at) AS updated_at").
+		Scan(&products).Error; err != nil {
+		return "", err
+	}
+	if err := database.DB.Model(&models.Category{}).
+		Select("COUNT(*) AS count, MAX(updated_at) AS updated_at").
+		Scan(&categories).Error; err != nil {
+		return "", err
+	}
+	if err := database.DB.Model(&models.Promotion{}).
+		Select("COUNT(*) AS count, MAX(updated_at) AS updated_at").
+		Scan(&promos).Error; err != nil {
+		return "", err
+	}
+
+	// Акции начинаются и заканчиваются по времени без изменения записей
+	active, err := promotions.Active(time.Now())
+	if err != nil {
+		return "", err
+	}
+	activeIDs := make([]uint, 0, len(active))
+	for _, promo := range active {
+		activeIDs = append(activeIDs, promo.ID)
+	}
+
+	return fmt.Sprintf("p%d@%s|c%d@%s|a%d@%s|%v",
+		products.Count, products.UpdatedAt.String,
+		categories.Count, categories.UpdatedAt.String,
+		promos.Count, promos.UpdatedAt.String, activeIDs), nil
+}
@@ -0,0 +1,226 @@
+package feeds
+
+import (
+	"encoding/xml"
+	"io"
+	"strconv"
+
+	"texnousta-backend/internal/config"
+	"texnousta-backend/internal/models"
+)
+
+// writers - функции записи фида по формату
+var writers = map[string]func(w io.Writer, catalog *Catalog) error{
+	FormatYML:    writeYML,
+	FormatGoogle: writeGoogle,
+}
+
+// ContentType - MIME-тип фида
+func ContentType(format string) string {
+	if format == FormatGoogle {
+		return "application/rss+xml; charset=utf-8"
+	}
+	return "application/xml; charset=utf-8"
+}
+
+// YML (Яндекс Маркет): https://yandex.ru/support/partnermarket/export/yml.html
+
+type ymlCatalog struct {
+	XMLName xml.Name `xml:"yml_catalog"`
+	Date    string   `xml:"date,attr"`
+	Shop    ymlShop  `xml:"shop"`
+}
+
+type ymlShop struct {
+	Name       string        `xml:"name"`
+	Company    string        `xml:"company"`
+	URL        string        `xml:"url"`
+	Currencies []ymlCurrency `xml:"currencies>currency"`
+	Categories []ymlCategory `xml:"categories>category"`
+	Offers     []ymlOffer    `xml:"offers>offer"`
+}
+
+type ymlCurrency struct {
+	ID   string `xml:"id,attr"`
+	Rate string `xml:"rate,attr"`
+}
+
+type ymlCategory struct {
+	ID   uint   `xml:"id,attr"`
+	Name string `xml:",chardata"`
+}
+
+type ymlOffer struct {
+	ID          uint   `xml:"id,attr"`
+	Available   bool   `xml:"available,attr"`
+	URL         string `xml:"url"`
+	Price       string `xml:"price"`
+	OldPrice    string `xml:"oldprice,omitempty"`
+	CurrencyID  string `xml:"currencyId"`
+	CategoryID  uint   `xml:"categoryId"`
+	Picture     string `xml:"picture,omitempty"`
+	Name        string `xml:"name"`
+	Vendor      string `xml:"vendor,omitempty"`
+	Model       string `xml:"model,omitempty"`
+	VendorCode  string `xml:"vendorCode,omitempty"`
+	Description string `xml:"description,omitempty"`
+	Count       int    `xml:"count"`
+}
+
+func writeYML(w io.Writer, catalog *Catalog) error {
+	currency := config.Currency()
+	doc := ymlCatalog{
+		Date: catalog.Generated.Format("2006-01-02T15:04-07:00"),
+		Shop: ymlShop{
+			Name:       config.ShopName(),
+			Company:    config.CompanyName(),
+			URL:        config.SiteURL(),
+			Currencies: []ymlCurrency{{ID: currency, Rate: "1"}},
+		},
+	}
+
+	for _, category := range catalog.Categories {
+		doc.Shop.Categories = append(doc.Shop.Categories, ymlCategory{ID: category.ID, Name: category.Name})
+	}
+
+	for _, product := range catalog.Products {
+		price, oldPrice := prices(&product)
+		doc.Shop.Offers = append(doc.Shop.Offers, ymlOffer{
+			ID:          product.ID,
+			Available:   product.Stock > 0,
+			URL:         config.ProductURL(product.ID),
+			Price:       price,
+			OldPrice:    oldPrice,
+			CurrencyID:  currency,
+			CategoryID:  product.CategoryID,
+			Picture:     config.AbsoluteURL(product.Image),
+			Name:        product.Name,
+			Vendor:      product.Brand,
+			Model:       product.Model,
+			VendorCode:  product.SKU,
+			Description: product.Description,
+			Count:       product.Stock,
+		})
+	}
+
+	return encode(w, doc)
+}
+
+// Google Merchant Center: https://support.google.com/merchants/answer/7052112
+
+const googleNamespace = "http://base.google.com/ns/1.0"
+
+type googleRSS struct {
+	XMLName xml.Name      `xml:"rss"`
+	Version string        `xml:"version,attr"`
+	G       string        `xml:"xmlns:g,attr"`
+	Channel googleChannel `xml:"channel"`
+}
+
+type googleChannel struct {
+	Title       string       `xml:"title"`
+	Link        string       `xml:"link"`
+	Description string       `xml:"description"`
+	Items       []googleItem `xml:"item"`
+}
+
+type googleItem struct {
+	ID              string `xml:"g:id"`
+	Title           string `xml:"g:title"`
+	Description     string `xml:"g:description"`
+	Link            string `xml:"g:link"`
+	ImageLink       string `xml:"g:image_link,omitempty"`
+	Availability    string `xml:"g:availability"`
+	Price           string `xml:"g:price"`
+	SalePrice       string `xml:"g:sale_price,omitempty"`
+	Brand           string `xml:"g:brand,omitempty"`
+	MPN             string `xml:"g:mpn,omitempty"`
+	ProductType     string `xml:"g:product_type,omitempty"`
+	Condition       string `xml:"g:condition"`
+	IdentifierExist string `xml:"g:identifier_exists,omitempty"`
+}
+
+func writeGoogle(w io.Writer, catalog *Catalog) error {
+	currency := config.Currency()
+	categoryNames := make(map[uint]string, len(catalog.Categories))
+	for _, category := range catalog.Categories {
+		categoryNames[category.ID] = category.Name
+	}
+
+	doc := googleRSS{
+		Version: "2.0",
+		G:       googleNamespace,
+		Channel: googleChannel{
+			Title:       config.ShopName(),
+			Link:        config.SiteURL(),
+			Description: "Каталог товаров " + config.ShopName(),
+		},
+	}
+
+	for _, product := range catalog.Products {
+		item := googleItem{
+			ID:           strconv.FormatUint(uint64(product.ID), 10),
+			Title:        product.Name,
+			Description:  product.Description,
+			Link:         config.ProductURL(product.ID),
+			ImageLink:    config.AbsoluteURL(product.Image),
+			Availability: "out_of_stock",
+			Brand:        product.Brand,
+			MPN:          product.SKU,
+			ProductType:  categoryNames[product.CategoryID],
+			Condition:    "new",
+		}
+		if item.Description == "" {
+			item.Description = product.Name
+		}
+		if item.MPN == "" {
+			item.MPN = product.Model
+		}
+		if item.Brand == "" && item.MPN == "" {
+			item.IdentifierExist = "no"
+		}
+		if product.Stock > 0 {
+			item.Availability = "in_stock"
+		}
+
+		// Google ожидает обычную цену в price, а цену со скидкой в sale_price
+		price, oldPrice := prices(&product)
+		if oldPrice != "" {
+			item.Price = oldPrice + " " + currency
+			item.SalePrice = price + " " + currency
+		} else {
+			item.Price = price + " " + currency
+		}
+
+		doc.Channel.Items = append(doc.Channel.Items, item)
+	}
+
+	return encode(w, doc)
+}
+
+// prices возвращает текущую цену и, если есть скидка, цену до скидки
+func prices(product *models.Product) (price, oldPrice string) {
+	current, before := product.DisplayPrices()
+	price = formatPrice(current)
+	if before > 0 {
+		oldPrice = formatPrice(before)
+	}
+	return price, oldPrice
+}
+
+func formatPrice(v float64) string {
+	return strconv.FormatFloat(v, 'f', -1, 64)
+}
+
+func encode(w io.Writer, doc interface{}) error {
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(doc); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
@@ -0,0 +1,126 @@
+// Package geoip определяет страну, регион и город посетителя по IP.
+//
+// Используется локальная база в формате MaxMind (.mmdb, например GeoLite2-City
+// или DB-IP City Lite), путь к которой задается в GEOIP_DB_PATH. Сеть не
+// нужна. Если файл не задан или не открывается, определение местоположения
+// отключается и Lookup возвращает пустой результат.
+package geoip
+
+import (
+	"log"
+	"net"
+	"os"
+	"sync"
+
+	"github.com/oschwald/maxminddb-golang"
+)
+
+// Location - местоположение посетителя. Пустые поля - не удалось определить.
+type Location struct {
+	Country string `json:"country"` // код страны ISO 3166-1, например UZ
+	Region  string `json:"region"`  // область или город республиканского значения
+	City    string `json:"city"`
+}
+
+// record - нужные поля записи базы City/Country
+type record struct {
+	Country struct {
+		ISOCode string            `maxminddb:"iso_code"`
+		Names   map[string]string `maxminddb:"names"`
+	} `maxminddb:"country"`
+	Subdivisions []struct {
+		Names map[string]string `maxminddb:"names"`
+	} `maxminddb:"subdivisions"`
+	City struct {
+		Names map[string]string `maxminddb:"names"`
+	} `maxminddb:"city"`
+}
+
+// Resolver ищет IP в базе MaxMind
+type Resolver struct {
+	reader   *maxminddb.Reader
+	language string
+}
+
+// Open открывает базу. language - язык названий (ru, en, ...), если в базе
+// нет названия на нем, берется английское.
+func Open(path, language string) (*Resolver, error) {
+	reader, err := maxminddb.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	return &Resolver{reader: reader, language: language}, nil
+}
+
+// Lookup ищет IP. Некорректные и частные адреса дают пустой результат.
+func (r *Resolver) Lookup(ip string) Location {
+	parsed := net.ParseIP(ip)
+	if r == nil || parsed == nil || parsed.IsPrivate() || parsed.IsLoopback() {
+		return Location{}
+	}
+
+	var rec record
+	if err := r.reader.Lookup(parsed, &rec); err != nil {
+		return Location{}
+	}
+
+	loc := Location{
+		Country: rec.Country.ISOCode,
+		City:    r.name(rec.City.Names),
+	}
+	if len(rec.Subdivisions) > 0 {
+		loc.Region = r.name(rec.Subdivisions[0].Names)
+	}
+	return loc
+}
+
+func (r *Resolver) name(names map[string]string) string {
+	if name := names[r.language]; name != "" {
+		return name
+	}
+	return names["en"]
+}
+
+// Close закрывает базу
+func (r *Resolver) Close() error {
+	return r.reader.Close()
+}
+
+var (
+	defaultOnce     sync.Once
+	defaultResolver *Resolver
+)
+
+// Default - база из GEOIP_DB_PATH (язык названий GEOIP_LANGUAGE, по
+// умолчанию ru). Открывается при первом обращении; nil, если база не задана
+// или не открылась.
+func Default() *Resolver {
+	defaultOnce.Do(func() {
+		path := os.Getenv("GEOIP_DB_PATH")
+		if path == "" {
+			return
+		}
+		language := os.Getenv("GEOIP_LANGUAGE")
+		if language == "" {
+			language = "ru"
+		}
+		resolver, err := Open(path, language)
+		if err != nil {
+			log.Printf("⚠️ GeoIP отключен: не удалось открыть %s: %v", path, err)
+			return
+		}
+		log.Printf("✅ GeoIP база загружена: %s", path)
+		defaultResolver = resolver
+	})
+	return defaultResolver
+}
+
+// Enabled - доступна ли база по умолчанию
+func Enabled() bool {
+	return Default() != nil
+}
+
+// Lookup ищет IP в базе по умолчанию
+func Lookup(ip string) Location {
+	return Default().Lookup(ip)
+}
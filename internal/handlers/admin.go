@@ -55,9 +55,10 @@ func CreateCategory(c *gin.Context) {
 	}
 
 	category := models.Category{
-		Name:        req.Name,
-		Description: req.Description,
-		IsActive:    req.IsActive,
+		Name:             req.Name,
+		Description:      req.Description,
+		IsActive:         req.IsActive,
+		ExcludeFromFeeds: req.ExcludeFromFeeds,
 	}
 
 	if err := database.DB.Create(&category).Error; err != nil {
@@ -88,9 +89,10 @@ func UpdateCategory(c *gin.Context) {
 	}
 
 	updates := map[string]interface{}{
-		"name":        req.Name,
-		"description": req.Description,
-		"is_active":   req.IsActive,
+		"name":               req.Name,
+		"description":        req.Description,
+		"is_active":          req.IsActive,
+		"exclude_from_feeds": req.ExcludeFromFeeds,
 	}
 
 	if err := database.DB.Model(&category).Updates(updates).Error; err != nil {
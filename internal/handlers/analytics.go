@@ -2,6 +2,7 @@ package handlers
 
 import (
 	"crypto/md5"
+	"errors"
 	"fmt"
 	"log"
 	"net/http"
@@ -9,8 +10,13 @@ import (
 	"strconv"
 	"strings"
 	"time"
+	"unicode/utf8"
+	"texnousta-backend/internal/analytics"
 	"texnousta-backend/internal/database"
+	"texnousta-backend/internal/geoip"
 	"texnousta-backend/internal/models"
+	"texnousta-backend/internal/realtime"
+	"texnousta-backend/internal/useragent"
 
 	"github.com/gin-gonic/gin"
 )
@@ -70,10 +76,11 @@ func isValidAdminToken(c *gin.Context) bool {
 
 // TrackVisitor регистрирует посещение сайта
 // @Summary Отслеживание посетителей
-// @Description Регистрирует посещение сайта по IP адресу
+// @Description Регистрирует просмотр страницы: путь, заголовок, источник перехода и сессию. Уникальные посетители по-прежнему считаются по IP за день
 // @Tags Analytics
 // @Accept json
 // @Produce json
+// @Param view body models.PageViewRequest false "Данные о просмотре страницы"
 // @Success 200 {object} map[string]interface{}
 // @Router /track-visitor [post]
 func TrackVisitor(c *gin.Context) {
@@ -84,17 +91,57 @@ func TrackVisitor(c *gin.Context) {
 	date := now.Format("2006-01-02")
 	month := now.Format("2006-01")
 	
-	// Проверяем, был ли уже такой посетитель сегодня
+	// Боты помечаются, а при BOT_HITS=drop не сохраняются
+	botReason := analytics.DetectBot(clientIP, userAgent, c.Request.Header)
+	if botReason != "" && analytics.DropBotHits() {
+		c.JSON(http.StatusOK, gin.H{"message": "Посещение зарегистрировано"})
+		return
+	}
+	
+	// В режиме приватности вместо IP сохраняется хеш или обрезанный адрес
+	storedIP := analytics.StoredIP(database.DB, clientIP, now)
+	
+	// Тело запроса необязательно: старый фронтенд отправляет пустой POST
+	var req models.PageViewRequest
+	_ = c.ShouldBindJSON(&req)
+	if req.SessionID == "" {
+		req.SessionID = c.GetHeader("X-Session-ID")
+	}
+	sessionID := analytics.SessionID(req.SessionID, analytics.SessionIP(database.DB, clientIP, now), date)
+	
+	// Источник визита фиксируется при первом просмотре сессии
+	if botReason == "" {
+		attribution := analytics.Attribute(&req)
+		attribution.SessionID = sessionID
+		if err := analytics.SaveAttribution(database.DB, &attribution); err != nil {
+			log.Printf("❌ Ошибка сохранения источника визита: %v", err)
+		}
+	}
+	
+	// Проверяем, был ли уже такой посетитель сегодня: по ключу посетителя,
+	// потому что обрезанный IP общий для соседних адресов
+	ua := parseUserAgent(userAgent, botReason)
+	location := geoip.Lookup(clientIP)
+	visitorKey := analytics.VisitorKey(database.DB, clientIP, now)
 	var existing models.VisitorStat
-	result := database.DB.Where("ip_address = ? AND date = ?", clientIP, date).First(&existing)
+	result := database.DB.Where("visitor_key = ? AND date = ?", visitorKey, date).First(&existing)
+	newVisitor := result.Error != nil
 	
-	if result.Error != nil {
+	if newVisitor {
 		// Новый посетитель за сегодня - создаем запись
 		visitor := models.VisitorStat{
-			IPAddress: clientIP,
-			UserAgent: userAgent,
-			Date:      date,
-			Month:     month,
+			IPAddress:  storedIP,
+			VisitorKey: visitorKey,
+			UserAgent:  truncate(userAgent, 500),
+			DeviceType: ua.DeviceType,
+			OS:         ua.OS,
+			Browser:    ua.Browser,
+			IsBot:      ua.IsBot,
+			Country:    location.Country,
+			Region:     truncate(location.Region, 100),
+			City:       truncate(location.City, 100),
+			Date:       date,
+			Month:      month,
 		}
 		
 		if err := database.DB.Create(&visitor).Error; err != nil {
@@ -102,20 +149,96 @@ func TrackVisitor(c *gin.Context) {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "Ошибка сохранения статистики"})
 			return
 		}
-		log.Printf("✅ Посетитель зарегистрирован: IP=%s, дата=%s", clientIP, date)
+		log.Printf("✅ Посетитель зарегистрирован: IP=%s, дата=%s", storedIP, date)
 	}
 	
+	// Каждый просмотр страницы
+	pageView := models.PageView{
+		SessionID: sessionID,
+		IPAddress: storedIP,
+		UserAgent: truncate(userAgent, 500),
+		Path:      truncate(analytics.CleanPath(req.Path), 500),
+		Title:     truncate(req.Title, 300),
+		Referrer:  truncate(req.Referrer, 1000),
+		IsBot:     botReason != "",
+		Date:      date,
+		Month:     month,
+	}
+	if err := database.DB.Create(&pageView).Error; err != nil {
+		log.Printf("❌ Ошибка сохранения просмотра страницы: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Ошибка сохранения статистики"})
+		return
+	}
+	
+	realtime.Publish(realtime.EventVisit, gin.H{
+		"path":        pageView.Path,
+		"title":       pageView.Title,
+		"referrer":    pageView.Referrer,
+		"session_id":  sessionID,
+		"new_visitor": newVisitor,
+		"device_type": ua.DeviceType,
+		"region":      location.Region,
+		"os":          ua.OS,
+		"browser":     ua.Browser,
+		"is_bot":      ua.IsBot,
+	})
+	
 	c.JSON(http.StatusOK, gin.H{"message": "Посещение зарегистрировано"})
 }
 
+// requestSessionID - сессия сайта, из которой пришел запрос (заголовок X-Session-ID или IP за день)
+func requestSessionID(c *gin.Context) string {
+	now := time.Now()
+	return analytics.SessionID(c.GetHeader("X-Session-ID"), analytics.SessionIP(database.DB, c.ClientIP(), now), now.Format("2006-01-02"))
+}
+
+// parseUserAgent разбирает User-Agent; запрос, признанный ботом по другим
+// признакам (IP, заголовки), тоже помечается как бот
+func parseUserAgent(userAgent, botReason string) useragent.Info {
+	ua := useragent.Parse(userAgent)
+	if botReason != "" {
+		ua.IsBot = true
+		ua.DeviceType = useragent.Bot
+	}
+	return ua
+}
+
+// trafficParam разбирает параметр traffic и отвечает 400, если он некорректен
+func trafficParam(c *gin.Context) (humansOnly bool, ok bool) {
+	humansOnly, ok = analytics.HumansOnly(c.Query("traffic"))
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Параметр traffic должен быть human или all"})
+	}
+	return humansOnly, ok
+}
+
+func trafficName(humansOnly bool) string {
+	if humansOnly {
+		return "human"
+	}
+	return "all"
+}
+
+// truncate обрезает строку до max байт, не разрывая символы
+func truncate(s string, max int) string {
+	if len(s) <= max {
+		return s
+	}
+	for max > 0 && !utf8.RuneStart(s[max]) {
+		max--
+	}
+	return s[:max]
+}
+
 // GetVisitorStats возвращает статистику посетителей
 // @Summary Получить статистику посетителей
-// @Description Возвращает статистику посетителей по дням и месяцам
+// @Description Возвращает статистику посетителей по дням и месяцам и по регионам (GeoIP). total_unique - уникальные посетители за все время из сводной таблицы (месяцы, сырые записи которых удалены по ANALYTICS_RETENTION_DAYS, учитываются по месячным итогам)
 // @Tags Analytics
 // @Accept json
 // @Produce json
 // @Security BearerAuth
 // @Param days query int false "Количество дней для отображения (по умолчанию 30)"
+// @Param traffic query string false "human - без ботов (по умолчанию), all - весь трафик"
 // @Success 200 {object} models.VisitorStatsResponse
 // @Router /admin/analytics/visitors [get]
 func GetVisitorStats(c *gin.Context) {
@@ -124,70 +247,165 @@ func GetVisitorStats(c *gin.Context) {
 	if err != nil || days <= 0 {
 		days = 30
 	}
+	humansOnly, ok := trafficParam(c)
+	if !ok {
+		return
+	}
 	
 	// Получаем дату начала периода
 	startDate := time.Now().AddDate(0, 0, -days).Format("2006-01-02")
 	
-	// Дневная статистика
-	var dailyStats []models.DailyStat
-	database.DB.Raw(`
-		SELECT 
-			date,
-			COUNT(DISTINCT ip_address) as unique_views,
-			COUNT(*) as total_views
-		FROM visitor_stats 
-		WHERE date >= ? 
-		GROUP BY date 
-		ORDER BY date DESC
-	`, startDate).Scan(&dailyStats)
+	// Статистика берется из сводной таблицы, которую пересчитывает фоновая задача
+	dailyStats, err := analytics.DailyStats(database.DB, analytics.MetricVisitors, startDate, humansOnly)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Ошибка при получении статистики"})
+		return
+	}
 	
 	// Месячная статистика за последние 12 месяцев
-	var monthlyStats []models.MonthlyStat
 	startMonth := time.Now().AddDate(0, -12, 0).Format("2006-01")
+	monthlyStats, err := analytics.MonthlyStats(database.DB, analytics.MetricVisitors, startMonth, humansOnly)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Ошибка при получении статистики"})
+		return
+	}
 	
-	database.DB.Raw(`
-		SELECT 
-			month,
-			COUNT(DISTINCT ip_address) as unique_views,
-			COUNT(*) as total_views
-		FROM visitor_stats 
-		WHERE month >= ? 
-		GROUP BY month 
-		ORDER BY month DESC
-	`, startMonth).Scan(&monthlyStats)
-	
-	// Общее количество уникальных посетителей
-	var totalUnique int64
-	database.DB.Model(&models.VisitorStat{}).
-		Distinct("ip_address").
-		Count(&totalUnique)
+	// До появления просмотров страниц учитывался только первый визит за день
+	for i := range dailyStats {
+		if dailyStats[i].TotalViews < dailyStats[i].UniqueViews {
+			dailyStats[i].TotalViews = dailyStats[i].UniqueViews
+		}
+	}
+	for i := range monthlyStats {
+		if monthlyStats[i].TotalViews < monthlyStats[i].UniqueViews {
+			monthlyStats[i].TotalViews = monthlyStats[i].UniqueViews
+		}
+	}
+	
+	// Уникальные посетители за все время из сводной таблицы
+	totalUnique, err := analytics.UniqueVisitors(database.DB, analytics.MetricVisitors, 0, humansOnly)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Ошибка при получении статистики"})
+		return
+	}
+	
+	// Посетители по регионам (по сырым записям за период)
+	regions, err := analytics.RegionStats(database.DB, "visitor_stats", startDate, humansOnly, 50)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Ошибка при получении статистики"})
+		return
+	}
 	
 	response := models.VisitorStatsResponse{
 		DailyStats:   dailyStats,
 		MonthlyStats: monthlyStats,
 		TotalUnique:  totalUnique,
+		ByRegion:     regions,
 	}
 	
 	c.JSON(http.StatusOK, response)
 }
 
+// GetDeviceStats возвращает разбивку посетителей или кликов по телефону по устройствам
+// @Summary Статистика устройств
+// @Description Разбивка по типу устройства, операционной системе и браузеру по сохраненным User-Agent. Боты не учитываются в ОС и браузерах, а в типах устройств - только при traffic=all
+// @Tags Analytics
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param days query int false "Количество дней для отображения (по умолчанию 30)"
+// @Param source query string false "visitors или phone-clicks (по умолчанию visitors)"
+// @Param traffic query string false "human - без ботов (по умолчанию), all - весь трафик"
+// @Success 200 {object} models.DeviceStatsResponse
+// @Failure 400 {object} map[string]interface{}
+// @Failure 401 {object} map[string]interface{}
+// @Router /admin/device-stats [get]
+func GetDeviceStats(c *gin.Context) {
+	if !isValidAdminToken(c) {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Не авторизован"})
+		return
+	}
+	
+	days, err := strconv.Atoi(c.DefaultQuery("days", "30"))
+	if err != nil || days <= 0 {
+		days = 30
+	}
+	source := c.DefaultQuery("source", "visitors")
+	table, ok := analytics.DeviceTables[source]
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Источник должен быть visitors или phone-clicks"})
+		return
+	}
+	humansOnly, ok := trafficParam(c)
+	if !ok {
+		return
+	}
+	
+	startDate := time.Now().AddDate(0, 0, -days).Format("2006-01-02")
+	report, err := analytics.DeviceStats(database.DB, table, startDate, humansOnly)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Ошибка при получении статистики устройств"})
+		return
+	}
+	report.Days = days
+	report.Source = source
+	report.Traffic = trafficName(humansOnly)
+	
+	c.JSON(http.StatusOK, report)
+}
+
 // TrackPhoneClick регистрирует клик по кнопке телефона
 // @Summary Отслеживание кликов по телефону
-// @Description Регистрирует клик по кнопке с номером телефона
+// @Description Регистрирует клик по кнопке с номером телефона: страница, кнопка, ее расположение и товар
 // @Tags Analytics
 // @Accept json
 // @Produce json
+// @Param click body models.PhoneClickRequest false "Кнопка, по которой кликнули"
 // @Success 200 {object} map[string]interface{}
 // @Router /track-phone-click [post]
 func TrackPhoneClick(c *gin.Context) {
 	clientIP := c.ClientIP()
 	userAgent := c.GetHeader("User-Agent")
-	date := time.Now().Format("2006-01-02")
+	now := time.Now()
+	date := now.Format("2006-01-02")
+	
+	botReason := analytics.DetectBot(clientIP, userAgent, c.Request.Header)
+	if botReason != "" && analytics.DropBotHits() {
+		c.JSON(http.StatusOK, gin.H{"message": "Клик по телефону зарегистрирован"})
+		return
+	}
+	
+	// Тело запроса необязательно: старый фронтенд отправляет пустой POST
+	var req models.PhoneClickRequest
+	_ = c.ShouldBindJSON(&req)
+	path := analytics.CleanPath(req.Path)
+	if req.Path == "" {
+		path = analytics.ReferrerPath(c.GetHeader("Referer"))
+	}
+	if req.ProductID != nil && *req.ProductID == 0 {
+		req.ProductID = nil
+	}
 	
+	storedIP := analytics.StoredIP(database.DB, clientIP, now)
+	ua := parseUserAgent(userAgent, botReason)
+	location := geoip.Lookup(clientIP)
 	phoneClick := models.PhoneClickStat{
-		IPAddress: clientIP,
-		UserAgent: userAgent,
-		Date:      date,
+		IPAddress:  storedIP,
+		VisitorKey: analytics.VisitorKey(database.DB, clientIP, now),
+		UserAgent:  truncate(userAgent, 500),
+		DeviceType: ua.DeviceType,
+		OS:         ua.OS,
+		Browser:    ua.Browser,
+		IsBot:      ua.IsBot,
+		Country:    location.Country,
+		Region:     truncate(location.Region, 100),
+		City:       truncate(location.City, 100),
+		Date:       date,
+		SessionID:  requestSessionID(c),
+		Path:       truncate(path, 500),
+		ButtonID:   truncate(strings.TrimSpace(req.ButtonID), 100),
+		Placement:  analytics.NormalizePlacement(req.Placement),
+		ProductID:  req.ProductID,
 	}
 	
 	if err := database.DB.Create(&phoneClick).Error; err != nil {
@@ -196,18 +414,30 @@ func TrackPhoneClick(c *gin.Context) {
 		return
 	}
 	
-	log.Printf("✅ Клик по телефону зарегистрирован: IP=%s, дата=%s", clientIP, date)
+	log.Printf("✅ Клик по телефону зарегистрирован: IP=%s, дата=%s", storedIP, date)
+	realtime.Publish(realtime.EventPhoneClick, gin.H{
+		"id":          phoneClick.ID,
+		"session_id":  phoneClick.SessionID,
+		"path":        phoneClick.Path,
+		"placement":   phoneClick.Placement,
+		"product_id":  phoneClick.ProductID,
+		"region":      phoneClick.Region,
+		"device_type": phoneClick.DeviceType,
+		"is_bot":      phoneClick.IsBot,
+	})
 	c.JSON(http.StatusOK, gin.H{"message": "Клик по телефону зарегистрирован"})
 }
 
 // GetPhoneClickStats возвращает статистику кликов по телефону
 // @Summary Получить статистику кликов по телефону
-// @Description Возвращает статистику кликов по кнопке телефона
+// @Description Возвращает статистику кликов по кнопке телефона по дням, расположению кнопок, страницам, товарам и регионам (GeoIP)
 // @Tags Analytics
 // @Accept json
 // @Produce json
 // @Security BearerAuth
-// @Param days query int false "Количество дней для отображения (по умолчанию 30)"
+// @Param days query int false "Количество дней: 1, 7, 14, 30, 90, 180 или 365, другое значение округляется вверх (по умолчанию 30)"
+// @Param limit query int false "Количество страниц и товаров в списках (по умолчанию 20)"
+// @Param traffic query string false "human - без ботов (по умолчанию), all - весь трафик"
 // @Success 200 {object} models.PhoneClickStatsResponse
 // @Router /admin/analytics/phone-clicks [get]
 func GetPhoneClickStats(c *gin.Context) {
@@ -216,39 +446,57 @@ func GetPhoneClickStats(c *gin.Context) {
 	if err != nil || days <= 0 {
 		days = 30
 	}
+	// Уникальные за период посчитаны заранее только для периодов из RollupWindows
+	days = analytics.WindowDays(days)
+	humansOnly, ok := trafficParam(c)
+	if !ok {
+		return
+	}
 	
 	startDate := time.Now().AddDate(0, 0, -days).Format("2006-01-02")
 	
-	// Общее количество кликов
+	// Дневная статистика кликов из сводной таблицы
+	dailyClicks, err := analytics.DailyStats(database.DB, analytics.MetricPhoneClicks, startDate, humansOnly)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Ошибка при получении статистики кликов"})
+		return
+	}
+	
+	// Итоги за период; уникальные клики - уникальные посетители за весь период
 	var totalClicks int64
-	database.DB.Model(&models.PhoneClickStat{}).
-		Where("date >= ?", startDate).
-		Count(&totalClicks)
-	
-	// Уникальные клики (по IP)
-	var uniqueClicks int64
-	database.DB.Model(&models.PhoneClickStat{}).
-		Where("date >= ?", startDate).
-		Distinct("ip_address").
-		Count(&uniqueClicks)
-	
-	// Дневная статистика кликов
-	var dailyClicks []models.DailyStat
-	database.DB.Raw(`
-		SELECT 
-			date,
-			COUNT(DISTINCT ip_address) as unique_views,
-			COUNT(*) as total_views
-		FROM phone_click_stats 
-		WHERE date >= ? 
-		GROUP BY date 
-		ORDER BY date DESC
-	`, startDate).Scan(&dailyClicks)
+	for _, day := range dailyClicks {
+		totalClicks += day.TotalViews
+	}
+	uniqueClicks, err := analytics.UniqueVisitors(database.DB, analytics.MetricPhoneClicks, days, humansOnly)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Ошибка при получении статистики кликов"})
+		return
+	}
+	
+	// Какие кнопки, страницы и товары приводят к звонкам
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "20"))
+	if err != nil || limit <= 0 || limit > 100 {
+		limit = 20
+	}
+	placements, pages, products, err := analytics.PhoneClickBreakdown(database.DB, startDate, limit, humansOnly)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Ошибка при получении статистики кликов"})
+		return
+	}
+	regions, err := analytics.RegionStats(database.DB, "phone_click_stats", startDate, humansOnly, 50)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Ошибка при получении статистики кликов"})
+		return
+	}
 	
 	response := models.PhoneClickStatsResponse{
 		TotalClicks:  totalClicks,
 		UniqueClicks: uniqueClicks,
 		DailyClicks:  dailyClicks,
+		ByPlacement:  placements,
+		ByPage:       pages,
+		ByProduct:    products,
+		ByRegion:     regions,
 	}
 	
 	c.JSON(http.StatusOK, response)
@@ -363,7 +611,131 @@ func GetDatabaseStatus(c *gin.Context) {
 		"phone_contacts":    phoneContactsCount,
 		"visitors":          visitorsCount,
 		"phone_clicks":      phoneClicksCount,
+		"privacy_mode":      analytics.PrivacyMode(),
+		"geoip_enabled":     geoip.Enabled(),
 		"timestamp":         time.Now(),
 	})
 }
 
+
+// GetPageStats возвращает отчет по страницам и сессиям
+// @Summary Статистика страниц
+// @Description Популярные страницы, страницы входа и выхода, число сессий и среднее число страниц за сессию
+// @Tags Analytics
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param days query int false "Количество дней (по умолчанию 30)"
+// @Param limit query int false "Количество страниц в каждом списке (по умолчанию 20)"
+// @Param traffic query string false "human - без ботов (по умолчанию), all - весь трафик"
+// @Success 200 {object} models.PageStatsResponse
+// @Failure 401 {object} map[string]interface{}
+// @Router /admin/page-stats [get]
+func GetPageStats(c *gin.Context) {
+	if !isValidAdminToken(c) {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Не авторизован"})
+		return
+	}
+
+	days, err := strconv.Atoi(c.DefaultQuery("days", "30"))
+	if err != nil || days <= 0 {
+		days = 30
+	}
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "20"))
+	if err != nil || limit <= 0 || limit > 100 {
+		limit = 20
+	}
+	humansOnly, ok := trafficParam(c)
+	if !ok {
+		return
+	}
+
+	startDate := time.Now().AddDate(0, 0, -days).Format("2006-01-02")
+	report, err := analytics.PageStats(database.DB, startDate, limit, humansOnly)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Ошибка при получении статистики страниц"})
+		return
+	}
+	report.Days = days
+	report.Traffic = trafficName(humansOnly)
+
+	c.JSON(http.StatusOK, report)
+}
+
+// GetAttributionStats возвращает отчет по источникам трафика и рекламным кампаниям
+// @Summary Атрибуция по источникам
+// @Description Сессии, обращения, оставленные телефоны, клики по телефону и заказы в разрезе источника (utm_source или сайт-источник) и кампании
+// @Tags Analytics
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param days query int false "Количество дней (по умолчанию 30)"
+// @Param group query string false "Группировка: source или campaign (по умолчанию source)"
+// @Success 200 {object} models.AttributionStatsResponse
+// @Failure 401 {object} map[string]interface{}
+// @Router /admin/attribution-stats [get]
+func GetAttributionStats(c *gin.Context) {
+	if !isValidAdminToken(c) {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Не авторизован"})
+		return
+	}
+
+	days, err := strconv.Atoi(c.DefaultQuery("days", "30"))
+	if err != nil || days <= 0 {
+		days = 30
+	}
+	group := c.DefaultQuery("group", "source")
+	if group != "source" && group != "campaign" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Группировка должна быть source или campaign"})
+		return
+	}
+
+	stats, err := analytics.AttributionStats(database.DB, time.Now().AddDate(0, 0, -days), group)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Ошибка при получении статистики источников"})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.AttributionStatsResponse{Days: days, Group: group, Stats: stats})
+}
+
+// AnonymizeStats обезличивает или удаляет сохраненную статистику
+// @Summary Обезличивание статистики
+// @Description anonymize заменяет IP в сохраненной статистике хешем с одноразовой солью или обрезанным адресом, purge удаляет сырые записи (сводные отчеты по дням и месяцам сохраняются)
+// @Tags Analytics
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body models.AnonymizeRequest true "Действие и период"
+// @Success 200 {object} analytics.AnonymizeResult
+// @Failure 400 {object} map[string]interface{}
+// @Failure 401 {object} map[string]interface{}
+// @Failure 403 {object} map[string]interface{}
+// @Router /admin/stats/anonymize [post]
+func AnonymizeStats(c *gin.Context) {
+	var req models.AnonymizeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var result *analytics.AnonymizeResult
+	var err error
+	if req.Action == "purge" {
+		result, err = analytics.Purge(database.DB, req.Before)
+	} else {
+		result, err = analytics.Anonymize(database.DB, req.Method, req.Before)
+	}
+	if errors.Is(err, analytics.ErrInvalidDate) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		log.Printf("❌ Ошибка обезличивания статистики: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Ошибка при обработке статистики"})
+		return
+	}
+
+	log.Printf("✅ Статистика обработана: %s, записей: %d", result.Action, result.Updated)
+	c.JSON(http.StatusOK, result)
+}
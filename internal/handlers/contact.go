@@ -6,6 +6,7 @@ import (
 	"strconv"
 	"texnousta-backend/internal/database"
 	"texnousta-backend/internal/models"
+	"texnousta-backend/internal/realtime"
 
 	"github.com/gin-gonic/gin"
 )
@@ -40,12 +41,13 @@ func CreateContact(c *gin.Context) {
 
 	// Создание контактного обращения
 	contact := models.ContactForm{
-		Name:    req.Name,
-		Email:   req.Email,
-		Phone:   req.Phone,
-		Subject: req.Subject,
-		Message: req.Message,
-		IsRead:  false,
+		Name:      req.Name,
+		Email:     req.Email,
+		Phone:     req.Phone,
+		Subject:   req.Subject,
+		Message:   req.Message,
+		IsRead:    false,
+		SessionID: requestSessionID(c),
 	}
 
 	if err := database.DB.Create(&contact).Error; err != nil {
@@ -53,6 +55,8 @@ func CreateContact(c *gin.Context) {
 		return
 	}
 
+	realtime.Publish(realtime.EventContact, contact)
+
 	c.JSON(http.StatusCreated, gin.H{
 		"message": "Ваше обращение успешно отправлено. Мы свяжемся с вами в ближайшее время.",
 		"id":      contact.ID,
@@ -195,11 +199,12 @@ func CreateQuickContact(c *gin.Context) {
 
 	// Создание быстрого контактного обращения
 	contact := models.ContactForm{
-		Name:    req.Name,
-		Phone:   req.Phone,
-		Subject: "Быстрая заявка",
-		Message: "Клиент оставил заявку на обратный звонок",
-		IsRead:  false,
+		Name:      req.Name,
+		Phone:     req.Phone,
+		Subject:   "Быстрая заявка",
+		Message:   "Клиент оставил заявку на обратный звонок",
+		IsRead:    false,
+		SessionID: requestSessionID(c),
 	}
 
 	if err := database.DB.Create(&contact).Error; err != nil {
@@ -207,6 +212,8 @@ func CreateQuickContact(c *gin.Context) {
 		return
 	}
 
+	realtime.Publish(realtime.EventQuickContact, contact)
+
 	c.JSON(http.StatusCreated, gin.H{
 		"message": "Заявка принята! Мы перезвоним вам в течение 15 минут.",
 		"id":      contact.ID,
@@ -234,11 +241,12 @@ func CreatePhoneContact(c *gin.Context) {
 
 	// Создание контактного обращения только с телефоном
 	contact := models.ContactForm{
-		Name:    "Не указано",
-		Phone:   req.Phone,
-		Subject: "Оставлен телефон",
-		Message: "Клиент оставил только номер телефона для связи",
-		IsRead:  false,
+		Name:      "Не указано",
+		Phone:     req.Phone,
+		Subject:   "Оставлен телефон",
+		Message:   "Клиент оставил только номер телефона для связи",
+		IsRead:    false,
+		SessionID: requestSessionID(c),
 	}
 
 	if err := database.DB.Create(&contact).Error; err != nil {
@@ -250,7 +258,8 @@ func CreatePhoneContact(c *gin.Context) {
 
 	// Также сохраняем в отдельную таблицу для аналитики
 	phoneContact := models.PhoneContact{
-		Phone: req.Phone,
+		Phone:     req.Phone,
+		SessionID: contact.SessionID,
 	}
 	if err := database.DB.Create(&phoneContact).Error; err != nil {
 		log.Printf("❌ Ошибка сохранения в phone_contacts: %v", err)
@@ -258,6 +267,8 @@ func CreatePhoneContact(c *gin.Context) {
 		log.Printf("✅ Телефон сохранен в phone_contacts с ID: %d", phoneContact.ID)
 	}
 
+	realtime.Publish(realtime.EventPhoneContact, contact)
+
 	c.JSON(http.StatusCreated, gin.H{
 		"message": "Номер телефона сохранен! Мы свяжемся с вами.",
 		"id":      contact.ID,
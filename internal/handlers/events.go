@@ -0,0 +1,155 @@
+package handlers
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"texnousta-backend/internal/analytics"
+	"texnousta-backend/internal/database"
+	"texnousta-backend/internal/events"
+	"texnousta-backend/internal/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// rejectedEvent - событие из пачки, не прошедшее проверку
+type rejectedEvent struct {
+	Index int    `json:"index"`
+	Name  string `json:"name"`
+	Error string `json:"error"`
+}
+
+// TrackEvents принимает пачку событий с сайта
+// @Summary Отправить события
+// @Description Пачка до 50 событий (add_to_cart, banner_click, filter_used, form_start). Свойства проверяются по схеме события, неподходящие события отклоняются, остальные сохраняются
+// @Tags Analytics
+// @Accept json
+// @Produce json
+// @Param events body models.ClientEventBatch true "События"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]interface{}
+// @Router /events [post]
+func TrackEvents(c *gin.Context) {
+	var req models.ClientEventBatch
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	clientIP := c.ClientIP()
+	now := time.Now()
+
+	botReason := analytics.DetectBot(clientIP, c.GetHeader("User-Agent"), c.Request.Header)
+	if botReason != "" && analytics.DropBotHits() {
+		c.JSON(http.StatusOK, gin.H{"accepted": len(req.Events), "rejected": []rejectedEvent{}})
+		return
+	}
+
+	if req.SessionID == "" {
+		req.SessionID = c.GetHeader("X-Session-ID")
+	}
+	sessionID := analytics.SessionID(req.SessionID, analytics.SessionIP(database.DB, clientIP, now), now.Format("2006-01-02"))
+
+	accepted := make([]models.ClientEvent, 0, len(req.Events))
+	rejected := []rejectedEvent{}
+	for i, input := range req.Events {
+		if err := events.Validate(input.Name, input.Properties); err != nil {
+			rejected = append(rejected, rejectedEvent{Index: i, Name: input.Name, Error: err.Error()})
+			continue
+		}
+		event, err := events.NewEvent(input, sessionID, truncate(analytics.CleanPath(input.Path), 500), botReason != "", now)
+		if err != nil {
+			rejected = append(rejected, rejectedEvent{Index: i, Name: input.Name, Error: "Некорректные свойства"})
+			continue
+		}
+		accepted = append(accepted, event)
+	}
+
+	if len(accepted) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Ни одно событие не прошло проверку", "rejected": rejected})
+		return
+	}
+
+	if err := events.Save(database.DB, accepted); err != nil {
+		log.Printf("❌ Ошибка сохранения событий: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Ошибка сохранения событий"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"accepted": len(accepted), "rejected": rejected})
+}
+
+// GetEventSchemas возвращает схемы событий, которые принимает сервер
+// @Summary Схемы событий
+// @Description Список событий с описанием свойств, их типов и обязательности
+// @Tags Analytics
+// @Produce json
+// @Success 200 {object} map[string]interface{}
+// @Router /events/schemas [get]
+func GetEventSchemas(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"events": events.Schemas()})
+}
+
+// GetEventStats возвращает число событий с группировкой
+// @Summary Статистика событий
+// @Description Количество событий и уникальных сессий с группировкой по событию, дате, странице или свойству события (property:<имя>, только вместе с name)
+// @Tags Analytics
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param name query string false "Событие (по умолчанию все)"
+// @Param group_by query string false "name, date, path или property:<имя> (по умолчанию name)"
+// @Param days query int false "Количество дней (по умолчанию 30)"
+// @Param limit query int false "Количество строк (по умолчанию 50)"
+// @Param traffic query string false "human - без ботов (по умолчанию), all - весь трафик"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]interface{}
+// @Failure 401 {object} map[string]interface{}
+// @Router /admin/event-stats [get]
+func GetEventStats(c *gin.Context) {
+	if !isValidAdminToken(c) {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Не авторизован"})
+		return
+	}
+
+	days, err := strconv.Atoi(c.DefaultQuery("days", "30"))
+	if err != nil || days <= 0 {
+		days = 30
+	}
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "50"))
+	if err != nil || limit <= 0 || limit > 500 {
+		limit = 50
+	}
+	humansOnly, ok := trafficParam(c)
+	if !ok {
+		return
+	}
+
+	query := events.Query{
+		Name:       c.Query("name"),
+		GroupBy:    c.DefaultQuery("group_by", events.GroupName),
+		StartDate:  time.Now().AddDate(0, 0, -days).Format("2006-01-02"),
+		HumansOnly: humansOnly,
+		Limit:      limit,
+	}
+	stats, err := events.Stats(database.DB, query)
+	if errors.Is(err, events.ErrInvalidGroup) || errors.Is(err, events.ErrUnknownEvent) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Ошибка при получении статистики событий"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"name":     query.Name,
+		"group_by": query.GroupBy,
+		"days":     days,
+		"traffic":  trafficName(humansOnly),
+		"stats":    stats,
+	})
+}
@@ -0,0 +1,85 @@
+package handlers
+
+import (
+	"bytes"
+	"errors"
+	"log"
+	"net/http"
+	"time"
+
+	"texnousta-backend/internal/database"
+	"texnousta-backend/internal/export"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ExportStats выгружает статистику или обращения в CSV или XLSX
+// @Summary Выгрузка статистики
+// @Description Выгрузки: visitors-daily, visitors-monthly, phone-clicks, phone-contacts, contacts. CSV отдается построчно по мере чтения из базы, XLSX содержит лист итогов и лист с данными
+// @Tags Analytics
+// @Produce text/csv
+// @Produce application/vnd.openxmlformats-officedocument.spreadsheetml.sheet
+// @Security BearerAuth
+// @Param dataset path string true "Выгрузка"
+// @Param format query string false "csv или xlsx (по умолчанию csv)"
+// @Param from query string false "Начало периода YYYY-MM-DD (по умолчанию 30 дней назад)"
+// @Param to query string false "Конец периода YYYY-MM-DD (по умолчанию сегодня)"
+// @Param traffic query string false "human - без ботов (по умолчанию), all - весь трафик"
+// @Success 200 {file} file
+// @Failure 400 {object} map[string]interface{}
+// @Failure 401 {object} map[string]interface{}
+// @Failure 403 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Router /admin/export/{dataset} [get]
+func ExportStats(c *gin.Context) {
+	ds, ok := export.Lookup(c.Param("dataset"))
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Выгрузка не найдена"})
+		return
+	}
+	format := c.DefaultQuery("format", export.FormatCSV)
+	if format != export.FormatCSV && format != export.FormatXLSX {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Формат должен быть csv или xlsx"})
+		return
+	}
+	humansOnly, ok := trafficParam(c)
+	if !ok {
+		return
+	}
+
+	now := time.Now()
+	filter, err := export.NewFilter(
+		c.DefaultQuery("from", now.AddDate(0, 0, -30).Format("2006-01-02")),
+		c.DefaultQuery("to", now.Format("2006-01-02")),
+		humansOnly,
+	)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.Header("Content-Disposition", `attachment; filename="`+export.Filename(ds, filter, format)+`"`)
+
+	if format == export.FormatXLSX {
+		var buf bytes.Buffer
+		err := export.WriteXLSX(&buf, database.DB, ds, filter)
+		if errors.Is(err, export.ErrTooManyRows) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		if err != nil {
+			log.Printf("❌ Ошибка выгрузки %s: %v", ds.Name, err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Ошибка при формировании файла"})
+			return
+		}
+		c.Data(http.StatusOK, export.ContentType(format), buf.Bytes())
+		return
+	}
+
+	// CSV пишется сразу в ответ: после первой строки статус уже не изменить
+	c.Header("Content-Type", export.ContentType(format))
+	c.Status(http.StatusOK)
+	if err := export.WriteCSV(c.Writer, database.DB, ds, filter); err != nil {
+		log.Printf("❌ Ошибка выгрузки %s: %v", ds.Name, err)
+	}
+}
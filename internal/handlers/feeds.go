@@ -0,0 +1,48 @@
+package handlers
+
+import (
+	"log"
+	"net/http"
+
+	"texnousta-backend/internal/feeds"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetYandexFeed отдает каталог в формате YML для Яндекс Маркета
+//
+//	@Summary		Фид Яндекс Маркета
+//	@Description	Активные товары в формате YML. Исключенные из фидов товары и категории не выгружаются
+//	@Tags			feeds
+//	@Produce		xml
+//	@Success		200	{string}	string	"YML"
+//	@Failure		500	{object}	map[string]interface{}
+//	@Router			/feeds/yandex.yml [get]
+func GetYandexFeed(c *gin.Context) {
+	renderFeed(c, feeds.FormatYML)
+}
+
+// GetGoogleFeed отдает каталог в формате RSS для Google Merchant Center
+//
+//	@Summary		Фид Google Merchant
+//	@Description	Активные товары в формате RSS 2.0 с пространством имен g:. Исключенные из фидов товары и категории не выгружаются
+//	@Tags			feeds
+//	@Produce		xml
+//	@Success		200	{string}	string	"RSS"
+//	@Failure		500	{object}	map[string]interface{}
+//	@Router			/feeds/google.xml [get]
+func GetGoogleFeed(c *gin.Context) {
+	renderFeed(c, feeds.FormatGoogle)
+}
+
+func renderFeed(c *gin.Context, format string) {
+	data, err := feeds.Render(format)
+	if err != nil {
+		log.Printf("❌ Ошибка формирования фида %s: %v", format, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Ошибка при формировании фида"})
+		return
+	}
+
+	c.Header("Cache-Control", "public, max-age=300")
+	c.Data(http.StatusOK, feeds.ContentType(format), data)
+}
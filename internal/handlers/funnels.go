@@ -0,0 +1,82 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"texnousta-backend/internal/analytics"
+	"texnousta-backend/internal/database"
+	"texnousta-backend/internal/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetFunnelStats возвращает отчет по воронке конверсии
+// @Summary Воронка конверсии
+// @Description Сколько сессий прошло каждый шаг воронки и сколько ушло между шагами. Готовые воронки: leads (посещение - звонок, заявка или заказ), calls, contacts, orders. Свои шаги задаются параметром steps через запятую: visit, phone_click, contact, phone_contact, order, page:<путь>, event:<событие>; альтернативы внутри шага - через |
+// @Tags Analytics
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param funnel query string false "Готовая воронка (по умолчанию leads)"
+// @Param steps query string false "Свои шаги через запятую, например visit,event:add_to_cart,order"
+// @Param from query string false "Начало периода YYYY-MM-DD (по умолчанию 30 дней назад)"
+// @Param to query string false "Конец периода YYYY-MM-DD (по умолчанию сегодня)"
+// @Param ordered query bool false "Шаги должны идти по порядку (по умолчанию true)"
+// @Param traffic query string false "human - без ботов (по умолчанию), all - весь трафик"
+// @Success 200 {object} models.FunnelResponse
+// @Failure 400 {object} map[string]interface{}
+// @Failure 401 {object} map[string]interface{}
+// @Router /admin/funnel-stats [get]
+func GetFunnelStats(c *gin.Context) {
+	if !isValidAdminToken(c) {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Не авторизован"})
+		return
+	}
+
+	name := "custom"
+	var steps []string
+	if param := c.Query("steps"); param != "" {
+		for _, step := range strings.Split(param, ",") {
+			steps = append(steps, strings.TrimSpace(step))
+		}
+	} else {
+		name = c.DefaultQuery("funnel", "leads")
+		preset, ok := analytics.FunnelPresets[name]
+		if !ok {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Неизвестная воронка: " + name})
+			return
+		}
+		steps = preset
+	}
+
+	now := time.Now()
+	from := c.DefaultQuery("from", now.AddDate(0, 0, -30).Format("2006-01-02"))
+	to := c.DefaultQuery("to", now.Format("2006-01-02"))
+	ordered := c.DefaultQuery("ordered", "true") != "false"
+	humansOnly, ok := trafficParam(c)
+	if !ok {
+		return
+	}
+
+	result, err := analytics.Funnel(database.DB, steps, from, to, ordered, humansOnly)
+	if errors.Is(err, analytics.ErrInvalidFunnel) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Ошибка при расчете воронки"})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.FunnelResponse{
+		Funnel:  name,
+		From:    from,
+		To:      to,
+		Ordered: ordered,
+		Traffic: trafficName(humansOnly),
+		Steps:   result,
+	})
+}
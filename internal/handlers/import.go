@@ -0,0 +1,85 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"os"
+	"strconv"
+
+	"texnousta-backend/internal/importer"
+	"texnousta-backend/internal/stockalerts"
+
+	"github.com/gin-gonic/gin"
+)
+
+// defaultMaxFileSize - ограничение размера файла, если MAX_FILE_SIZE не задан
+const defaultMaxFileSize = 5 << 20
+
+// ImportProducts загружает товары из файла CSV или XLSX (только для админов)
+//
+//	@Summary		Импорт товаров
+//	@Description	Создает или обновляет товары из CSV/XLSX. Товар ищется по артикулу (sku), затем по модели. Для обновления достаточно колонки sku или model и изменяемых колонок (например, sku,price); name, price и category нужны только для новых товаров. Пустая ячейка не меняет поле найденного товара. При ошибках ничего не сохраняется
+//	@Tags			admin
+//	@Accept			multipart/form-data
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Param			file	formData	file	true	"Файл CSV или XLSX"
+//	@Param			dry_run	query		bool	false	"Только проверить файл, не сохраняя изменения"
+//	@Success		200		{object}	importer.Report
+//	@Failure		400		{object}	map[string]interface{}
+//	@Failure		500		{object}	map[string]interface{}
+//	@Router			/admin/products/import [post]
+func ImportProducts(c *gin.Context) {
+	maxSize := int64(defaultMaxFileSize)
+	if v, err := strconv.ParseInt(os.Getenv("MAX_FILE_SIZE"), 10, 64); err == nil && v > 0 {
+		maxSize = v
+	}
+
+	header, err := c.FormFile("file")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Файл не передан"})
+		return
+	}
+	if header.Size > maxSize {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Файл слишком большой"})
+		return
+	}
+
+	file, err := header.Open()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Ошибка при чтении файла"})
+		return
+	}
+	defer file.Close()
+
+	actorID, actorName := currentActor(c)
+	report, err := importer.Import(header.Filename, file, importer.Options{
+		DryRun:    c.Query("dry_run") == "true",
+		ActorID:   actorID,
+		ActorName: actorName,
+	})
+	var fileErr *importer.FileError
+	if errors.As(err, &fileErr) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fileErr.Message})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Ошибка при импорте товаров"})
+		return
+	}
+
+	if len(report.Errors) > 0 {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":  "Файл содержит ошибки, изменения не сохранены",
+			"report": report,
+		})
+		return
+	}
+
+	// Оповещения о низком остатке и о поступлении для подписчиков
+	if report.Committed {
+		stockalerts.CheckAsync(report.ProductIDs...)
+	}
+
+	c.JSON(http.StatusOK, report)
+}
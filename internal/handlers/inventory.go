@@ -0,0 +1,171 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"texnousta-backend/internal/database"
+	"texnousta-backend/internal/inventory"
+	"texnousta-backend/internal/models"
+	"texnousta-backend/internal/stockalerts"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// PostStockMovement проводит движение товара (только для админов)
+//
+//	@Summary		Провести движение товара
+//	@Description	Поступление, продажа, возврат или корректировка. Остаток товара меняется вместе с записью в журнале. Резервы проводятся только заказами
+//	@Tags			admin
+//	@Accept			json
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Param			id			path		int							true	"ID товара"
+//	@Param			movement	body		models.StockMovementRequest	true	"Данные движения"
+//	@Success		201			{object}	map[string]interface{}
+//	@Failure		400			{object}	map[string]interface{}
+//	@Failure		404			{object}	map[string]interface{}
+//	@Failure		409			{object}	map[string]interface{}
+//	@Failure		500			{object}	map[string]interface{}
+//	@Router			/admin/products/{id}/stock-movements [post]
+func PostStockMovement(c *gin.Context) {
+	id := c.Param("id")
+
+	var product models.Product
+	if err := database.DB.First(&product, id).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Товар не найден"})
+		return
+	}
+
+	var req models.StockMovementRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if req.WarehouseID != nil {
+		var warehouse models.Warehouse
+		if err := database.DB.Where("id = ? AND is_active = ?", *req.WarehouseID, true).
+			First(&warehouse).Error; err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Склад не найден"})
+			return
+		}
+	}
+
+	actorID, actorName := currentActor(c)
+	movement := models.StockMovement{
+		ProductID:   product.ID,
+		WarehouseID: req.WarehouseID,
+		Type:        req.Type,
+		Quantity:    inventory.Signed(req.Type, req.Quantity),
+		Reason:      req.Reason,
+		ActorID:     actorID,
+		ActorName:   actorName,
+		OrderID:     req.OrderID,
+	}
+
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		return inventory.Post(tx, &movement)
+	})
+	if errors.Is(err, inventory.ErrInsufficientStock) {
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Ошибка при проведении движения"})
+		return
+	}
+
+	stockalerts.CheckAsync(product.ID)
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message":  "Движение успешно проведено",
+		"movement": movement,
+		"stock":    movement.BalanceAfter,
+	})
+}
+
+// GetStockMovements получает журнал движения товара (только для админов)
+//
+//	@Summary		Журнал движения товара
+//	@Description	История поступлений, продаж, возвратов, корректировок и резервов товара
+//	@Tags			admin
+//	@Accept			json
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Param			id		path		int		true	"ID товара"
+//	@Param			type	query		string	false	"Тип движения"
+//	@Param			warehouse_id	query		int		false	"ID склада"
+//	@Param			page	query		int		false	"Номер страницы"		default(1)
+//	@Param			limit	query		int		false	"Количество на странице"	default(50)
+//	@Success		200		{object}	map[string]interface{}
+//	@Failure		404		{object}	map[string]interface{}
+//	@Failure		500		{object}	map[string]interface{}
+//	@Router			/admin/products/{id}/stock-movements [get]
+func GetStockMovements(c *gin.Context) {
+	id := c.Param("id")
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "50"))
+
+	if page <= 0 {
+		page = 1
+	}
+	if limit <= 0 || limit > 200 {
+		limit = 50
+	}
+
+	offset := (page - 1) * limit
+
+	var product models.Product
+	if err := database.DB.First(&product, id).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Товар не найден"})
+		return
+	}
+
+	query := database.DB.Model(&models.StockMovement{}).Where("product_id = ?", product.ID)
+	if movementType := c.Query("type"); movementType != "" {
+		query = query.Where("type = ?", movementType)
+	}
+	if warehouseID := c.Query("warehouse_id"); warehouseID != "" {
+		query = query.Where("warehouse_id = ?", warehouseID)
+	}
+
+	var total int64
+	query.Count(&total)
+
+	var movements []models.StockMovement
+	if err := query.Preload("Warehouse").
+		Order("id DESC").
+		Offset(offset).
+		Limit(limit).
+		Find(&movements).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Ошибка при получении журнала движения"})
+		return
+	}
+
+	availability, _ := inventory.Availability(database.DB, product.ID)
+
+	c.JSON(http.StatusOK, gin.H{
+		"product_id":   product.ID,
+		"stock":        product.Stock,
+		"availability": availability,
+		"movements":    movements,
+		"pagination": gin.H{
+			"page":        page,
+			"limit":       limit,
+			"total":       total,
+			"total_pages": (total + int64(limit) - 1) / int64(limit),
+		},
+	})
+}
+
+// currentActor возвращает ID и имя пользователя, выполняющего действие
+func currentActor(c *gin.Context) (*uint, string) {
+	user, exists := c.Get("user")
+	if !exists {
+		return nil, "system"
+	}
+	userModel := user.(models.User)
+	return &userModel.ID, userModel.Name
+}
@@ -0,0 +1,352 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"texnousta-backend/internal/database"
+	"texnousta-backend/internal/marketplace"
+	"texnousta-backend/internal/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// marketplaceTimeout - ограничение времени на запросы к API маркетплейса
+const marketplaceTimeout = 2 * time.Minute
+
+// marketplaceAdapter находит адаптер по коду из URL и отвечает 404, если его нет
+func marketplaceAdapter(c *gin.Context) (marketplace.Adapter, bool) {
+	adapter, err := marketplace.Get(c.Param("name"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Маркетплейс не найден"})
+		return nil, false
+	}
+	return adapter, true
+}
+
+// marketplaceError отвечает на ошибку обращения к маркетплейсу
+func marketplaceError(c *gin.Context, adapter marketplace.Adapter, err error) {
+	if errors.Is(err, marketplace.ErrNotConfigured) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	log.Printf("❌ Ошибка обмена с маркетплейсом %s: %v", adapter.Name(), err)
+	c.JSON(http.StatusBadGateway, gin.H{"error": "Ошибка обмена с маркетплейсом", "details": err.Error()})
+}
+
+// GetMarketplaces получает список подключенных маркетплейсов (только для админов)
+func GetMarketplaces(c *gin.Context) {
+	type mappingCount struct {
+		Marketplace string
+		Count       int64
+	}
+	var counts []mappingCount
+	database.DB.Model(&models.MarketplaceCategory{}).
+		Select("marketplace, COUNT(*) AS count").
+		Group("marketplace").
+		Scan(&counts)
+
+	mapped := make(map[string]int64, len(counts))
+	for _, row := range counts {
+		mapped[row.Marketplace] = row.Count
+	}
+
+	items := make([]gin.H, 0)
+	for _, name := range marketplace.Names() {
+		items = append(items, gin.H{"name": name, "mapped_categories": mapped[name]})
+	}
+	c.JSON(http.StatusOK, gin.H{"marketplaces": items})
+}
+
+// GetMarketplaceCategories получает сопоставление категорий с маркетплейсом (только для админов)
+func GetMarketplaceCategories(c *gin.Context) {
+	adapter, ok := marketplaceAdapter(c)
+	if !ok {
+		return
+	}
+
+	var mappings []models.MarketplaceCategory
+	if err := database.DB.Preload("Category").
+		Where("marketplace = ?", adapter.Name()).
+		Order("category_id ASC").
+		Find(&mappings).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Ошибка при получении категорий"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"categories": mappings})
+}
+
+// SetMarketplaceCategory сопоставляет категорию с категорией маркетплейса
+//
+//	@Summary		Сопоставить категорию с маркетплейсом
+//	@Description	Создает или изменяет соответствие нашей категории категории маркетплейса. Товары выгружаются только из сопоставленных категорий
+//	@Tags			admin
+//	@Accept			json
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Param			name		path		string								true	"Код маркетплейса, например uzum"
+//	@Param			mapping		body		models.MarketplaceCategoryRequest	true	"Сопоставление"
+//	@Success		200			{object}	map[string]interface{}
+//	@Failure		400			{object}	map[string]interface{}
+//	@Failure		404			{object}	map[string]interface{}
+//	@Failure		500			{object}	map[string]interface{}
+//	@Router			/admin/marketplaces/{name}/categories [put]
+func SetMarketplaceCategory(c *gin.Context) {
+	adapter, ok := marketplaceAdapter(c)
+	if !ok {
+		return
+	}
+
+	var req models.MarketplaceCategoryRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var category models.Category
+	if err := database.DB.First(&category, req.CategoryID).Error; err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Категория не найдена"})
+		return
+	}
+
+	mapping := models.MarketplaceCategory{Marketplace: adapter.Name(), CategoryID: req.CategoryID}
+	err := database.DB.Where(mapping).
+		Assign(models.MarketplaceCategory{
+			ExternalCategoryID:   req.ExternalCategoryID,
+			ExternalCategoryName: req.ExternalCategoryName,
+		}).
+		FirstOrCreate(&mapping).Error
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Ошибка при сохранении сопоставления"})
+		return
+	}
+
+	mapping.Category = category
+	c.JSON(http.StatusOK, gin.H{
+		"message":  "Категория сопоставлена",
+		"category": mapping,
+	})
+}
+
+// DeleteMarketplaceCategory удаляет сопоставление категории (только для админов)
+func DeleteMarketplaceCategory(c *gin.Context) {
+	adapter, ok := marketplaceAdapter(c)
+	if !ok {
+		return
+	}
+
+	result := database.DB.
+		Where("marketplace = ? AND category_id = ?", adapter.Name(), c.Param("category_id")).
+		Delete(&models.MarketplaceCategory{})
+	if result.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Ошибка при удалении сопоставления"})
+		return
+	}
+	if result.RowsAffected == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Сопоставление не найдено"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Сопоставление удалено"})
+}
+
+// GetMarketplaceBulkFile формирует файл массовой загрузки карточек
+//
+//	@Summary		Файл массовой загрузки
+//	@Description	XLSX с товарами сопоставленных категорий в формате маркетплейса для ручной загрузки в личном кабинете
+//	@Tags			admin
+//	@Produce		application/vnd.openxmlformats-officedocument.spreadsheetml.sheet
+//	@Security		BearerAuth
+//	@Param			name	path		string	true	"Код маркетплейса"
+//	@Success		200		{file}		file
+//	@Failure		400		{object}	map[string]interface{}
+//	@Failure		404		{object}	map[string]interface{}
+//	@Router			/admin/marketplaces/{name}/bulk-file [get]
+func GetMarketplaceBulkFile(c *gin.Context) {
+	adapter, ok := marketplaceAdapter(c)
+	if !ok {
+		return
+	}
+
+	filer, ok := adapter.(marketplace.BulkFiler)
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Маркетплейс не поддерживает файл массовой загрузки"})
+		return
+	}
+
+	listings, err := marketplace.Listings(database.DB, adapter.Name())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Ошибка при получении товаров"})
+		return
+	}
+
+	var buf bytes.Buffer
+	if err := filer.WriteBulkFile(&buf, listings); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Ошибка при формировании файла"})
+		return
+	}
+
+	c.Header("Content-Disposition", `attachment; filename="`+adapter.Name()+`-products.xlsx"`)
+	c.Data(http.StatusOK, "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet", buf.Bytes())
+}
+
+// PushMarketplaceProducts выгружает карточки товаров на маркетплейс
+//
+//	@Summary		Выгрузить карточки на маркетплейс
+//	@Description	Создает или обновляет карточки товаров из сопоставленных категорий
+//	@Tags			admin
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Param			name	path		string	true	"Код маркетплейса"
+//	@Success		200		{object}	marketplace.PushResult
+//	@Failure		400		{object}	map[string]interface{}
+//	@Failure		502		{object}	map[string]interface{}
+//	@Router			/admin/marketplaces/{name}/push-products [post]
+func PushMarketplaceProducts(c *gin.Context) {
+	adapter, ok := marketplaceAdapter(c)
+	if !ok {
+		return
+	}
+
+	listings, err := marketplace.Listings(database.DB, adapter.Name())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Ошибка при получении товаров"})
+		return
+	}
+	if len(listings) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Нет товаров для выгрузки: сопоставьте категории с маркетплейсом"})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), marketplaceTimeout)
+	defer cancel()
+	result, err := adapter.PushProducts(ctx, listings)
+	if err != nil {
+		marketplaceError(c, adapter, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+// PushMarketplaceStock обновляет цены и остатки на маркетплейсе (только для админов)
+func PushMarketplaceStock(c *gin.Context) {
+	adapter, ok := marketplaceAdapter(c)
+	if !ok {
+		return
+	}
+
+	updates, err := marketplace.StockUpdates(database.DB, adapter.Name())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Ошибка при получении товаров"})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), marketplaceTimeout)
+	defer cancel()
+	if err := adapter.PushStock(ctx, updates); err != nil {
+		marketplaceError(c, adapter, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Цены и остатки обновлены", "updated": len(updates)})
+}
+
+// PullMarketplaceOrders получает новые заказы с маркетплейса
+//
+//	@Summary		Получить заказы с маркетплейса
+//	@Description	Загружает заказы, созданные после since (по умолчанию - после последнего полученного заказа или за 7 дней)
+//	@Tags			admin
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Param			name	path		string	true	"Код маркетплейса"
+//	@Param			since	query		string	false	"Дата в формате RFC3339"
+//	@Success		200		{object}	map[string]interface{}
+//	@Failure		400		{object}	map[string]interface{}
+//	@Failure		502		{object}	map[string]interface{}
+//	@Router			/admin/marketplaces/{name}/pull-orders [post]
+func PullMarketplaceOrders(c *gin.Context) {
+	adapter, ok := marketplaceAdapter(c)
+	if !ok {
+		return
+	}
+
+	since := time.Now().AddDate(0, 0, -7)
+	if v := c.Query("since"); v != "" {
+		parsed, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Некорректная дата since"})
+			return
+		}
+		since = parsed
+	} else {
+		var last models.MarketplaceOrder
+		if err := database.DB.Where("marketplace = ?", adapter.Name()).
+			Order("placed_at DESC").
+			First(&last).Error; err == nil {
+			since = last.PlacedAt
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), marketplaceTimeout)
+	defer cancel()
+	orders, err := adapter.PullOrders(ctx, since)
+	if err != nil {
+		marketplaceError(c, adapter, err)
+		return
+	}
+
+	created, err := marketplace.SaveOrders(database.DB, adapter.Name(), orders)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Ошибка при сохранении заказов"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"received": len(orders),
+		"created":  created,
+	})
+}
+
+// GetMarketplaceOrders получает сохраненные заказы с маркетплейса (только для админов)
+func GetMarketplaceOrders(c *gin.Context) {
+	adapter, ok := marketplaceAdapter(c)
+	if !ok {
+		return
+	}
+
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+	offset := (page - 1) * limit
+
+	query := database.DB.Model(&models.MarketplaceOrder{}).Where("marketplace = ?", adapter.Name())
+
+	var total int64
+	query.Count(&total)
+
+	var orders []models.MarketplaceOrder
+	if err := query.Preload("Items").
+		Order("placed_at DESC").
+		Offset(offset).
+		Limit(limit).
+		Find(&orders).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Ошибка при получении заказов"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"orders": orders,
+		"pagination": gin.H{
+			"page":        page,
+			"limit":       limit,
+			"total":       total,
+			"total_pages": (total + int64(limit) - 1) / int64(limit),
+		},
+	})
+}
@@ -0,0 +1,186 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"texnousta-backend/internal/database"
+	"texnousta-backend/internal/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// SubscribeToStock подписывает покупателя на поступление товара
+//
+//	@Summary		Сообщить о поступлении
+//	@Description	Подписка по телефону или email на товар, которого нет в наличии
+//	@Tags			products
+//	@Accept			json
+//	@Produce		json
+//	@Param			id				path		int								true	"ID товара"
+//	@Param			subscription	body		models.StockSubscriptionRequest	true	"Телефон или email"
+//	@Success		201				{object}	map[string]interface{}
+//	@Failure		400				{object}	map[string]interface{}
+//	@Failure		404				{object}	map[string]interface{}
+//	@Failure		500				{object}	map[string]interface{}
+//	@Router			/products/{id}/subscribe [post]
+func SubscribeToStock(c *gin.Context) {
+	id := c.Param("id")
+
+	var product models.Product
+	if err := database.DB.Where("id = ? AND is_active = ?", id, true).
+		First(&product).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Товар не найден"})
+		return
+	}
+
+	var req models.StockSubscriptionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if product.Stock > 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Товар есть в наличии"})
+		return
+	}
+
+	phone := strings.TrimSpace(req.Phone)
+	email := strings.ToLower(strings.TrimSpace(req.Email))
+
+	// Повторная подписка с теми же контактами не создает дубликат
+	var subscription models.StockSubscription
+	err := database.DB.Where(models.StockSubscription{ProductID: product.ID, Phone: phone, Email: email}).
+		Where("notified_at IS NULL").
+		FirstOrCreate(&subscription).Error
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Ошибка при сохранении подписки"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message": "Мы сообщим вам, когда товар появится в наличии",
+		"id":      subscription.ID,
+	})
+}
+
+// GetAdminNotifications получает уведомления администратора
+//
+//	@Summary		Уведомления администратора
+//	@Description	Список уведомлений (например, о низком остатке товара)
+//	@Tags			admin
+//	@Accept			json
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Param			page	query		int		false	"Номер страницы"		default(1)
+//	@Param			limit	query		int		false	"Количество на странице"	default(20)
+//	@Param			unread	query		bool	false	"Только непрочитанные"
+//	@Success		200		{object}	map[string]interface{}
+//	@Failure		500		{object}	map[string]interface{}
+//	@Router			/admin/notifications [get]
+func GetAdminNotifications(c *gin.Context) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+	onlyUnread := c.Query("unread") == "true"
+
+	offset := (page - 1) * limit
+
+	query := database.DB.Model(&models.AdminNotification{})
+	if onlyUnread {
+		query = query.Where("is_read = ?", false)
+	}
+
+	var total int64
+	query.Count(&total)
+
+	var unread int64
+	database.DB.Model(&models.AdminNotification{}).Where("is_read = ?", false).Count(&unread)
+
+	var notifications []models.AdminNotification
+	if err := query.Order("created_at DESC").
+		Offset(offset).
+		Limit(limit).
+		Find(&notifications).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Ошибка при получении уведомлений"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"notifications": notifications,
+		"unread":        unread,
+		"pagination": gin.H{
+			"page":        page,
+			"limit":       limit,
+			"total":       total,
+			"total_pages": (total + int64(limit) - 1) / int64(limit),
+		},
+	})
+}
+
+// MarkAdminNotificationAsRead помечает уведомление как прочитанное (только для админов)
+func MarkAdminNotificationAsRead(c *gin.Context) {
+	id := c.Param("id")
+
+	result := database.DB.Model(&models.AdminNotification{}).
+		Where("id = ?", id).
+		Update("is_read", true)
+	if result.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Ошибка при обновлении уведомления"})
+		return
+	}
+	if result.RowsAffected == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Уведомление не найдено"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Уведомление помечено как прочитанное"})
+}
+
+// GetLowStockProducts получает товары с остатком не выше порога (только для админов)
+//
+//	@Summary		Товары с низким остатком
+//	@Description	Активные товары, остаток которых опустился до заданного порога
+//	@Tags			admin
+//	@Accept			json
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Success		200	{object}	map[string]interface{}
+//	@Failure		500	{object}	map[string]interface{}
+//	@Router			/admin/products/low-stock [get]
+func GetLowStockProducts(c *gin.Context) {
+	var products []models.Product
+	if err := database.DB.Preload("Category").
+		Where("is_active = ? AND low_stock_threshold > 0 AND stock <= low_stock_threshold", true).
+		Order("stock ASC").
+		Find(&products).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Ошибка при получении товаров"})
+		return
+	}
+
+	// Сколько покупателей ждут поступления каждого товара
+	type waiting struct {
+		ProductID uint
+		Count     int64
+	}
+	var rows []waiting
+	database.DB.Model(&models.StockSubscription{}).
+		Select("product_id, COUNT(*) AS count").
+		Where("notified_at IS NULL").
+		Group("product_id").
+		Scan(&rows)
+
+	subscribers := make(map[uint]int64, len(rows))
+	for _, row := range rows {
+		subscribers[row.ProductID] = row.Count
+	}
+
+	items := make([]gin.H, 0, len(products))
+	for _, product := range products {
+		items = append(items, gin.H{
+			"product":     product,
+			"subscribers": subscribers[product.ID],
+		})
+	}
+
+	c.JSON(http.StatusOK, gin.H{"products": items})
+}
@@ -0,0 +1,235 @@
+package handlers
+
+import (
+	"bytes"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+
+	"texnousta-backend/internal/commerceml"
+	"texnousta-backend/internal/database"
+	"texnousta-backend/internal/models"
+	"texnousta-backend/internal/stockalerts"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// onecCookie - имя cookie сессии обмена с 1С
+const onecCookie = "texnousta_1c"
+
+// onecSessionTTL - время жизни сессии обмена
+const onecSessionTTL = time.Hour
+
+// onecExported - заказы, отданные 1С в последнем query, по токену сессии.
+// Отмечаются выгруженными только после подтверждения success.
+var onecExported = struct {
+	sync.Mutex
+	ids map[string][]uint
+}{ids: make(map[string][]uint)}
+
+// OneCExchange - точка обмена с 1С по протоколу CommerceML
+//
+//	@Summary		Обмен с 1С (CommerceML)
+//	@Description	Протокол обмена 1С: checkauth (Basic-авторизация администратора), init, file, import для каталога; query и success для заказов. Ответы - text/plain
+//	@Tags			1c
+//	@Accept			xml
+//	@Produce		plain
+//	@Param			type		query		string	true	"catalog или sale"
+//	@Param			mode		query		string	true	"checkauth, init, file, import, query, success"
+//	@Param			filename	query		string	false	"Имя файла для file и import"
+//	@Success		200			{string}	string	"success"
+//	@Failure		401			{string}	string	"failure"
+//	@Router			/1c/exchange [get]
+//	@Router			/1c/exchange [post]
+func OneCExchange(c *gin.Context) {
+	exchangeType := c.Query("type")
+	mode := c.Query("mode")
+
+	if mode == "checkauth" {
+		onecCheckAuth(c)
+		return
+	}
+
+	user, token, ok := onecSession(c)
+	if !ok {
+		onecFailure(c, http.StatusUnauthorized, "Требуется авторизация")
+		return
+	}
+
+	switch {
+	case mode == "init":
+		// Новый сеанс выгрузки каталога начинается с чистого каталога обмена
+		if exchangeType == "catalog" {
+			if err := commerceml.Reset(); err != nil {
+				log.Printf("❌ Ошибка подготовки каталога обмена 1С: %v", err)
+				onecFailure(c, http.StatusInternalServerError, "Ошибка подготовки каталога обмена")
+				return
+			}
+		}
+		c.String(http.StatusOK, "zip=no\nfile_limit=%d\n", commerceml.FileLimit)
+
+	case mode == "file" && exchangeType == "catalog":
+		if err := commerceml.SaveFile(c.Query("filename"), c.Request.Body); err != nil {
+			onecFailure(c, http.StatusBadRequest, err.Error())
+			return
+		}
+		onecSuccess(c)
+
+	case mode == "import" && exchangeType == "catalog":
+		onecImport(c, user)
+
+	case mode == "query" && exchangeType == "sale":
+		onecQueryOrders(c, token)
+
+	case mode == "success" && exchangeType == "sale":
+		onecExported.Lock()
+		ids := onecExported.ids[token]
+		delete(onecExported.ids, token)
+		onecExported.Unlock()
+
+		if err := commerceml.MarkExported(database.DB, ids); err != nil {
+			onecFailure(c, http.StatusInternalServerError, "Ошибка при отметке заказов")
+			return
+		}
+		onecSuccess(c)
+
+	case mode == "file" && exchangeType == "sale":
+		// Изменения заказов из 1С пока не загружаются на сайт
+		onecSuccess(c)
+
+	default:
+		onecFailure(c, http.StatusBadRequest, fmt.Sprintf("Неизвестный режим обмена: %s/%s", exchangeType, mode))
+	}
+}
+
+// onecCheckAuth проверяет логин и пароль администратора и открывает сессию
+func onecCheckAuth(c *gin.Context) {
+	email, password, ok := c.Request.BasicAuth()
+	if !ok {
+		c.Header("WWW-Authenticate", `Basic realm="1C exchange"`)
+		onecFailure(c, http.StatusUnauthorized, "Требуется авторизация")
+		return
+	}
+
+	var user models.User
+	if err := database.DB.Where("email = ?", email).First(&user).Error; err != nil ||
+		!user.IsActive || user.Role != "admin" ||
+		bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(password)) != nil {
+		onecFailure(c, http.StatusUnauthorized, "Неверные учетные данные")
+		return
+	}
+
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+		"user_id": user.ID,
+		"role":    user.Role,
+		"scope":   "1c",
+		"exp":     time.Now().Add(onecSessionTTL).Unix(),
+	})
+	tokenString, err := token.SignedString([]byte(os.Getenv("JWT_SECRET")))
+	if err != nil {
+		onecFailure(c, http.StatusInternalServerError, "Ошибка при создании сессии")
+		return
+	}
+
+	c.String(http.StatusOK, "success\n%s\n%s\n", onecCookie, tokenString)
+}
+
+// onecSession проверяет cookie сессии, выданной checkauth
+func onecSession(c *gin.Context) (*models.User, string, bool) {
+	tokenString, err := c.Cookie(onecCookie)
+	if err != nil || tokenString == "" {
+		return nil, "", false
+	}
+
+	token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
+		return []byte(os.Getenv("JWT_SECRET")), nil
+	}, jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}))
+	if err != nil || !token.Valid {
+		return nil, "", false
+	}
+
+	claims, ok := token.Claims.(jwt.MapClaims)
+	if !ok || claims["scope"] != "1c" {
+		return nil, "", false
+	}
+	userID, ok := claims["user_id"].(float64)
+	if !ok {
+		return nil, "", false
+	}
+
+	var user models.User
+	if err := database.DB.First(&user, uint(userID)).Error; err != nil ||
+		!user.IsActive || user.Role != "admin" {
+		return nil, "", false
+	}
+	return &user, tokenString, true
+}
+
+// onecImport загружает принятый файл (import.xml или offers.xml)
+func onecImport(c *gin.Context, user *models.User) {
+	filename := c.Query("filename")
+	priceType := os.Getenv("ONEC_PRICE_TYPE")
+	if priceType == "" {
+		priceType = "Розничная"
+	}
+	result, err := commerceml.ImportFile(database.DB, filename, commerceml.Options{
+		PriceType: priceType,
+		ActorID:   &user.ID,
+		ActorName: user.Name,
+	})
+	if err != nil {
+		log.Printf("❌ Ошибка загрузки %s из 1С: %v", filename, err)
+		onecFailure(c, http.StatusOK, err.Error())
+		return
+	}
+
+	log.Printf("✅ Загружен %s из 1С: категорий +%d/%d, товаров +%d/%d (снято %d), предложений %d (пропущено %d)",
+		filename,
+		result.CategoriesCreated, result.CategoriesUpdated,
+		result.ProductsCreated, result.ProductsUpdated, result.ProductsDisabled,
+		result.OffersApplied, result.OffersSkipped)
+
+	// Оповещения о низком остатке и о поступлении для подписчиков
+	stockalerts.CheckAsync(result.ProductIDs...)
+
+	onecSuccess(c)
+}
+
+// onecQueryOrders отдает 1С заказы, которые еще не были выгружены
+func onecQueryOrders(c *gin.Context, token string) {
+	orders, err := commerceml.PendingOrders(database.DB)
+	if err != nil {
+		onecFailure(c, http.StatusInternalServerError, "Ошибка при получении заказов")
+		return
+	}
+
+	var buf bytes.Buffer
+	if err := commerceml.WriteOrders(&buf, orders); err != nil {
+		onecFailure(c, http.StatusInternalServerError, "Ошибка при формировании файла заказов")
+		return
+	}
+
+	ids := make([]uint, 0, len(orders))
+	for _, order := range orders {
+		ids = append(ids, order.ID)
+	}
+	onecExported.Lock()
+	onecExported.ids[token] = ids
+	onecExported.Unlock()
+
+	c.Data(http.StatusOK, "application/xml; charset=utf-8", buf.Bytes())
+}
+
+func onecSuccess(c *gin.Context) {
+	c.String(http.StatusOK, "success\n")
+}
+
+// onecFailure - ответ об ошибке в формате протокола обмена
+func onecFailure(c *gin.Context, status int, message string) {
+	c.String(status, "failure\n%s\n", message)
+}
@@ -0,0 +1,332 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"texnousta-backend/internal/database"
+	"texnousta-backend/internal/inventory"
+	"texnousta-backend/internal/models"
+	"texnousta-backend/internal/promotions"
+	"texnousta-backend/internal/stockalerts"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// CreateOrder оформляет заказ текущего пользователя
+//
+//	@Summary		Оформить заказ
+//	@Description	Оформление заказа: цены пересчитываются с учетом действующих акций, товар резервируется в точке самовывоза или на складе доставки
+//	@Tags			orders
+//	@Accept			json
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Param			order	body		models.OrderRequest	true	"Состав заказа и данные доставки"
+//	@Success		201		{object}	map[string]interface{}
+//	@Failure		400		{object}	map[string]interface{}
+//	@Failure		401		{object}	map[string]interface{}
+//	@Failure		409		{object}	map[string]interface{}
+//	@Failure		500		{object}	map[string]interface{}
+//	@Router			/orders [post]
+func CreateOrder(c *gin.Context) {
+	var req models.OrderRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	userID := c.GetUint("user_id")
+	_, actorName := currentActor(c)
+
+	// Загрузка товаров корзины
+	lines := make([]promotions.CartLine, 0, len(req.Items))
+	for _, item := range req.Items {
+		var product models.Product
+		if err := database.DB.Where("id = ? AND is_active = ?", item.ProductID, true).
+			First(&product).Error; err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Товар %d не найден", item.ProductID)})
+			return
+		}
+		lines = append(lines, promotions.CartLine{Product: product, Quantity: item.Quantity})
+	}
+
+	// Повторный расчет цен с учетом акций на момент оформления
+	priced, total, err := promotions.PriceCart(lines)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Ошибка при расчете стоимости заказа"})
+		return
+	}
+
+	// Склад резервирования: выбранная точка самовывоза или основной склад для доставки
+	deliveryType := "delivery"
+	shippingAddress := req.ShippingAddress
+	var warehouse *models.Warehouse
+	if req.PickupPointID != nil {
+		var point models.Warehouse
+		if err := database.DB.Where("id = ? AND is_active = ? AND is_pickup_point = ?", *req.PickupPointID, true, true).
+			First(&point).Error; err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Точка самовывоза не найдена"})
+			return
+		}
+		warehouse = &point
+		deliveryType = "pickup"
+		if shippingAddress == "" {
+			shippingAddress = "Самовывоз: " + point.Name + ", " + point.Address
+		}
+	} else {
+		warehouse, err = inventory.DefaultWarehouse(database.DB)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Склад доставки не настроен"})
+			return
+		}
+	}
+
+	order := models.Order{
+		UserID:          userID,
+		Total:           total,
+		ShippingAddress: shippingAddress,
+		Phone:           req.Phone,
+		Notes:           req.Notes,
+		DeliveryType:    deliveryType,
+		WarehouseID:     &warehouse.ID,
+		SessionID:       requestSessionID(c),
+	}
+
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&order).Error; err != nil {
+			return err
+		}
+
+		for _, line := range priced {
+			// Резерв на выбранном складе: остаток не может уйти в минус.
+			// В продажу резерв переводится при выдаче заказа (UpdateOrderStatus)
+			if err := inventory.Post(tx, &models.StockMovement{
+				ProductID:   line.Product.ID,
+				WarehouseID: &warehouse.ID,
+				Type:        inventory.Reservation,
+				Quantity:    inventory.Signed(inventory.Reservation, line.Quantity),
+				Reason:      fmt.Sprintf("Резерв по заказу #%d", order.ID),
+				ActorID:     &order.UserID,
+				ActorName:   actorName,
+				OrderID:     &order.ID,
+			}); err != nil {
+				return err
+			}
+
+			item := models.OrderItem{
+				OrderID:   order.ID,
+				ProductID: line.Product.ID,
+				Quantity:  line.Quantity,
+				Price:     line.UnitPrice,
+				BasePrice: line.BasePrice,
+			}
+			if line.Promotion != nil {
+				item.PromotionID = &line.Promotion.ID
+			}
+			if err := tx.Create(&item).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+
+	if errors.Is(err, inventory.ErrInsufficientStock) {
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Ошибка при оформлении заказа"})
+		return
+	}
+
+	stockalerts.CheckAsync(orderProductIDs(priced)...)
+
+	database.DB.Preload("OrderItems.Product").Preload("Warehouse").First(&order, order.ID)
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message": "Заказ успешно оформлен",
+		"order":   order,
+	})
+}
+
+// GetMyOrders получает заказы текущего пользователя
+//
+//	@Summary		Мои заказы
+//	@Description	Получение списка заказов текущего пользователя
+//	@Tags			orders
+//	@Accept			json
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Success		200	{object}	map[string]interface{}
+//	@Failure		401	{object}	map[string]interface{}
+//	@Failure		500	{object}	map[string]interface{}
+//	@Router			/orders [get]
+func GetMyOrders(c *gin.Context) {
+	userID := c.GetUint("user_id")
+
+	var orders []models.Order
+	if err := database.DB.Preload("OrderItems.Product").Preload("Warehouse").
+		Where("user_id = ?", userID).
+		Order("created_at DESC").
+		Find(&orders).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Ошибка при получении заказов"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"orders": orders})
+}
+
+// GetOrders получает список всех заказов (только для админов)
+//
+//	@Summary		Получить список заказов
+//	@Description	Получение заказов с фильтрацией по статусу (только для администраторов)
+//	@Tags			admin
+//	@Accept			json
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Param			page	query		int		false	"Номер страницы"		default(1)
+//	@Param			limit	query		int		false	"Количество на странице"	default(20)
+//	@Param			status	query		string	false	"Статус заказа"
+//	@Success		200		{object}	map[string]interface{}
+//	@Failure		500		{object}	map[string]interface{}
+//	@Router			/admin/orders [get]
+func GetOrders(c *gin.Context) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+	status := c.Query("status")
+
+	offset := (page - 1) * limit
+
+	query := database.DB.Model(&models.Order{})
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+
+	var total int64
+	query.Count(&total)
+
+	var orders []models.Order
+	if err := query.Preload("OrderItems.Product").Preload("Warehouse").
+		Order("created_at DESC").
+		Offset(offset).
+		Limit(limit).
+		Find(&orders).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Ошибка при получении заказов"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"orders": orders,
+		"pagination": gin.H{
+			"page":        page,
+			"limit":       limit,
+			"total":       total,
+			"total_pages": (total + int64(limit) - 1) / int64(limit),
+		},
+	})
+}
+
+// errOrderCompleted - заказ уже выдан или отменен
+var errOrderCompleted = errors.New("Заказ уже завершен")
+
+// UpdateOrderStatus меняет статус заказа (только для админов).
+// При выдаче заказа резерв переводится в продажу, при отмене - снимается.
+//
+//	@Summary		Изменить статус заказа
+//	@Description	Смена статуса заказа. delivered переводит резерв в продажу, cancelled снимает резерв
+//	@Tags			admin
+//	@Accept			json
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Param			id		path		int		true	"ID заказа"
+//	@Param			status	body		object	true	"Новый статус"
+//	@Success		200		{object}	map[string]interface{}
+//	@Failure		400		{object}	map[string]interface{}
+//	@Failure		404		{object}	map[string]interface{}
+//	@Failure		500		{object}	map[string]interface{}
+//	@Router			/admin/orders/{id}/status [put]
+func UpdateOrderStatus(c *gin.Context) {
+	id := c.Param("id")
+
+	var order models.Order
+	if err := database.DB.First(&order, id).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Заказ не найден"})
+		return
+	}
+
+	var req struct {
+		Status string `json:"status" binding:"required,oneof=pending confirmed shipped delivered cancelled"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if order.Status == "delivered" || order.Status == "cancelled" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": errOrderCompleted.Error()})
+		return
+	}
+
+	actorID, actorName := currentActor(c)
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		// Статус меняется только у незавершенного заказа: из двух одновременных
+		// запросов продажу или снятие резерва проведет только один
+		result := tx.Model(&models.Order{}).
+			Where("id = ? AND status NOT IN ?", order.ID, []string{"delivered", "cancelled"}).
+			Update("status", req.Status)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return errOrderCompleted
+		}
+
+		switch req.Status {
+		case "delivered":
+			if err := inventory.CompleteOrder(tx, order.ID, actorID, actorName); err != nil {
+				return err
+			}
+		case "cancelled":
+			if err := inventory.ReleaseOrder(tx, order.ID, actorID, actorName); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if errors.Is(err, errOrderCompleted) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Ошибка при обновлении статуса заказа"})
+		return
+	}
+
+	// Снятый резерв возвращает товар в наличие
+	if req.Status == "cancelled" {
+		var productIDs []uint
+		database.DB.Model(&models.OrderItem{}).Where("order_id = ?", order.ID).Pluck("product_id", &productIDs)
+		stockalerts.CheckAsync(productIDs...)
+	}
+
+	order.Status = req.Status
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Статус заказа обновлен",
+		"order":   order,
+	})
+}
+
+// orderProductIDs возвращает ID товаров заказа без повторов
+func orderProductIDs(lines []promotions.PricedLine) []uint {
+	seen := make(map[uint]bool)
+	var ids []uint
+	for _, line := range lines {
+		if !seen[line.Product.ID] {
+			seen[line.Product.ID] = true
+			ids = append(ids, line.Product.ID)
+		}
+	}
+	return ids
+}
@@ -0,0 +1,73 @@
+package handlers
+
+import (
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"texnousta-backend/internal/analytics"
+	"texnousta-backend/internal/database"
+	"texnousta-backend/internal/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// recordProductView сохраняет просмотр карточки товара. Просмотры ботов
+// не сохраняются, чтобы не влиять на популярность и рекомендации.
+func recordProductView(c *gin.Context, productID uint) {
+	if analytics.DetectBot(c.ClientIP(), c.GetHeader("User-Agent"), c.Request.Header) != "" {
+		return
+	}
+	view := models.ProductView{
+		ProductID: productID,
+		SessionID: requestSessionID(c),
+		IPAddress: analytics.StoredIP(database.DB, c.ClientIP(), time.Now()),
+	}
+	go func() {
+		if err := database.DB.Create(&view).Error; err != nil {
+			log.Printf("❌ Ошибка сохранения просмотра товара: %v", err)
+		}
+	}()
+}
+
+// GetProductPopularity возвращает отчет о популярности товаров
+// @Summary Популярность товаров
+// @Description Самые просматриваемые и самые заказываемые товары за период с конверсией просмотров в заказы
+// @Tags Analytics
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param days query int false "Количество дней (по умолчанию 30)"
+// @Param limit query int false "Количество товаров в каждом списке (по умолчанию 20)"
+// @Success 200 {object} models.ProductPopularityResponse
+// @Failure 401 {object} map[string]interface{}
+// @Router /admin/product-stats [get]
+func GetProductPopularity(c *gin.Context) {
+	if !isValidAdminToken(c) {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Не авторизован"})
+		return
+	}
+
+	days, err := strconv.Atoi(c.DefaultQuery("days", "30"))
+	if err != nil || days <= 0 {
+		days = 30
+	}
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "20"))
+	if err != nil || limit <= 0 || limit > 100 {
+		limit = 20
+	}
+
+	since := time.Now().AddDate(0, 0, -days)
+	viewed, ordered, err := analytics.ProductPopularity(database.DB, since, limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Ошибка при получении статистики товаров"})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.ProductPopularityResponse{
+		Days:        days,
+		MostViewed:  viewed,
+		MostOrdered: ordered,
+	})
+}
@@ -1,12 +1,19 @@
 package handlers
 
 import (
+	"errors"
 	"net/http"
 	"strconv"
+	"texnousta-backend/internal/analytics"
 	"texnousta-backend/internal/database"
+	"texnousta-backend/internal/inventory"
 	"texnousta-backend/internal/models"
+	"texnousta-backend/internal/promotions"
+	"texnousta-backend/internal/seo"
+	"texnousta-backend/internal/stockalerts"
 
 	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
 )
 
 // GetProducts получает список товаров с фильтрацией и пагинацией
@@ -21,7 +28,7 @@ import (
 //	@Param			category	query		int		false	"ID категории"
 //	@Param			search		query		string	false	"Поиск по названию"
 //	@Param			featured	query		bool	false	"Только рекомендуемые"
-//	@Param			sort		query		string	false	"Сортировка"			default(created_at)
+//	@Param			sort		query		string	false	"Сортировка (поле или popular - по просмотрам за 30 дней)"	default(created_at)
 //	@Param			order		query		string	false	"Порядок сортировки"		default(desc)
 //	@Success		200			{object}	map[string]interface{}
 //	@Failure		500			{object}	map[string]interface{}
@@ -57,9 +64,15 @@ func GetProducts(c *gin.Context) {
 	query.Count(&total)
 
 	// Получение товаров с пагинацией
+	if sortBy == "popular" {
+		// Самые просматриваемые за последние 30 дней
+		query = analytics.OrderByPopularity(query.Select("products.*"))
+	} else {
+		query = query.Order(sortBy + " " + order)
+	}
+
 	var products []models.Product
 	if err := query.Preload("Category").
-		Order(sortBy + " " + order).
 		Offset(offset).
 		Limit(limit).
 		Find(&products).Error; err != nil {
@@ -67,6 +80,9 @@ func GetProducts(c *gin.Context) {
 		return
 	}
 
+	// Расчет цен с учетом действующих акций
+	promotions.ApplyToProducts(products)
+
 	c.JSON(http.StatusOK, gin.H{
 		"products": products,
 		"pagination": gin.H{
@@ -85,9 +101,10 @@ func GetProducts(c *gin.Context) {
 //	@Tags			products
 //	@Accept			json
 //	@Produce		json
-//	@Param			id	path		int	true	"ID товара"
-//	@Success		200	{object}	map[string]interface{}
-//	@Failure		404	{object}	map[string]interface{}
+//	@Param			id		path		int		true	"ID товара"
+//	@Param			include	query		string	false	"jsonld - добавить разметку schema.org"
+//	@Success		200		{object}	map[string]interface{}
+//	@Failure		404		{object}	map[string]interface{}
 //	@Router			/products/{id} [get]
 func GetProduct(c *gin.Context) {
 	id := c.Param("id")
@@ -100,7 +117,20 @@ func GetProduct(c *gin.Context) {
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"product": product})
+	promotions.ApplyToProduct(&product)
+	recordProductView(c, product.ID)
+
+	// Наличие по складам и магазинам
+	if availability, err := inventory.Availability(database.DB, product.ID); err == nil {
+		product.Availability = availability
+	}
+
+	response := gin.H{"product": product}
+	if c.Query("include") == "jsonld" {
+		response["jsonld"] = seo.ProductJSONLD(&product)
+	}
+
+	c.JSON(http.StatusOK, response)
 }
 
 // CreateProduct создает новый товар (только для админов)
@@ -132,24 +162,49 @@ func CreateProduct(c *gin.Context) {
 		return
 	}
 
+	if req.Stock < 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Остаток не может быть отрицательным"})
+		return
+	}
+
 	product := models.Product{
-		Name:        req.Name,
-		Description: req.Description,
-		Price:       req.Price,
-		OldPrice:    req.OldPrice,
-		CategoryID:  req.CategoryID,
-		Brand:       req.Brand,
-		Model:       req.Model,
-		Stock:       req.Stock,
-		IsActive:    req.IsActive,
-		IsFeatured:  req.IsFeatured,
-	}
-
-	if err := database.DB.Create(&product).Error; err != nil {
+		Name:              req.Name,
+		Description:       req.Description,
+		Price:             req.Price,
+		OldPrice:          req.OldPrice,
+		CategoryID:        req.CategoryID,
+		Brand:             req.Brand,
+		Model:             req.Model,
+		SKU:               req.SKU,
+		IsActive:          req.IsActive,
+		IsFeatured:        req.IsFeatured,
+		LowStockThreshold: req.LowStockThreshold,
+		ExcludeFromFeeds:  req.ExcludeFromFeeds,
+	}
+
+	actorID, actorName := currentActor(c)
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&product).Error; err != nil {
+			return err
+		}
+
+		// Начальный остаток проводится через журнал как поступление
+		return inventory.Post(tx, &models.StockMovement{
+			ProductID: product.ID,
+			Type:      inventory.Receipt,
+			Quantity:  req.Stock,
+			Reason:    "Создание товара",
+			ActorID:   actorID,
+			ActorName: actorName,
+		})
+	})
+	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Ошибка при создании товара"})
 		return
 	}
 
+	stockalerts.CheckAsync(product.ID)
+
 	// Загрузка связанной категории
 	database.DB.Preload("Category").First(&product, product.ID)
 
@@ -184,23 +239,55 @@ func UpdateProduct(c *gin.Context) {
 
 	// Обновление полей
 	updates := map[string]interface{}{
-		"name":        req.Name,
-		"description": req.Description,
-		"price":       req.Price,
-		"old_price":   req.OldPrice,
-		"category_id": req.CategoryID,
-		"brand":       req.Brand,
-		"model":       req.Model,
-		"stock":       req.Stock,
-		"is_active":   req.IsActive,
-		"is_featured": req.IsFeatured,
-	}
-
-	if err := database.DB.Model(&product).Updates(updates).Error; err != nil {
+		"name":                req.Name,
+		"description":         req.Description,
+		"price":               req.Price,
+		"old_price":           req.OldPrice,
+		"category_id":         req.CategoryID,
+		"brand":               req.Brand,
+		"model":               req.Model,
+		"sku":                 req.SKU,
+		"is_active":           req.IsActive,
+		"is_featured":         req.IsFeatured,
+		"low_stock_threshold": req.LowStockThreshold,
+		"exclude_from_feeds":  req.ExcludeFromFeeds,
+	}
+
+	// Остаток не перезаписывается напрямую: разница проводится через журнал
+	// корректирующим движением
+	actorID, actorName := currentActor(c)
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&product).Updates(updates).Error; err != nil {
+			return err
+		}
+
+		_, err := inventory.SetLevel(tx, product.ID, req.Stock, models.StockMovement{
+			Reason:    "Изменение остатка в карточке товара",
+			ActorID:   actorID,
+			ActorName: actorName,
+		})
+		return err
+	})
+	if errors.Is(err, inventory.ErrInsufficientStock) && req.Stock < 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Остаток не может быть отрицательным"})
+		return
+	}
+	if errors.Is(err, inventory.ErrInsufficientStock) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if errors.Is(err, inventory.ErrConcurrentUpdate) {
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Ошибка при обновлении товара"})
 		return
 	}
 
+	// Оповещения о низком остатке и о поступлении для подписчиков
+	stockalerts.CheckAsync(product.ID)
+
 	// Загрузка обновленного товара с категорией
 	database.DB.Preload("Category").First(&product, product.ID)
 
@@ -0,0 +1,268 @@
+package handlers
+
+import (
+	"net/http"
+	"texnousta-backend/internal/database"
+	"texnousta-backend/internal/models"
+	"texnousta-backend/internal/promotions"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetActivePromotions получает список действующих акций
+//
+//	@Summary		Получить действующие акции
+//	@Description	Получение акций, действующих в данный момент (в том числе флеш-распродаж с таймером)
+//	@Tags			promotions
+//	@Accept			json
+//	@Produce		json
+//	@Param			flash	query		bool	false	"Только флеш-распродажи"
+//	@Success		200		{object}	map[string]interface{}
+//	@Failure		500		{object}	map[string]interface{}
+//	@Router			/promotions [get]
+func GetActivePromotions(c *gin.Context) {
+	promos, err := promotions.Active(time.Now())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Ошибка при получении акций"})
+		return
+	}
+
+	if c.Query("flash") == "true" {
+		flash := make([]models.Promotion, 0, len(promos))
+		for _, promo := range promos {
+			if promo.IsFlash {
+				flash = append(flash, promo)
+			}
+		}
+		promos = flash
+	}
+
+	c.JSON(http.StatusOK, gin.H{"promotions": promos})
+}
+
+// GetPromotions получает список всех акций (только для админов)
+//
+//	@Summary		Получить список акций
+//	@Description	Получение всех правил акций, включая неактивные и завершенные (только для администраторов)
+//	@Tags			admin
+//	@Accept			json
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Success		200	{object}	map[string]interface{}
+//	@Failure		401	{object}	map[string]interface{}
+//	@Failure		403	{object}	map[string]interface{}
+//	@Failure		500	{object}	map[string]interface{}
+//	@Router			/admin/promotions [get]
+func GetPromotions(c *gin.Context) {
+	var promos []models.Promotion
+	if err := database.DB.Order("created_at DESC").Find(&promos).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Ошибка при получении акций"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"promotions": promos})
+}
+
+// CreatePromotion создает новую акцию (только для админов)
+//
+//	@Summary		Создать акцию
+//	@Description	Создание правила акции: скидка на все товары, категорию, бренд, товар или комплект
+//	@Tags			admin
+//	@Accept			json
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Param			promotion	body		models.PromotionRequest	true	"Данные акции"
+//	@Success		201			{object}	map[string]interface{}
+//	@Failure		400			{object}	map[string]interface{}
+//	@Failure		401			{object}	map[string]interface{}
+//	@Failure		403			{object}	map[string]interface{}
+//	@Failure		500			{object}	map[string]interface{}
+//	@Router			/admin/promotions [post]
+func CreatePromotion(c *gin.Context) {
+	var req models.PromotionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if msg := validatePromotion(&req); msg != "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": msg})
+		return
+	}
+
+	promo := models.Promotion{
+		Name:              req.Name,
+		Description:       req.Description,
+		Type:              req.Type,
+		Value:             req.Value,
+		Scope:             req.Scope,
+		CategoryID:        req.CategoryID,
+		ProductID:         req.ProductID,
+		Brand:             req.Brand,
+		TriggerCategoryID: req.TriggerCategoryID,
+		IsFlash:           req.IsFlash,
+		StartsAt:          req.StartsAt,
+		EndsAt:            req.EndsAt,
+		IsActive:          req.IsActive,
+	}
+
+	if err := database.DB.Create(&promo).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Ошибка при создании акции"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message":   "Акция успешно создана",
+		"promotion": promo,
+	})
+}
+
+// UpdatePromotion обновляет акцию (только для админов)
+//
+//	@Summary		Обновить акцию
+//	@Description	Изменение правила акции (только для администраторов)
+//	@Tags			admin
+//	@Accept			json
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Param			id			path		int						true	"ID акции"
+//	@Param			promotion	body		models.PromotionRequest	true	"Данные акции"
+//	@Success		200			{object}	map[string]interface{}
+//	@Failure		400			{object}	map[string]interface{}
+//	@Failure		401			{object}	map[string]interface{}
+//	@Failure		403			{object}	map[string]interface{}
+//	@Failure		404			{object}	map[string]interface{}
+//	@Failure		500			{object}	map[string]interface{}
+//	@Router			/admin/promotions/{id} [put]
+func UpdatePromotion(c *gin.Context) {
+	id := c.Param("id")
+
+	var promo models.Promotion
+	if err := database.DB.First(&promo, id).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Акция не найдена"})
+		return
+	}
+
+	var req models.PromotionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if msg := validatePromotion(&req); msg != "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": msg})
+		return
+	}
+
+	updates := map[string]interface{}{
+		"name":                req.Name,
+		"description":         req.Description,
+		"type":                req.Type,
+		"value":               req.Value,
+		"scope":               req.Scope,
+		"category_id":         req.CategoryID,
+		"product_id":          req.ProductID,
+		"brand":               req.Brand,
+		"trigger_category_id": req.TriggerCategoryID,
+		"is_flash":            req.IsFlash,
+		"starts_at":           req.StartsAt,
+		"ends_at":             req.EndsAt,
+		"is_active":           req.IsActive,
+	}
+
+	if err := database.DB.Model(&promo).Updates(updates).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Ошибка при обновлении акции"})
+		return
+	}
+
+	database.DB.First(&promo, promo.ID)
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":   "Акция успешно обновлена",
+		"promotion": promo,
+	})
+}
+
+// DeletePromotion удаляет акцию (только для админов)
+//
+//	@Summary		Удалить акцию
+//	@Description	Удаление правила акции (только для администраторов)
+//	@Tags			admin
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Param			id	path		int	true	"ID акции"
+//	@Success		200	{object}	map[string]interface{}
+//	@Failure		401	{object}	map[string]interface{}
+//	@Failure		403	{object}	map[string]interface{}
+//	@Failure		404	{object}	map[string]interface{}
+//	@Failure		500	{object}	map[string]interface{}
+//	@Router			/admin/promotions/{id} [delete]
+func DeletePromotion(c *gin.Context) {
+	id := c.Param("id")
+
+	var promo models.Promotion
+	if err := database.DB.First(&promo, id).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Акция не найдена"})
+		return
+	}
+
+	if err := database.DB.Delete(&promo).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Ошибка при удалении акции"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Акция успешно удалена"})
+}
+
+// validatePromotion проверяет, что для выбранной области действия заданы нужные поля
+func validatePromotion(req *models.PromotionRequest) string {
+	if req.Type == promotions.TypePercent && req.Value > 100 {
+		return "Процент скидки не может превышать 100"
+	}
+	if req.StartsAt != nil && req.EndsAt != nil && !req.EndsAt.After(*req.StartsAt) {
+		return "Дата окончания акции должна быть позже даты начала"
+	}
+	if req.IsFlash && req.EndsAt == nil {
+		return "Для флеш-распродажи необходимо указать дату окончания"
+	}
+
+	switch req.Scope {
+	case promotions.ScopeCategory:
+		if req.CategoryID == nil {
+			return "Не указана категория акции"
+		}
+	case promotions.ScopeBrand:
+		if req.Brand == "" {
+			return "Не указан бренд акции"
+		}
+	case promotions.ScopeProduct:
+		if req.ProductID == nil {
+			return "Не указан товар акции"
+		}
+	case promotions.ScopeBundle:
+		if req.CategoryID == nil || req.TriggerCategoryID == nil {
+			return "Для комплекта необходимо указать категорию товара-условия и категорию товара со скидкой"
+		}
+	}
+
+	if req.CategoryID != nil {
+		var category models.Category
+		if err := database.DB.First(&category, *req.CategoryID).Error; err != nil {
+			return "Категория не найдена"
+		}
+	}
+	if req.TriggerCategoryID != nil {
+		var category models.Category
+		if err := database.DB.First(&category, *req.TriggerCategoryID).Error; err != nil {
+			return "Категория товара-условия не найдена"
+		}
+	}
+	if req.ProductID != nil {
+		var product models.Product
+		if err := database.DB.First(&product, *req.ProductID).Error; err != nil {
+			return "Товар не найден"
+		}
+	}
+	return ""
+}
@@ -0,0 +1,107 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"texnousta-backend/internal/middleware"
+	"texnousta-backend/internal/realtime"
+
+	"github.com/gin-gonic/gin"
+)
+
+// streamHeartbeat - интервал пустых сообщений, чтобы прокси не закрывали соединение
+const streamHeartbeat = 25 * time.Second
+
+// CreateStreamTicket выдает билет на подключение к потоку событий
+//
+//	@Summary		Билет на поток событий
+//	@Description	Короткоживущий билет для EventSource: действует минуту и подходит только для /admin/events/stream. Для переподключения нужен новый билет
+//	@Tags			admin
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Success		200	{object}	map[string]interface{}
+//	@Failure		401	{object}	map[string]interface{}
+//	@Failure		403	{object}	map[string]interface{}
+//	@Failure		500	{object}	map[string]interface{}
+//	@Router			/admin/events/stream-ticket [post]
+func CreateStreamTicket(c *gin.Context) {
+	userID, _ := currentActor(c)
+	if userID == nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Пользователь не аутентифицирован"})
+		return
+	}
+
+	ticket, err := middleware.NewStreamTicket(*userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Ошибка при создании билета"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"ticket":     ticket,
+		"expires_in": int(middleware.StreamTicketTTL.Seconds()),
+	})
+}
+
+// StreamAdminEvents отправляет события сайта в реальном времени (Server-Sent Events)
+//
+//	@Summary		Поток событий для админ-панели
+//	@Description	События visit, phone_click, contact, quick_contact и phone_contact в формате text/event-stream. EventSource не умеет передавать заголовки, поэтому вместо токена передается билет из /admin/events/stream-ticket параметром ticket
+//	@Tags			admin
+//	@Produce		text/event-stream
+//	@Security		BearerAuth
+//	@Param			ticket	query		string	false	"Билет на подключение (действует минуту)"
+//	@Param			types	query		string	false	"Типы событий через запятую (по умолчанию все)"
+//	@Success		200		{object}	realtime.Event
+//	@Failure		401		{object}	map[string]interface{}
+//	@Failure		403		{object}	map[string]interface{}
+//	@Router			/admin/events/stream [get]
+func StreamAdminEvents(c *gin.Context) {
+	var types map[string]bool
+	if param := c.Query("types"); param != "" {
+		types = make(map[string]bool)
+		for _, t := range strings.Split(param, ",") {
+			types[strings.TrimSpace(t)] = true
+		}
+	}
+
+	events, unsubscribe := realtime.Default.Subscribe()
+	defer unsubscribe()
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Header("X-Accel-Buffering", "no") // отключает буферизацию в nginx
+
+	heartbeat := time.NewTicker(streamHeartbeat)
+	defer heartbeat.Stop()
+
+	// Первое сообщение сразу, чтобы клиент понял, что подключение установлено
+	fmt.Fprint(c.Writer, ": connected\n\n")
+	c.Writer.Flush()
+
+	c.Stream(func(w io.Writer) bool {
+		select {
+		case <-c.Request.Context().Done():
+			return false
+		case <-heartbeat.C:
+			fmt.Fprint(w, ": ping\n\n")
+			return true
+		case event := <-events:
+			if types != nil && !types[event.Type] {
+				return true
+			}
+			data, err := json.Marshal(event)
+			if err != nil {
+				return true
+			}
+			fmt.Fprintf(w, "id: %d\nevent: %s\ndata: %s\n\n", event.ID, event.Type, data)
+			return true
+		}
+	})
+}
@@ -0,0 +1,51 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"texnousta-backend/internal/database"
+	"texnousta-backend/internal/models"
+	"texnousta-backend/internal/promotions"
+	"texnousta-backend/internal/recommend"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetRelatedProducts получает рекомендации для карточки товара
+//
+//	@Summary		Рекомендации к товару
+//	@Description	"Часто покупают вместе" по заказам и "Похожие товары" по просмотрам. Если истории мало, похожие дополняются товарами той же категории и бренда в близком ценовом диапазоне
+//	@Tags			products
+//	@Accept			json
+//	@Produce		json
+//	@Param			id		path		int	true	"ID товара"
+//	@Param			limit	query		int	false	"Количество товаров в каждом списке"	default(8)
+//	@Success		200		{object}	recommend.Result
+//	@Failure		404		{object}	map[string]interface{}
+//	@Failure		500		{object}	map[string]interface{}
+//	@Router			/products/{id}/related [get]
+func GetRelatedProducts(c *gin.Context) {
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "8"))
+	if limit < 1 || limit > 50 {
+		limit = 8
+	}
+
+	var product models.Product
+	if err := database.DB.Where("id = ? AND is_active = ?", c.Param("id"), true).
+		First(&product).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Товар не найден"})
+		return
+	}
+
+	result, err := recommend.Related(database.DB, &product, limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Ошибка при получении рекомендаций"})
+		return
+	}
+
+	promotions.ApplyToProducts(result.BoughtTogether)
+	promotions.ApplyToProducts(result.Related)
+
+	c.JSON(http.StatusOK, result)
+}
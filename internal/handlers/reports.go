@@ -0,0 +1,277 @@
+package handlers
+
+import (
+	"net/http"
+	"net/mail"
+	"time"
+
+	"texnousta-backend/internal/database"
+	"texnousta-backend/internal/models"
+	"texnousta-backend/internal/notify"
+	"texnousta-backend/internal/reports"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetReportSchedules получает расписания отчетов (только для админов)
+//
+//	@Summary		Получить расписания отчетов
+//	@Description	Расписания отправки отчетов с временем следующего и последнего запуска (только для администраторов)
+//	@Tags			admin
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Success		200	{object}	map[string]interface{}
+//	@Failure		401	{object}	map[string]interface{}
+//	@Failure		403	{object}	map[string]interface{}
+//	@Failure		500	{object}	map[string]interface{}
+//	@Router			/admin/reports/schedules [get]
+func GetReportSchedules(c *gin.Context) {
+	var schedules []models.ReportSchedule
+	if err := database.DB.Order("created_at DESC").Find(&schedules).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Ошибка при получении расписаний"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"schedules": schedules})
+}
+
+// CreateReportSchedule создает расписание отчета (только для админов)
+//
+//	@Summary		Создать расписание отчета
+//	@Description	Отчет о посещениях, звонках, обращениях, заказах и выручке за прошедший день, неделю или месяц по расписанию cron (минута час день месяц день_недели, например "0 9 * * 1" - по понедельникам в 9:00). Отправляется на email или в Telegram (chat_id)
+//	@Tags			admin
+//	@Accept			json
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Param			schedule	body		models.ReportScheduleRequest	true	"Расписание"
+//	@Success		201			{object}	map[string]interface{}
+//	@Failure		400			{object}	map[string]interface{}
+//	@Failure		401			{object}	map[string]interface{}
+//	@Failure		403			{object}	map[string]interface{}
+//	@Failure		500			{object}	map[string]interface{}
+//	@Router			/admin/reports/schedules [post]
+func CreateReportSchedule(c *gin.Context) {
+	var req models.ReportScheduleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	nextRun, msg := validateReportSchedule(&req)
+	if msg != "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": msg})
+		return
+	}
+
+	schedule := models.ReportSchedule{
+		Name:      req.Name,
+		Cron:      req.Cron,
+		Period:    req.Period,
+		Channel:   req.Channel,
+		Recipient: req.Recipient,
+		IsActive:  req.IsActive,
+		NextRunAt: nextRun,
+	}
+	if err := database.DB.Create(&schedule).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Ошибка при создании расписания"})
+		return
+	}
+	// is_active со значением false не сохраняется при создании из-за default:true
+	if !req.IsActive {
+		if err := database.DB.Model(&schedule).Update("is_active", false).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Ошибка при создании расписания"})
+			return
+		}
+		schedule.IsActive = false
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message":  "Расписание отчета создано",
+		"schedule": schedule,
+	})
+}
+
+// UpdateReportSchedule обновляет расписание отчета (только для админов)
+//
+//	@Summary		Обновить расписание отчета
+//	@Description	Изменение расписания; время следующего запуска пересчитывается, последняя ошибка сбрасывается
+//	@Tags			admin
+//	@Accept			json
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Param			id			path		int								true	"ID расписания"
+//	@Param			schedule	body		models.ReportScheduleRequest	true	"Расписание"
+//	@Success		200			{object}	map[string]interface{}
+//	@Failure		400			{object}	map[string]interface{}
+//	@Failure		401			{object}	map[string]interface{}
+//	@Failure		403			{object}	map[string]interface{}
+//	@Failure		404			{object}	map[string]interface{}
+//	@Failure		500			{object}	map[string]interface{}
+//	@Router			/admin/reports/schedules/{id} [put]
+func UpdateReportSchedule(c *gin.Context) {
+	var schedule models.ReportSchedule
+	if err := database.DB.First(&schedule, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Расписание не найдено"})
+		return
+	}
+
+	var req models.ReportScheduleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	nextRun, msg := validateReportSchedule(&req)
+	if msg != "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": msg})
+		return
+	}
+
+	updates := map[string]interface{}{
+		"name":        req.Name,
+		"cron":        req.Cron,
+		"period":      req.Period,
+		"channel":     req.Channel,
+		"recipient":   req.Recipient,
+		"is_active":   req.IsActive,
+		"next_run_at": nextRun,
+		"last_error":  "",
+	}
+	if err := database.DB.Model(&schedule).Updates(updates).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Ошибка при обновлении расписания"})
+		return
+	}
+
+	database.DB.First(&schedule, schedule.ID)
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":  "Расписание отчета обновлено",
+		"schedule": schedule,
+	})
+}
+
+// DeleteReportSchedule удаляет расписание отчета (только для админов)
+//
+//	@Summary		Удалить расписание отчета
+//	@Description	Удаление расписания отправки отчета (только для администраторов)
+//	@Tags			admin
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Param			id	path		int	true	"ID расписания"
+//	@Success		200	{object}	map[string]interface{}
+//	@Failure		401	{object}	map[string]interface{}
+//	@Failure		403	{object}	map[string]interface{}
+//	@Failure		404	{object}	map[string]interface{}
+//	@Failure		500	{object}	map[string]interface{}
+//	@Router			/admin/reports/schedules/{id} [delete]
+func DeleteReportSchedule(c *gin.Context) {
+	var schedule models.ReportSchedule
+	if err := database.DB.First(&schedule, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Расписание не найдено"})
+		return
+	}
+
+	if err := database.DB.Delete(&schedule).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Ошибка при удалении расписания"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Расписание отчета удалено"})
+}
+
+// SendReportSchedule отправляет отчет по расписанию немедленно (только для админов)
+//
+//	@Summary		Отправить отчет сейчас
+//	@Description	Формирует и отправляет отчет по расписанию, не меняя время следующего запуска
+//	@Tags			admin
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Param			id	path		int	true	"ID расписания"
+//	@Success		200	{object}	map[string]interface{}
+//	@Failure		401	{object}	map[string]interface{}
+//	@Failure		404	{object}	map[string]interface{}
+//	@Failure		502	{object}	map[string]interface{}
+//	@Router			/admin/reports/schedules/{id}/send [post]
+func SendReportSchedule(c *gin.Context) {
+	var schedule models.ReportSchedule
+	if err := database.DB.First(&schedule, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Расписание не найдено"})
+		return
+	}
+
+	if err := reports.Run(database.DB, &schedule, time.Now()); err != nil {
+		c.JSON(http.StatusBadGateway, gin.H{"error": "Ошибка отправки отчета: " + err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":  "Отчет отправлен",
+		"schedule": schedule,
+	})
+}
+
+// PreviewReport формирует отчет без отправки (только для админов)
+//
+//	@Summary		Просмотр отчета
+//	@Description	Отчет за последний завершенный день, неделю или месяц в HTML, PDF или JSON
+//	@Tags			admin
+//	@Produce		html
+//	@Produce		application/pdf
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Param			period	query		string	false	"day, week или month (по умолчанию week)"
+//	@Param			format	query		string	false	"html, pdf или json (по умолчанию html)"
+//	@Success		200		{file}		file
+//	@Failure		400		{object}	map[string]interface{}
+//	@Failure		401		{object}	map[string]interface{}
+//	@Failure		500		{object}	map[string]interface{}
+//	@Router			/admin/reports/preview [get]
+func PreviewReport(c *gin.Context) {
+	period := c.DefaultQuery("period", reports.PeriodWeek)
+	if period != reports.PeriodDay && period != reports.PeriodWeek && period != reports.PeriodMonth {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Период должен быть day, week или month"})
+		return
+	}
+
+	report, err := reports.Build(database.DB, period, time.Now())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Ошибка при формировании отчета"})
+		return
+	}
+
+	switch c.DefaultQuery("format", "html") {
+	case "json":
+		c.JSON(http.StatusOK, gin.H{"title": report.Title(), "report": report})
+	case "pdf":
+		data, err := report.PDF()
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Ошибка при формировании PDF"})
+			return
+		}
+		c.Header("Content-Disposition", `inline; filename="report-`+period+`-`+report.From.Format("2006-01-02")+`.pdf"`)
+		c.Data(http.StatusOK, "application/pdf", data)
+	case "html":
+		html, err := report.HTML()
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Ошибка при формировании отчета"})
+			return
+		}
+		c.Data(http.StatusOK, "text/html; charset=utf-8", []byte(html))
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Формат должен быть html, pdf или json"})
+	}
+}
+
+// validateReportSchedule проверяет расписание и получателя и возвращает время первого запуска
+func validateReportSchedule(req *models.ReportScheduleRequest) (*time.Time, string) {
+	nextRun, err := reports.NextRun(req.Cron, time.Now())
+	if err != nil {
+		return nil, "Некорректное расписание: " + err.Error()
+	}
+	if req.Channel == notify.ChannelEmail {
+		if _, err := mail.ParseAddressList(req.Recipient); err != nil {
+			return nil, "Некорректный email получателя"
+		}
+	}
+	return nextRun, ""
+}
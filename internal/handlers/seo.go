@@ -0,0 +1,89 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+
+	"texnousta-backend/internal/database"
+	"texnousta-backend/internal/models"
+	"texnousta-backend/internal/promotions"
+	"texnousta-backend/internal/seo"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetSitemap отдает sitemap.xml (или индекс sitemap для большого каталога)
+func GetSitemap(c *gin.Context) {
+	var buf bytes.Buffer
+	if err := seo.WriteSitemap(&buf); err != nil {
+		log.Printf("❌ Ошибка формирования sitemap: %v", err)
+		c.String(http.StatusInternalServerError, "Ошибка при формировании sitemap")
+		return
+	}
+
+	c.Header("Cache-Control", "public, max-age=3600")
+	c.Data(http.StatusOK, "application/xml; charset=utf-8", buf.Bytes())
+}
+
+// GetSitemapPart отдает часть sitemap из индекса
+func GetSitemapPart(c *gin.Context) {
+	var buf bytes.Buffer
+	err := seo.WritePart(&buf, c.Param("file"))
+	if errors.Is(err, seo.ErrNotFound) {
+		c.String(http.StatusNotFound, err.Error())
+		return
+	}
+	if err != nil {
+		log.Printf("❌ Ошибка формирования sitemap %s: %v", c.Param("file"), err)
+		c.String(http.StatusInternalServerError, "Ошибка при формировании sitemap")
+		return
+	}
+
+	c.Header("Cache-Control", "public, max-age=3600")
+	c.Data(http.StatusOK, "application/xml; charset=utf-8", buf.Bytes())
+}
+
+// GetRobots отдает robots.txt
+func GetRobots(c *gin.Context) {
+	var buf bytes.Buffer
+	if err := seo.WriteRobots(&buf); err != nil {
+		log.Printf("❌ Ошибка формирования robots.txt: %v", err)
+		c.String(http.StatusInternalServerError, "Ошибка при формировании robots.txt")
+		return
+	}
+
+	c.Header("Cache-Control", "public, max-age=3600")
+	c.Data(http.StatusOK, "text/plain; charset=utf-8", buf.Bytes())
+}
+
+// GetProductJSONLD отдает разметку schema.org для карточки товара
+//
+//	@Summary		Разметка schema.org товара
+//	@Description	JSON-LD с Product (цена, старая цена, наличие, бренд) и BreadcrumbList для встраивания в страницу. aggregateRating не выводится, пока в магазине нет отзывов
+//	@Tags			products
+//	@Produce		json
+//	@Param			id	path		int	true	"ID товара"
+//	@Success		200	{object}	seo.JSONLD
+//	@Failure		404	{object}	map[string]interface{}
+//	@Router			/products/{id}/jsonld [get]
+func GetProductJSONLD(c *gin.Context) {
+	var product models.Product
+	if err := database.DB.Preload("Category").
+		Where("id = ? AND is_active = ?", c.Param("id"), true).
+		First(&product).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Товар не найден"})
+		return
+	}
+
+	promotions.ApplyToProduct(&product)
+
+	data, err := json.Marshal(seo.ProductJSONLD(&product))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Ошибка при формировании разметки"})
+		return
+	}
+	c.Data(http.StatusOK, "application/ld+json; charset=utf-8", data)
+}
@@ -0,0 +1,228 @@
+package handlers
+
+import (
+	"net/http"
+	"texnousta-backend/internal/database"
+	"texnousta-backend/internal/inventory"
+	"texnousta-backend/internal/models"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// GetPickupPoints получает список магазинов, где можно забрать заказ
+//
+//	@Summary		Точки самовывоза
+//	@Description	Получение списка активных магазинов и складов, из которых возможен самовывоз
+//	@Tags			warehouses
+//	@Accept			json
+//	@Produce		json
+//	@Success		200	{object}	map[string]interface{}
+//	@Failure		500	{object}	map[string]interface{}
+//	@Router			/pickup-points [get]
+func GetPickupPoints(c *gin.Context) {
+	var points []models.Warehouse
+	if err := database.DB.Where("is_active = ? AND is_pickup_point = ?", true, true).
+		Order("name ASC").
+		Find(&points).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Ошибка при получении точек самовывоза"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"pickup_points": points})
+}
+
+// GetProductAvailability получает наличие товара по складам и магазинам
+//
+//	@Summary		Наличие товара по магазинам
+//	@Description	Количество товара на каждом складе и в каждом магазине, например "3 шт. — Магазин Чиланзар"
+//	@Tags			products
+//	@Accept			json
+//	@Produce		json
+//	@Param			id	path		int	true	"ID товара"
+//	@Success		200	{object}	map[string]interface{}
+//	@Failure		404	{object}	map[string]interface{}
+//	@Failure		500	{object}	map[string]interface{}
+//	@Router			/products/{id}/availability [get]
+func GetProductAvailability(c *gin.Context) {
+	id := c.Param("id")
+
+	var product models.Product
+	if err := database.DB.Where("id = ? AND is_active = ?", id, true).
+		First(&product).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Товар не найден"})
+		return
+	}
+
+	availability, err := inventory.Availability(database.DB, product.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Ошибка при получении наличия товара"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"product_id":   product.ID,
+		"stock":        product.Stock,
+		"availability": availability,
+	})
+}
+
+// GetWarehouses получает список складов и магазинов (только для админов)
+func GetWarehouses(c *gin.Context) {
+	var warehouses []models.Warehouse
+	if err := database.DB.Order("id ASC").Find(&warehouses).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Ошибка при получении складов"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"warehouses": warehouses})
+}
+
+// CreateWarehouse создает склад или магазин (только для админов)
+//
+//	@Summary		Создать склад
+//	@Description	Создание склада или магазина (точки самовывоза)
+//	@Tags			admin
+//	@Accept			json
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Param			warehouse	body		models.WarehouseRequest	true	"Данные склада"
+//	@Success		201			{object}	map[string]interface{}
+//	@Failure		400			{object}	map[string]interface{}
+//	@Failure		500			{object}	map[string]interface{}
+//	@Router			/admin/warehouses [post]
+func CreateWarehouse(c *gin.Context) {
+	var req models.WarehouseRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	warehouse := models.Warehouse{
+		Name:          req.Name,
+		Type:          req.Type,
+		City:          req.City,
+		Address:       req.Address,
+		Phone:         req.Phone,
+		WorkingHours:  req.WorkingHours,
+		IsPickupPoint: req.IsPickupPoint,
+		IsDefault:     req.IsDefault,
+		IsActive:      req.IsActive,
+	}
+
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&warehouse).Error; err != nil {
+			return err
+		}
+		return resetDefaultWarehouse(tx, warehouse.ID, req.IsDefault)
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Ошибка при создании склада"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message":   "Склад успешно создан",
+		"warehouse": warehouse,
+	})
+}
+
+// UpdateWarehouse обновляет склад или магазин (только для админов)
+func UpdateWarehouse(c *gin.Context) {
+	id := c.Param("id")
+
+	var warehouse models.Warehouse
+	if err := database.DB.First(&warehouse, id).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Склад не найден"})
+		return
+	}
+
+	var req models.WarehouseRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// Без основного склада некуда проводить движения и не с чего доставлять
+	if warehouse.IsDefault && (!req.IsDefault || !req.IsActive) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Сначала назначьте другой основной склад"})
+		return
+	}
+
+	updates := map[string]interface{}{
+		"name":            req.Name,
+		"type":            req.Type,
+		"city":            req.City,
+		"address":         req.Address,
+		"phone":           req.Phone,
+		"working_hours":   req.WorkingHours,
+		"is_pickup_point": req.IsPickupPoint,
+		"is_default":      req.IsDefault,
+		"is_active":       req.IsActive,
+	}
+
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&warehouse).Updates(updates).Error; err != nil {
+			return err
+		}
+		return resetDefaultWarehouse(tx, warehouse.ID, req.IsDefault)
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Ошибка при обновлении склада"})
+		return
+	}
+
+	database.DB.First(&warehouse, warehouse.ID)
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":   "Склад успешно обновлен",
+		"warehouse": warehouse,
+	})
+}
+
+// DeleteWarehouse удаляет склад или магазин (только для админов)
+func DeleteWarehouse(c *gin.Context) {
+	id := c.Param("id")
+
+	var warehouse models.Warehouse
+	if err := database.DB.First(&warehouse, id).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Склад не найден"})
+		return
+	}
+
+	if warehouse.IsDefault {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Невозможно удалить основной склад"})
+		return
+	}
+
+	// Проверяем, есть ли на складе товары
+	var stockCount int64
+	database.DB.Model(&models.ProductStock{}).
+		Where("warehouse_id = ? AND quantity <> 0", warehouse.ID).
+		Count(&stockCount)
+
+	if stockCount > 0 {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Невозможно удалить склад, на нем есть товары",
+		})
+		return
+	}
+
+	if err := database.DB.Delete(&warehouse).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Ошибка при удалении склада"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Склад успешно удален"})
+}
+
+// resetDefaultWarehouse снимает признак основного склада с остальных складов,
+// если склад id назначен основным
+func resetDefaultWarehouse(tx *gorm.DB, id uint, isDefault bool) error {
+	if !isDefault {
+		return nil
+	}
+	return tx.Model(&models.Warehouse{}).
+		Where("id <> ? AND is_default = ?", id, true).
+		Update("is_default", false).Error
+}
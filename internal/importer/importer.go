@@ -0,0 +1,628 @@
+// Package importer загружает товары из таблиц CSV/XLSX.
+//
+// Колонки сопоставляются с полями models.ProductRequest по заголовку
+// (на русском или английском), категория ищется по названию, а товар
+// обновляется, если найден по артикулу (SKU) или модели, иначе создается.
+// Для обновления достаточно колонки с артикулом или моделью и изменяемых
+// колонок (например, sku и price); название, цена и категория обязательны
+// только для новых товаров.
+// Пустая ячейка у найденного товара означает "без изменений".
+// В режиме проверки (dry run) изменения не записываются, а возвращается
+// отчет с ошибками по строкам.
+package importer
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"texnousta-backend/internal/database"
+	"texnousta-backend/internal/inventory"
+	"texnousta-backend/internal/models"
+
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/validator/v10"
+	"gorm.io/gorm"
+)
+
+// Колонки файла импорта
+const (
+	colName              = "name"
+	colDescription       = "description"
+	colPrice             = "price"
+	colOldPrice          = "old_price"
+	colCategory          = "category"
+	colCategoryID        = "category_id"
+	colBrand             = "brand"
+	colModel             = "model"
+	colSKU               = "sku"
+	colStock             = "stock"
+	colIsActive          = "is_active"
+	colIsFeatured        = "is_featured"
+	colLowStockThreshold = "low_stock_threshold"
+)
+
+// headerAliases сопоставляет заголовки колонок с полями товара
+var headerAliases = map[string]string{
+	"name": colName, "название": colName, "наименование": colName, "товар": colName,
+	"description": colDescription, "описание": colDescription,
+	"price": colPrice, "цена": colPrice,
+	"old_price": colOldPrice, "old price": colOldPrice, "старая цена": colOldPrice,
+	"category": colCategory, "категория": colCategory,
+	"category_id": colCategoryID, "id категории": colCategoryID,
+	"brand": colBrand, "бренд": colBrand, "производитель": colBrand,
+	"model": colModel, "модель": colModel,
+	"sku": colSKU, "артикул": colSKU,
+	"stock": colStock, "остаток": colStock, "количество": colStock,
+	"is_active": colIsActive, "active": colIsActive, "активен": colIsActive,
+	"is_featured": colIsFeatured, "featured": colIsFeatured, "рекомендуемый": colIsFeatured,
+	"low_stock_threshold": colLowStockThreshold, "порог остатка": colLowStockThreshold,
+}
+
+// dbColumns - колонки таблицы products для обновления существующего товара
+var dbColumns = map[string]string{
+	colName:              "name",
+	colDescription:       "description",
+	colPrice:             "price",
+	colOldPrice:          "old_price",
+	colCategory:          "category_id",
+	colCategoryID:        "category_id",
+	colBrand:             "brand",
+	colModel:             "model",
+	colSKU:               "sku",
+	colIsActive:          "is_active",
+	colIsFeatured:        "is_featured",
+	colLowStockThreshold: "low_stock_threshold",
+}
+
+// fieldColumns - имена полей ProductRequest в сообщениях валидатора
+var fieldColumns = map[string]string{
+	"Name":              colName,
+	"Description":       colDescription,
+	"Price":             colPrice,
+	"OldPrice":          colOldPrice,
+	"CategoryID":        colCategory,
+	"Brand":             colBrand,
+	"Model":             colModel,
+	"SKU":               colSKU,
+	"Stock":             colStock,
+	"LowStockThreshold": colLowStockThreshold,
+}
+
+// Действия над товаром
+const (
+	ActionCreate = "create"
+	ActionUpdate = "update"
+)
+
+// Options - параметры импорта
+type Options struct {
+	DryRun    bool
+	ActorID   *uint
+	ActorName string
+}
+
+// RowError - ошибка в строке файла
+type RowError struct {
+	Row     int    `json:"row"` // номер строки в файле (заголовок - строка 1)
+	Field   string `json:"field,omitempty"`
+	Value   string `json:"value,omitempty"`
+	Message string `json:"message"`
+}
+
+// RowResult - что будет (или было) сделано со строкой
+type RowResult struct {
+	Row       int    `json:"row"`
+	Action    string `json:"action"` // create, update
+	ProductID uint   `json:"product_id,omitempty"`
+	SKU       string `json:"sku,omitempty"`
+	Model     string `json:"model,omitempty"`
+	Name      string `json:"name"`
+}
+
+// Report - результат импорта
+type Report struct {
+	DryRun    bool        `json:"dry_run"`
+	Committed bool        `json:"committed"`
+	TotalRows int         `json:"total_rows"`
+	Created   int         `json:"created"`
+	Updated   int         `json:"updated"`
+	Rows      []RowResult `json:"rows"`
+	Errors    []RowError  `json:"errors"`
+
+	// ProductIDs - товары, остаток которых мог измениться
+	ProductIDs []uint `json:"-"`
+}
+
+// row - разобранная строка файла
+type row struct {
+	line     int
+	req      models.ProductRequest
+	present  map[string]bool // заполненные ячейки: пустая ячейка не меняет поле товара
+	existing *models.Product
+}
+
+// Import читает файл и создает или обновляет товары.
+// Если в файле есть ошибки, ничего не записывается, а ошибки возвращаются в отчете.
+func Import(filename string, r io.Reader, opts Options) (*Report, error) {
+	table, err := readTable(filename, r)
+	if err != nil {
+		return nil, err
+	}
+	if len(table) < 2 {
+		return nil, fileErrorf("Файл не содержит данных")
+	}
+
+	columns, err := mapHeader(table[0])
+	if err != nil {
+		return nil, err
+	}
+
+	categories, err := loadCategories()
+	if err != nil {
+		return nil, err
+	}
+
+	report := &Report{DryRun: opts.DryRun, Rows: []RowResult{}, Errors: []RowError{}}
+	var rows []*row
+	seenKeys := make(map[string]int)
+	seenProducts := make(map[uint]int)
+
+	for i, cells := range table[1:] {
+		line := i + 2
+		if isEmptyRow(cells) {
+			continue
+		}
+		report.TotalRows++
+
+		parsed, errs := parseRow(line, cells, columns, categories)
+		if len(errs) == 0 {
+			// Один и тот же товар не должен встречаться в файле дважды
+			if key := matchKey(parsed); key != "" {
+				if first, ok := seenKeys[key]; ok {
+					errs = append(errs, RowError{Row: line, Message: fmt.Sprintf("Товар уже встречается в строке %d", first)})
+				} else {
+					seenKeys[key] = line
+				}
+			}
+		}
+		if len(errs) == 0 {
+			existing, err := findExisting(parsed)
+			if err != nil {
+				return nil, err
+			}
+			parsed.existing = existing
+			errs = validate(parsed)
+		}
+		if len(errs) == 0 && parsed.existing != nil {
+			// Разные артикул и модель могут указывать на один товар
+			if first, ok := seenProducts[parsed.existing.ID]; ok {
+				errs = append(errs, RowError{Row: line, Message: fmt.Sprintf("Товар уже встречается в строке %d", first)})
+			} else {
+				seenProducts[parsed.existing.ID] = line
+			}
+		}
+
+		report.Errors = append(report.Errors, errs...)
+		if len(errs) == 0 {
+			rows = append(rows, parsed)
+		}
+	}
+
+	for _, parsed := range rows {
+		result := RowResult{Row: parsed.line, Action: ActionCreate, SKU: parsed.req.SKU, Model: parsed.req.Model, Name: parsed.req.Name}
+		if parsed.existing != nil {
+			result.Action = ActionUpdate
+			result.ProductID = parsed.existing.ID
+			if !parsed.present[colName] {
+				result.Name = parsed.existing.Name
+			}
+			report.Updated++
+		} else {
+			report.Created++
+		}
+		report.Rows = append(report.Rows, result)
+	}
+
+	if opts.DryRun || len(report.Errors) > 0 {
+		return report, nil
+	}
+
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		for i, parsed := range rows {
+			id, err := apply(tx, parsed, opts)
+			if err != nil {
+				return fmt.Errorf("строка %d: %w", parsed.line, err)
+			}
+			report.Rows[i].ProductID = id
+			report.ProductIDs = append(report.ProductIDs, id)
+		}
+		return nil
+	})
+	if err != nil {
+		var fileErr *FileError
+		if errors.As(err, &fileErr) || errors.Is(err, inventory.ErrInsufficientStock) {
+			return nil, &FileError{Message: err.Error()}
+		}
+		return nil, err
+	}
+
+	report.Committed = true
+	return report, nil
+}
+
+// mapHeader сопоставляет колонки файла с полями товара
+func mapHeader(header []string) (map[string]int, error) {
+	columns := make(map[string]int)
+	for i, title := range header {
+		key := strings.ToLower(strings.TrimSpace(title))
+		if field, ok := headerAliases[key]; ok {
+			if _, dup := columns[field]; dup {
+				return nil, fileErrorf("Колонка %q встречается дважды", title)
+			}
+			columns[field] = i
+		}
+	}
+
+	// Без артикула и модели товары можно только создавать
+	_, hasSKU := columns[colSKU]
+	_, hasModel := columns[colModel]
+	if !hasSKU && !hasModel {
+		if missing := missingCreateColumns(columns); len(missing) > 0 {
+			return nil, fileErrorf("В файле нет колонки sku или model для поиска товаров, а для создания не хватает колонок: %s", strings.Join(missing, ", "))
+		}
+	}
+
+	keyColumns := 0
+	if hasSKU || hasModel {
+		keyColumns = 1
+	}
+	if len(columns) <= keyColumns {
+		return nil, fileErrorf("В файле нет колонок с данными товаров")
+	}
+	return columns, nil
+}
+
+// missingCreateColumns - колонки, без которых нельзя создать товар
+func missingCreateColumns(columns map[string]int) []string {
+	var missing []string
+	for _, required := range []string{colName, colPrice} {
+		if _, ok := columns[required]; !ok {
+			missing = append(missing, required)
+		}
+	}
+	_, hasCategory := columns[colCategory]
+	_, hasCategoryID := columns[colCategoryID]
+	if !hasCategory && !hasCategoryID {
+		missing = append(missing, colCategory)
+	}
+	return missing
+}
+
+// loadCategories возвращает ID категорий по названию (без учета регистра)
+func loadCategories() (map[string]uint, error) {
+	var categories []models.Category
+	if err := database.DB.Select("id, name").Find(&categories).Error; err != nil {
+		return nil, err
+	}
+	byName := make(map[string]uint, len(categories)*2)
+	for _, category := range categories {
+		byName[strings.ToLower(strings.TrimSpace(category.Name))] = category.ID
+		byName["#"+strconv.FormatUint(uint64(category.ID), 10)] = category.ID
+	}
+	return byName, nil
+}
+
+func parseRow(line int, cells []string, columns map[string]int, categories map[string]uint) (*row, []RowError) {
+	parsed := &row{line: line, present: make(map[string]bool)}
+	var errs []RowError
+
+	cell := func(field string) (string, bool) {
+		i, ok := columns[field]
+		if !ok {
+			return "", false
+		}
+		if i >= len(cells) {
+			return "", true
+		}
+		value := strings.TrimSpace(cells[i])
+		if value != "" {
+			parsed.present[field] = true
+		}
+		return value, true
+	}
+	fail := func(field, value, message string) {
+		errs = append(errs, RowError{Row: line, Field: field, Value: value, Message: message})
+	}
+
+	req := &parsed.req
+	req.Name, _ = cell(colName)
+	req.Description, _ = cell(colDescription)
+	req.Brand, _ = cell(colBrand)
+	req.Model, _ = cell(colModel)
+	req.SKU, _ = cell(colSKU)
+
+	if v, ok := cell(colPrice); ok && v != "" {
+		price, err := parseNumber(v)
+		if err != nil {
+			fail(colPrice, v, "Цена должна быть числом")
+		}
+		req.Price = price
+	}
+	if v, ok := cell(colOldPrice); ok && v != "" {
+		oldPrice, err := parseNumber(v)
+		if err != nil {
+			fail(colOldPrice, v, "Старая цена должна быть числом")
+		}
+		req.OldPrice = oldPrice
+	}
+	if v, ok := cell(colStock); ok && v != "" {
+		stock, err := parseInt(v)
+		if err != nil || stock < 0 {
+			fail(colStock, v, "Остаток должен быть целым неотрицательным числом")
+		}
+		req.Stock = stock
+	}
+	if v, ok := cell(colLowStockThreshold); ok && v != "" {
+		threshold, err := parseInt(v)
+		if err != nil {
+			fail(colLowStockThreshold, v, "Порог остатка должен быть целым числом")
+		}
+		req.LowStockThreshold = threshold
+	}
+
+	// Новые товары по умолчанию активны
+	req.IsActive = true
+	if v, ok := cell(colIsActive); ok {
+		active, err := parseBool(v, true)
+		if err != nil {
+			fail(colIsActive, v, "Ожидается да/нет")
+		}
+		req.IsActive = active
+	}
+	if v, ok := cell(colIsFeatured); ok {
+		featured, err := parseBool(v, false)
+		if err != nil {
+			fail(colIsFeatured, v, "Ожидается да/нет")
+		}
+		req.IsFeatured = featured
+	}
+
+	// Категория: по названию или по ID
+	if v, ok := cell(colCategory); ok && v != "" {
+		id, found := categories[strings.ToLower(v)]
+		if !found {
+			fail(colCategory, v, "Категория не найдена")
+		}
+		req.CategoryID = id
+	} else if v, ok := cell(colCategoryID); ok && v != "" {
+		id, found := categories["#"+v]
+		if !found {
+			fail(colCategoryID, v, "Категория не найдена")
+		}
+		req.CategoryID = id
+	}
+
+	return parsed, errs
+}
+
+// validate проверяет строку по тем же правилам, что и CreateProduct. У
+// найденного товара проверяются только заполненные ячейки.
+func validate(parsed *row) []RowError {
+	var err error
+	if parsed.existing == nil {
+		var missing []RowError
+		for _, column := range missingCreateColumns(presentColumns(parsed)) {
+			missing = append(missing, RowError{Row: parsed.line, Field: column, Message: "Товар не найден, а для создания нужно заполнить это поле"})
+		}
+		if len(missing) > 0 {
+			return missing
+		}
+		err = binding.Validator.ValidateStruct(&parsed.req)
+	} else {
+		var fields []string
+		for field, column := range fieldColumns {
+			if parsed.present[column] || (column == colCategory && parsed.present[colCategoryID]) {
+				fields = append(fields, field)
+			}
+		}
+		engine, ok := binding.Validator.Engine().(*validator.Validate)
+		if !ok {
+			return []RowError{{Row: parsed.line, Message: "Валидатор недоступен"}}
+		}
+		err = engine.StructPartial(&parsed.req, fields...)
+	}
+	if err == nil {
+		return nil
+	}
+
+	var fieldErrs validator.ValidationErrors
+	if !errors.As(err, &fieldErrs) {
+		return []RowError{{Row: parsed.line, Message: err.Error()}}
+	}
+
+	errs := make([]RowError, 0, len(fieldErrs))
+	for _, fe := range fieldErrs {
+		column := fieldColumns[fe.Field()]
+		errs = append(errs, RowError{
+			Row:     parsed.line,
+			Field:   column,
+			Value:   fmt.Sprint(fe.Value()),
+			Message: validationMessage(fe),
+		})
+	}
+	return errs
+}
+
+// presentColumns - заполненные колонки строки в виде карты для missingCreateColumns
+func presentColumns(parsed *row) map[string]int {
+	columns := make(map[string]int, len(parsed.present))
+	for column := range parsed.present {
+		columns[column] = 0
+	}
+	return columns
+}
+
+func validationMessage(fe validator.FieldError) string {
+	switch fe.Tag() {
+	case "required":
+		return "Обязательное поле"
+	case "gt":
+		return "Значение должно быть больше " + fe.Param()
+	case "gte":
+		return "Значение должно быть не меньше " + fe.Param()
+	}
+	return fmt.Sprintf("Некорректное значение (%s)", fe.Tag())
+}
+
+// matchKey - ключ, по которому строка сопоставляется с существующим товаром
+func matchKey(parsed *row) string {
+	if parsed.req.SKU != "" {
+		return "sku:" + strings.ToLower(parsed.req.SKU)
+	}
+	if parsed.req.Model != "" {
+		return "model:" + strings.ToLower(parsed.req.Model)
+	}
+	return ""
+}
+
+// findExisting ищет товар по артикулу, а если не нашел - по модели.
+// У товаров, созданных до появления артикулов, sku пустой, поэтому модель
+// проверяется и тогда, когда артикул в строке есть.
+func findExisting(parsed *row) (*models.Product, error) {
+	if parsed.req.SKU != "" {
+		product, err := findBy("sku", parsed.req.SKU)
+		if product != nil || err != nil {
+			return product, err
+		}
+	}
+	if parsed.req.Model != "" {
+		return findBy("model", parsed.req.Model)
+	}
+	return nil, nil
+}
+
+// findBy ищет товар по значению колонки без учета регистра
+func findBy(column, value string) (*models.Product, error) {
+	var product models.Product
+	err := database.DB.Where("LOWER("+column+") = ?", strings.ToLower(value)).
+		Order("id ASC").First(&product).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &product, nil
+}
+
+// apply создает или обновляет товар и приводит остаток к значению из файла
+func apply(tx *gorm.DB, parsed *row, opts Options) (uint, error) {
+	req := parsed.req
+	var productID uint
+
+	if parsed.existing == nil {
+		product := models.Product{
+			Name:              req.Name,
+			Description:       req.Description,
+			Price:             req.Price,
+			OldPrice:          req.OldPrice,
+			CategoryID:        req.CategoryID,
+			Brand:             req.Brand,
+			Model:             req.Model,
+			SKU:               req.SKU,
+			IsActive:          req.IsActive,
+			IsFeatured:        req.IsFeatured,
+			LowStockThreshold: req.LowStockThreshold,
+		}
+		// is_active по умолчанию true в схеме - false нужно записать явно
+		if err := tx.Create(&product).Error; err != nil {
+			return 0, err
+		}
+		if !req.IsActive {
+			if err := tx.Model(&product).Update("is_active", false).Error; err != nil {
+				return 0, err
+			}
+		}
+		productID = product.ID
+	} else {
+		// Обновляются только заполненные ячейки
+		updates := make(map[string]interface{})
+		values := map[string]interface{}{
+			colName:              req.Name,
+			colDescription:       req.Description,
+			colPrice:             req.Price,
+			colOldPrice:          req.OldPrice,
+			colCategory:          req.CategoryID,
+			colCategoryID:        req.CategoryID,
+			colBrand:             req.Brand,
+			colModel:             req.Model,
+			colSKU:               req.SKU,
+			colIsActive:          req.IsActive,
+			colIsFeatured:        req.IsFeatured,
+			colLowStockThreshold: req.LowStockThreshold,
+		}
+		for field, value := range values {
+			if parsed.present[field] {
+				updates[dbColumns[field]] = value
+			}
+		}
+		if err := tx.Model(parsed.existing).Updates(updates).Error; err != nil {
+			return 0, err
+		}
+		productID = parsed.existing.ID
+	}
+
+	if parsed.present[colStock] {
+		_, err := inventory.SetLevel(tx, productID, req.Stock, models.StockMovement{
+			Reason:    "Импорт товаров",
+			ActorID:   opts.ActorID,
+			ActorName: opts.ActorName,
+		})
+		if err != nil {
+			return 0, err
+		}
+	}
+	return productID, nil
+}
+
+func isEmptyRow(cells []string) bool {
+	for _, cell := range cells {
+		if strings.TrimSpace(cell) != "" {
+			return false
+		}
+	}
+	return true
+}
+
+// parseNumber разбирает число в формате "1 200,50" или "1200.50"
+func parseNumber(v string) (float64, error) {
+	v = strings.NewReplacer(" ", "", " ", "", ",", ".").Replace(v)
+	return strconv.ParseFloat(v, 64)
+}
+
+func parseInt(v string) (int, error) {
+	f, err := parseNumber(v)
+	if err != nil {
+		return 0, err
+	}
+	if f != float64(int(f)) {
+		return 0, fmt.Errorf("не целое число: %s", v)
+	}
+	return int(f), nil
+}
+
+// parseBool разбирает да/нет; пустая ячейка дает значение по умолчанию
+func parseBool(v string, def bool) (bool, error) {
+	switch strings.ToLower(strings.TrimSpace(v)) {
+	case "":
+		return def, nil
+	case "1", "true", "yes", "y", "да", "+", "x":
+		return true, nil
+	case "0", "false", "no", "n", "нет", "-":
+		return false, nil
+	}
+	return false, fmt.Errorf("не логическое значение: %s", v)
+}
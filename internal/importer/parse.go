@@ -0,0 +1,91 @@
+package importer
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+
+	"github.com/xuri/excelize/v2"
+)
+
+// FileError - ошибка в содержимом файла, которую нужно показать пользователю
+type FileError struct {
+	Message string
+}
+
+func (e *FileError) Error() string {
+	return e.Message
+}
+
+func fileErrorf(format string, args ...interface{}) *FileError {
+	return &FileError{Message: fmt.Sprintf(format, args...)}
+}
+
+// ErrUnsupportedFormat - файл не CSV и не XLSX
+var ErrUnsupportedFormat = &FileError{Message: "Поддерживаются только файлы CSV и XLSX"}
+
+// readTable читает файл в таблицу строк. Первая строка - заголовок.
+func readTable(filename string, r io.Reader) ([][]string, error) {
+	switch strings.ToLower(filepath.Ext(filename)) {
+	case ".csv":
+		return readCSV(r)
+	case ".xlsx":
+		return readXLSX(r)
+	}
+	return nil, ErrUnsupportedFormat
+}
+
+func readCSV(r io.Reader) ([][]string, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	// Excel сохраняет CSV в UTF-8 с BOM
+	data = bytes.TrimPrefix(data, []byte("\xef\xbb\xbf"))
+
+	reader := csv.NewReader(bytes.NewReader(data))
+	reader.Comma = detectDelimiter(data)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	rows, err := reader.ReadAll()
+	if err != nil {
+		return nil, fileErrorf("Ошибка чтения CSV: %v", err)
+	}
+	return rows, nil
+}
+
+// detectDelimiter определяет разделитель по строке заголовка:
+// русская локаль Excel сохраняет CSV через точку с запятой
+func detectDelimiter(data []byte) rune {
+	header := data
+	if i := bytes.IndexByte(data, '\n'); i >= 0 {
+		header = data[:i]
+	}
+	if bytes.Count(header, []byte(";")) > bytes.Count(header, []byte(",")) {
+		return ';'
+	}
+	return ','
+}
+
+func readXLSX(r io.Reader) ([][]string, error) {
+	book, err := excelize.OpenReader(r)
+	if err != nil {
+		return nil, fileErrorf("Ошибка чтения XLSX: %v", err)
+	}
+	defer book.Close()
+
+	sheets := book.GetSheetList()
+	if len(sheets) == 0 {
+		return nil, fileErrorf("В файле нет листов")
+	}
+
+	rows, err := book.GetRows(sheets[0])
+	if err != nil {
+		return nil, fileErrorf("Ошибка чтения XLSX: %v", err)
+	}
+	return rows, nil
+}
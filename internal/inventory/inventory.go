@@ -0,0 +1,257 @@
+// Package inventory ведет журнал движения товаров на складе.
+//
+// Любое изменение остатка проходит через Post или SetLevel: движение
+// записывается в stock_movements, а остаток на складе (ProductStock) и
+// общий остаток (Product.Stock) обновляются в той же транзакции, поэтому
+// остатки всегда согласованы с журналом.
+package inventory
+
+import (
+	"errors"
+	"fmt"
+
+	"texnousta-backend/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// Типы движений
+const (
+	Receipt     = "receipt"     // поступление
+	Sale        = "sale"        // продажа
+	Return      = "return"      // возврат от покупателя
+	Adjustment  = "adjustment"  // корректировка (инвентаризация)
+	Reservation = "reservation" // резерв под заказ
+	Release     = "release"     // снятие резерва
+)
+
+// ErrInsufficientStock - остаток не может стать отрицательным
+var ErrInsufficientStock = errors.New("Недостаточно товара на складе")
+
+// ErrConcurrentUpdate - остаток изменился во время корректировки
+var ErrConcurrentUpdate = errors.New("Остаток товара изменился, повторите попытку")
+
+// Signed возвращает количество со знаком для типа движения.
+// Для корректировки знак задает сам пользователь.
+func Signed(movementType string, quantity int) int {
+	switch movementType {
+	case Receipt, Return, Release:
+		return abs(quantity)
+	case Sale, Reservation:
+		return -abs(quantity)
+	}
+	return quantity
+}
+
+// Post проводит движение: записывает его в журнал и меняет остаток товара
+// на складе движения и общий остаток. Если склад не указан, используется
+// основной склад. Quantity в movement должно быть уже со знаком (см. Signed).
+// Должен вызываться внутри транзакции.
+func Post(tx *gorm.DB, movement *models.StockMovement) error {
+	if movement.Quantity == 0 {
+		return nil
+	}
+
+	if movement.WarehouseID == nil {
+		warehouse, err := DefaultWarehouse(tx)
+		if err != nil {
+			return err
+		}
+		movement.WarehouseID = &warehouse.ID
+	}
+
+	location := models.ProductStock{ProductID: movement.ProductID, WarehouseID: *movement.WarehouseID}
+	if err := tx.Where(location).FirstOrCreate(&location).Error; err != nil {
+		return err
+	}
+
+	// Атомарное изменение: остаток на складе не может уйти в минус
+	result := tx.Model(&models.ProductStock{}).
+		Where("id = ? AND quantity + ? >= 0", location.ID, movement.Quantity).
+		Update("quantity", gorm.Expr("quantity + ?", movement.Quantity))
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		var product models.Product
+		if err := tx.Select("id, name").First(&product, movement.ProductID).Error; err != nil {
+			return err
+		}
+		return fmt.Errorf("%w: %s", ErrInsufficientStock, product.Name)
+	}
+
+	if err := tx.Model(&models.Product{}).
+		Where("id = ?", movement.ProductID).
+		Update("stock", gorm.Expr("stock + ?", movement.Quantity)).Error; err != nil {
+		return err
+	}
+
+	if err := tx.Model(&models.ProductStock{}).
+		Where("id = ?", location.ID).
+		Pluck("quantity", &movement.LocationBalanceAfter).Error; err != nil {
+		return err
+	}
+	if err := tx.Model(&models.Product{}).
+		Where("id = ?", movement.ProductID).
+		Pluck("stock", &movement.BalanceAfter).Error; err != nil {
+		return err
+	}
+
+	return tx.Create(movement).Error
+}
+
+// SetLevel приводит общий остаток товара к level корректирующими движениями.
+// Используется, когда администратор задает остаток целиком (UpdateProduct, импорт).
+// Пополнение проводится на склад movement.WarehouseID (по умолчанию - основной
+// склад). Списание без указанного склада распределяется по складам с остатком:
+// сначала основной склад, затем склады с наибольшим остатком.
+// Возвращает проведенные движения или nil, если остаток не изменился.
+func SetLevel(tx *gorm.DB, productID uint, level int, movement models.StockMovement) ([]models.StockMovement, error) {
+	if level < 0 {
+		return nil, ErrInsufficientStock
+	}
+
+	var current int
+	if err := tx.Model(&models.Product{}).
+		Where("id = ?", productID).
+		Pluck("stock", &current).Error; err != nil {
+		return nil, err
+	}
+	if current == level {
+		return nil, nil
+	}
+
+	movement.ProductID = productID
+	movement.Type = Adjustment
+
+	var movements []models.StockMovement
+	if delta := level - current; delta > 0 || movement.WarehouseID != nil {
+		movement.Quantity = delta
+		if err := Post(tx, &movement); err != nil {
+			return nil, err
+		}
+		movements = append(movements, movement)
+	} else {
+		var err error
+		if movements, err = writeOff(tx, movement, -delta); err != nil {
+			return nil, err
+		}
+	}
+
+	// Остаток изменился между чтением и проведением движений
+	if movements[len(movements)-1].BalanceAfter != level {
+		return nil, ErrConcurrentUpdate
+	}
+	return movements, nil
+}
+
+// writeOff списывает quantity товара по складам с остатком: сначала
+// основной склад, затем склады с наибольшим остатком
+func writeOff(tx *gorm.DB, movement models.StockMovement, quantity int) ([]models.StockMovement, error) {
+	var locations []models.ProductStock
+	if err := tx.Model(&models.ProductStock{}).
+		Joins("JOIN warehouses ON warehouses.id = product_stocks.warehouse_id").
+		Where("product_stocks.product_id = ? AND product_stocks.quantity > 0", movement.ProductID).
+		Order("warehouses.is_default DESC, product_stocks.quantity DESC, product_stocks.id ASC").
+		Find(&locations).Error; err != nil {
+		return nil, err
+	}
+
+	var movements []models.StockMovement
+	for _, location := range locations {
+		if quantity == 0 {
+			break
+		}
+		take := min(location.Quantity, quantity)
+		warehouseID := location.WarehouseID
+
+		part := movement
+		part.WarehouseID = &warehouseID
+		part.Quantity = -take
+		if err := Post(tx, &part); err != nil {
+			return nil, err
+		}
+		movements = append(movements, part)
+		quantity -= take
+	}
+
+	if quantity > 0 {
+		var product models.Product
+		if err := tx.Select("id, name").First(&product, movement.ProductID).Error; err != nil {
+			return nil, err
+		}
+		return nil, fmt.Errorf("%w: %s", ErrInsufficientStock, product.Name)
+	}
+	return movements, nil
+}
+
+// EnsureOpeningBalances переносит остатки, заданные до появления журнала
+// и складов, на основной склад: создает движения "начальный остаток"
+// и строки остатков по складам.
+func EnsureOpeningBalances(db *gorm.DB) error {
+	warehouse, err := ensureDefaultWarehouse(db)
+	if err != nil {
+		return err
+	}
+
+	// Движения, проведенные до появления складов, относятся к основному складу
+	if err := db.Model(&models.StockMovement{}).
+		Where("warehouse_id IS NULL").
+		Update("warehouse_id", warehouse.ID).Error; err != nil {
+		return err
+	}
+
+	var products []models.Product
+	err = db.Select("id, stock").
+		Where("stock <> 0").
+		Where("NOT EXISTS (SELECT 1 FROM stock_movements WHERE stock_movements.product_id = products.id)").
+		Find(&products).Error
+	if err != nil {
+		return err
+	}
+
+	for _, product := range products {
+		movement := models.StockMovement{
+			ProductID:            product.ID,
+			WarehouseID:          &warehouse.ID,
+			Type:                 Adjustment,
+			Quantity:             product.Stock,
+			BalanceAfter:         product.Stock,
+			LocationBalanceAfter: product.Stock,
+			Reason:               "Начальный остаток",
+			ActorName:            "system",
+		}
+		if err := db.Create(&movement).Error; err != nil {
+			return err
+		}
+	}
+
+	// Остатки по складам для товаров, у которых их еще нет
+	products = nil
+	err = db.Select("id, stock").
+		Where("stock <> 0").
+		Where("NOT EXISTS (SELECT 1 FROM product_stocks WHERE product_stocks.product_id = products.id)").
+		Find(&products).Error
+	if err != nil {
+		return err
+	}
+
+	for _, product := range products {
+		location := models.ProductStock{
+			ProductID:   product.ID,
+			WarehouseID: warehouse.ID,
+			Quantity:    product.Stock,
+		}
+		if err := db.Create(&location).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func abs(v int) int {
+	if v < 0 {
+		return -v
+	}
+	return v
+}
@@ -0,0 +1,156 @@
+package inventory
+
+import (
+	"errors"
+	"fmt"
+
+	"texnousta-backend/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// Типы складов
+const (
+	WarehouseTypeWarehouse = "warehouse"
+	WarehouseTypeStore     = "store"
+)
+
+// ErrNoDefaultWarehouse - основной склад не настроен
+var ErrNoDefaultWarehouse = errors.New("Основной склад не настроен")
+
+// DefaultWarehouse возвращает основной склад: с него идет доставка
+// и на него проводятся движения без указания склада
+func DefaultWarehouse(db *gorm.DB) (*models.Warehouse, error) {
+	var warehouse models.Warehouse
+	err := db.Where("is_default = ? AND is_active = ?", true, true).
+		Order("id ASC").
+		First(&warehouse).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrNoDefaultWarehouse
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &warehouse, nil
+}
+
+// ensureDefaultWarehouse создает основной склад, если его еще нет
+func ensureDefaultWarehouse(db *gorm.DB) (*models.Warehouse, error) {
+	warehouse, err := DefaultWarehouse(db)
+	if !errors.Is(err, ErrNoDefaultWarehouse) {
+		return warehouse, err
+	}
+
+	created := models.Warehouse{
+		Name:      "Основной склад",
+		Type:      WarehouseTypeWarehouse,
+		City:      "Ташкент",
+		IsDefault: true,
+		IsActive:  true,
+	}
+	if err := db.Create(&created).Error; err != nil {
+		return nil, err
+	}
+	return &created, nil
+}
+
+// Availability возвращает наличие товара по активным складам и магазинам
+func Availability(db *gorm.DB, productID uint) ([]models.LocationStock, error) {
+	var stocks []models.ProductStock
+	err := db.Joins("Warehouse").
+		Where("product_stocks.product_id = ? AND product_stocks.quantity > 0", productID).
+		Where(`"Warehouse"."is_active" = ?`, true).
+		Order("product_stocks.quantity DESC").
+		Find(&stocks).Error
+	if err != nil {
+		return nil, err
+	}
+
+	availability := make([]models.LocationStock, 0, len(stocks))
+	for _, stock := range stocks {
+		availability = append(availability, models.LocationStock{
+			WarehouseID:   stock.WarehouseID,
+			Name:          stock.Warehouse.Name,
+			Type:          stock.Warehouse.Type,
+			City:          stock.Warehouse.City,
+			Address:       stock.Warehouse.Address,
+			IsPickupPoint: stock.Warehouse.IsPickupPoint,
+			Quantity:      stock.Quantity,
+			Label:         fmt.Sprintf("%d шт. — %s", stock.Quantity, stock.Warehouse.Name),
+		})
+	}
+	return availability, nil
+}
+
+// ReleaseOrder снимает резервы заказа (например, при отмене)
+func ReleaseOrder(tx *gorm.DB, orderID uint, actorID *uint, actorName string) error {
+	return settleOrder(tx, orderID, actorID, actorName, false)
+}
+
+// CompleteOrder переводит резервы заказа в продажу: резерв снимается
+// и проводится продажа с того же склада
+func CompleteOrder(tx *gorm.DB, orderID uint, actorID *uint, actorName string) error {
+	return settleOrder(tx, orderID, actorID, actorName, true)
+}
+
+func settleOrder(tx *gorm.DB, orderID uint, actorID *uint, actorName string, sell bool) error {
+	reserved, err := openReservations(tx, orderID)
+	if err != nil {
+		return err
+	}
+
+	for _, r := range reserved {
+		warehouseID := r.WarehouseID
+		release := models.StockMovement{
+			ProductID:   r.ProductID,
+			WarehouseID: &warehouseID,
+			Type:        Release,
+			Quantity:    r.Quantity,
+			Reason:      fmt.Sprintf("Снятие резерва по заказу #%d", orderID),
+			ActorID:     actorID,
+			ActorName:   actorName,
+			OrderID:     &orderID,
+		}
+		if err := Post(tx, &release); err != nil {
+			return err
+		}
+
+		if !sell {
+			continue
+		}
+		sale := models.StockMovement{
+			ProductID:   r.ProductID,
+			WarehouseID: &warehouseID,
+			Type:        Sale,
+			Quantity:    -r.Quantity,
+			Reason:      fmt.Sprintf("Заказ #%d", orderID),
+			ActorID:     actorID,
+			ActorName:   actorName,
+			OrderID:     &orderID,
+		}
+		if err := Post(tx, &sale); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// reservation - неснятый резерв заказа по товару и складу
+type reservation struct {
+	ProductID   uint
+	WarehouseID uint
+	Quantity    int
+}
+
+// openReservations считает, сколько единиц заказа еще зарезервировано:
+// сумма резервов минус уже снятые
+func openReservations(tx *gorm.DB, orderID uint) ([]reservation, error) {
+	var rows []reservation
+	err := tx.Model(&models.StockMovement{}).
+		Select("product_id, warehouse_id, -SUM(quantity) AS quantity").
+		Where("order_id = ? AND type IN ?", orderID, []string{Reservation, Release}).
+		Group("product_id, warehouse_id").
+		Having("SUM(quantity) < 0").
+		Scan(&rows).Error
+	return rows, err
+}
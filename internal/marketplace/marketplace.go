@@ -0,0 +1,130 @@
+// Package marketplace синхронизирует каталог с маркетплейсами.
+//
+// Каждый маркетплейс подключается через Adapter: выгрузка карточек товаров,
+// обновление цен и остатков и получение заказов. Товары выгружаются только
+// из категорий, сопоставленных с категориями маркетплейса
+// (models.MarketplaceCategory).
+package marketplace
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"texnousta-backend/internal/models"
+)
+
+// ErrUnknownMarketplace - адаптер с таким именем не зарегистрирован
+var ErrUnknownMarketplace = errors.New("Неизвестный маркетплейс")
+
+// ErrNotConfigured - у адаптера не заданы параметры подключения
+var ErrNotConfigured = errors.New("Маркетплейс не настроен")
+
+// Listing - товар для выгрузки на маркетплейс
+type Listing struct {
+	Product            models.Product
+	SKU                string // артикул, по которому маркетплейс связывает карточку с нашим товаром
+	ExternalCategoryID string
+	Price              float64 // цена продажи с учетом акций
+	OldPrice           float64 // цена до скидки, если есть
+	ImageURL           string
+}
+
+// StockUpdate - цена и остаток товара
+type StockUpdate struct {
+	SKU      string
+	Price    float64
+	OldPrice float64
+	Stock    int
+}
+
+// Order - заказ с маркетплейса
+type Order struct {
+	ExternalID string
+	Status     string
+	Total      float64
+	PlacedAt   time.Time
+	Items      []OrderItem
+}
+
+// OrderItem - позиция заказа с маркетплейса
+type OrderItem struct {
+	SKU      string
+	Name     string
+	Quantity int
+	Price    float64
+}
+
+// PushResult - итог выгрузки карточек
+type PushResult struct {
+	Sent     int    `json:"sent"`
+	Accepted int    `json:"accepted"`
+	TaskID   string `json:"task_id,omitempty"` // идентификатор задачи загрузки на стороне маркетплейса
+}
+
+// Adapter - подключение к маркетплейсу
+type Adapter interface {
+	// Name - код маркетплейса (используется в URL и в таблице сопоставления категорий)
+	Name() string
+	// PushProducts создает или обновляет карточки товаров
+	PushProducts(ctx context.Context, listings []Listing) (*PushResult, error)
+	// PushStock обновляет цены и остатки
+	PushStock(ctx context.Context, updates []StockUpdate) error
+	// PullOrders получает заказы, созданные после since
+	PullOrders(ctx context.Context, since time.Time) ([]Order, error)
+}
+
+// BulkFiler - адаптер, который умеет формировать файл массовой загрузки
+// для ручной загрузки в личном кабинете маркетплейса
+type BulkFiler interface {
+	WriteBulkFile(w io.Writer, listings []Listing) error
+}
+
+var (
+	mu        sync.RWMutex
+	factories = make(map[string]func() Adapter)
+)
+
+// Register регистрирует адаптер. Фабрика вызывается при каждом обращении,
+// чтобы адаптер читал актуальные настройки окружения.
+func Register(name string, factory func() Adapter) {
+	mu.Lock()
+	defer mu.Unlock()
+	factories[name] = factory
+}
+
+// Get возвращает адаптер по коду маркетплейса
+func Get(name string) (Adapter, error) {
+	mu.RLock()
+	factory, ok := factories[name]
+	mu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownMarketplace, name)
+	}
+	return factory(), nil
+}
+
+// Names - коды зарегистрированных маркетплейсов
+func Names() []string {
+	mu.RLock()
+	defer mu.RUnlock()
+	names := make([]string, 0, len(factories))
+	for name := range factories {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// ListingSKU - артикул товара на маркетплейсе: наш SKU или служебный по ID
+func ListingSKU(product *models.Product) string {
+	if product.SKU != "" {
+		return product.SKU
+	}
+	return "TU-" + strconv.FormatUint(uint64(product.ID), 10)
+}
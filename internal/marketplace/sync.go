@@ -0,0 +1,151 @@
+package marketplace
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+
+	"texnousta-backend/internal/config"
+	"texnousta-backend/internal/models"
+	"texnousta-backend/internal/promotions"
+
+	"gorm.io/gorm"
+)
+
+// Listings собирает активные товары из сопоставленных категорий
+func Listings(db *gorm.DB, marketplace string) ([]Listing, error) {
+	var mappings []models.MarketplaceCategory
+	if err := db.Where("marketplace = ?", marketplace).Find(&mappings).Error; err != nil {
+		return nil, err
+	}
+	if len(mappings) == 0 {
+		return nil, nil
+	}
+
+	externalCategory := make(map[uint]string, len(mappings))
+	categoryIDs := make([]uint, 0, len(mappings))
+	for _, m := range mappings {
+		externalCategory[m.CategoryID] = m.ExternalCategoryID
+		categoryIDs = append(categoryIDs, m.CategoryID)
+	}
+
+	var products []models.Product
+	if err := db.Preload("Category").
+		Where("is_active = ? AND category_id IN ?", true, categoryIDs).
+		Order("id ASC").
+		Find(&products).Error; err != nil {
+		return nil, err
+	}
+	promotions.ApplyToProducts(products)
+
+	listings := make([]Listing, 0, len(products))
+	for _, product := range products {
+		price, oldPrice := product.DisplayPrices()
+		listings = append(listings, Listing{
+			Product:            product,
+			SKU:                ListingSKU(&product),
+			ExternalCategoryID: externalCategory[product.CategoryID],
+			Price:              price,
+			OldPrice:           oldPrice,
+			ImageURL:           config.AbsoluteURL(product.Image),
+		})
+	}
+	return listings, nil
+}
+
+// StockUpdates - цены и остатки товаров из сопоставленных категорий
+func StockUpdates(db *gorm.DB, marketplace string) ([]StockUpdate, error) {
+	listings, err := Listings(db, marketplace)
+	if err != nil {
+		return nil, err
+	}
+
+	updates := make([]StockUpdate, 0, len(listings))
+	for _, l := range listings {
+		updates = append(updates, StockUpdate{
+			SKU:      l.SKU,
+			Price:    l.Price,
+			OldPrice: l.OldPrice,
+			Stock:    l.Product.Stock,
+		})
+	}
+	return updates, nil
+}
+
+// SaveOrders сохраняет полученные заказы. Уже сохраненные заказы
+// обновляются (статус и сумма), позиции не дублируются.
+func SaveOrders(db *gorm.DB, marketplace string, orders []Order) (created int, err error) {
+	err = db.Transaction(func(tx *gorm.DB) error {
+		for _, o := range orders {
+			var order models.MarketplaceOrder
+			err := tx.Where("marketplace = ? AND external_id = ?", marketplace, o.ExternalID).
+				First(&order).Error
+			if err == nil {
+				if err := tx.Model(&order).Updates(map[string]interface{}{
+					"status": o.Status,
+					"total":  o.Total,
+				}).Error; err != nil {
+					return err
+				}
+				continue
+			}
+			if !errors.Is(err, gorm.ErrRecordNotFound) {
+				return err
+			}
+
+			order = models.MarketplaceOrder{
+				Marketplace: marketplace,
+				ExternalID:  o.ExternalID,
+				Status:      o.Status,
+				Total:       o.Total,
+				PlacedAt:    o.PlacedAt,
+			}
+			for _, item := range o.Items {
+				productID, err := productBySKU(tx, item.SKU)
+				if err != nil {
+					return err
+				}
+				order.Items = append(order.Items, models.MarketplaceOrderItem{
+					ProductID: productID,
+					SKU:       item.SKU,
+					Name:      item.Name,
+					Quantity:  item.Quantity,
+					Price:     item.Price,
+				})
+			}
+			if err := tx.Create(&order).Error; err != nil {
+				return err
+			}
+			created++
+		}
+		return nil
+	})
+	return created, err
+}
+
+// productBySKU находит наш товар по артикулу маркетплейса (см. ListingSKU)
+func productBySKU(tx *gorm.DB, sku string) (*uint, error) {
+	if sku == "" {
+		return nil, nil
+	}
+
+	var product models.Product
+	err := tx.Select("id").Where("sku = ?", sku).First(&product).Error
+	if err == nil {
+		return &product.ID, nil
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+
+	if id, convErr := strconv.ParseUint(strings.TrimPrefix(sku, "TU-"), 10, 64); strings.HasPrefix(sku, "TU-") && convErr == nil {
+		err = tx.Select("id").First(&product, uint(id)).Error
+		if err == nil {
+			return &product.ID, nil
+		}
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, err
+		}
+	}
+	return nil, nil
+}
@@ -0,0 +1,306 @@
+package marketplace
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/xuri/excelize/v2"
+)
+
+// Uzum - код маркетплейса Uzum Market
+const Uzum = "uzum"
+
+// uzumDefaultURL - адрес Seller OpenAPI Uzum Market
+const uzumDefaultURL = "https://api-seller.uzum.uz/api/seller-openapi"
+
+// uzumPageSize - размер страницы при получении заказов
+const uzumPageSize = 50
+
+func init() {
+	Register(Uzum, func() Adapter { return NewUzumFromEnv() })
+}
+
+// UzumAdapter работает с Uzum Market через Seller OpenAPI.
+// Карточки загружаются файлом массовой загрузки (XLSX), цены и остатки -
+// запросами по артикулу (skuTitle).
+type UzumAdapter struct {
+	BaseURL    string
+	Token      string
+	ShopID     string
+	HTTPClient *http.Client
+}
+
+// NewUzumFromEnv создает адаптер по переменным UZUM_API_URL, UZUM_API_TOKEN и UZUM_SHOP_ID
+func NewUzumFromEnv() *UzumAdapter {
+	baseURL := os.Getenv("UZUM_API_URL")
+	if baseURL == "" {
+		baseURL = uzumDefaultURL
+	}
+	return &UzumAdapter{
+		BaseURL:    baseURL,
+		Token:      os.Getenv("UZUM_API_TOKEN"),
+		ShopID:     os.Getenv("UZUM_SHOP_ID"),
+		HTTPClient: &http.Client{Timeout: 60 * time.Second},
+	}
+}
+
+// Name возвращает код маркетплейса
+func (u *UzumAdapter) Name() string {
+	return Uzum
+}
+
+// PushProducts загружает файл массовой загрузки карточек
+func (u *UzumAdapter) PushProducts(ctx context.Context, listings []Listing) (*PushResult, error) {
+	if len(listings) == 0 {
+		return &PushResult{}, nil
+	}
+
+	var file bytes.Buffer
+	if err := WriteUzumBulkFile(&file, listings); err != nil {
+		return nil, err
+	}
+
+	var body bytes.Buffer
+	form := multipart.NewWriter(&body)
+	part, err := form.CreateFormFile("file", "products.xlsx")
+	if err != nil {
+		return nil, err
+	}
+	if _, err := part.Write(file.Bytes()); err != nil {
+		return nil, err
+	}
+	if err := form.Close(); err != nil {
+		return nil, err
+	}
+
+	var resp struct {
+		Payload struct {
+			TaskID   string `json:"taskId"`
+			Accepted int    `json:"accepted"`
+		} `json:"payload"`
+	}
+	path := "/v1/product/" + url.PathEscape(u.ShopID) + "/import"
+	if err := u.do(ctx, http.MethodPost, path, form.FormDataContentType(), &body, &resp); err != nil {
+		return nil, err
+	}
+
+	return &PushResult{Sent: len(listings), Accepted: resp.Payload.Accepted, TaskID: resp.Payload.TaskID}, nil
+}
+
+// PushStock обновляет цены и остатки по артикулам
+func (u *UzumAdapter) PushStock(ctx context.Context, updates []StockUpdate) error {
+	if len(updates) == 0 {
+		return nil
+	}
+
+	type skuPrice struct {
+		SkuTitle  string  `json:"skuTitle"`
+		FullPrice float64 `json:"fullPrice"`
+		SellPrice float64 `json:"sellPrice"`
+	}
+	type skuAmount struct {
+		SkuTitle string `json:"skuTitle"`
+		Amount   int    `json:"amount"`
+	}
+
+	prices := make([]skuPrice, 0, len(updates))
+	amounts := make([]skuAmount, 0, len(updates))
+	for _, update := range updates {
+		fullPrice := update.OldPrice
+		if fullPrice == 0 {
+			fullPrice = update.Price
+		}
+		prices = append(prices, skuPrice{SkuTitle: update.SKU, FullPrice: fullPrice, SellPrice: update.Price})
+		amounts = append(amounts, skuAmount{SkuTitle: update.SKU, Amount: update.Stock})
+	}
+
+	if err := u.postJSON(ctx, "/v1/product/"+url.PathEscape(u.ShopID)+"/sendPriceData",
+		map[string]interface{}{"skuList": prices}); err != nil {
+		return err
+	}
+	return u.postJSON(ctx, "/v2/fbs/sku/stocks", map[string]interface{}{"skuAmountList": amounts})
+}
+
+// PullOrders получает заказы FBS, созданные после since
+func (u *UzumAdapter) PullOrders(ctx context.Context, since time.Time) ([]Order, error) {
+	type uzumItem struct {
+		SkuTitle     string  `json:"skuTitle"`
+		ProductTitle string  `json:"productTitle"`
+		Amount       int     `json:"amount"`
+		SellerPrice  float64 `json:"sellerPrice"`
+	}
+	type uzumOrder struct {
+		ID          int64      `json:"id"`
+		Status      string     `json:"status"`
+		Price       float64    `json:"price"`
+		DateCreated int64      `json:"dateCreated"` // миллисекунды
+		OrderItems  []uzumItem `json:"orderItems"`
+	}
+
+	var orders []Order
+	for page := 0; ; page++ {
+		query := url.Values{}
+		query.Set("shopIds", u.ShopID)
+		query.Set("dateFrom", strconv.FormatInt(since.UnixMilli(), 10))
+		query.Set("page", strconv.Itoa(page))
+		query.Set("size", strconv.Itoa(uzumPageSize))
+
+		var resp struct {
+			Payload struct {
+				Orders []uzumOrder `json:"orders"`
+			} `json:"payload"`
+		}
+		if err := u.do(ctx, http.MethodGet, "/v2/fbs/orders?"+query.Encode(), "", nil, &resp); err != nil {
+			return nil, err
+		}
+
+		for _, o := range resp.Payload.Orders {
+			order := Order{
+				ExternalID: strconv.FormatInt(o.ID, 10),
+				Status:     o.Status,
+				Total:      o.Price,
+				PlacedAt:   time.UnixMilli(o.DateCreated),
+			}
+			for _, item := range o.OrderItems {
+				order.Items = append(order.Items, OrderItem{
+					SKU:      item.SkuTitle,
+					Name:     item.ProductTitle,
+					Quantity: item.Amount,
+					Price:    item.SellerPrice,
+				})
+			}
+			orders = append(orders, order)
+		}
+
+		if len(resp.Payload.Orders) < uzumPageSize {
+			return orders, nil
+		}
+	}
+}
+
+// WriteBulkFile формирует файл массовой загрузки карточек
+func (u *UzumAdapter) WriteBulkFile(w io.Writer, listings []Listing) error {
+	return WriteUzumBulkFile(w, listings)
+}
+
+func (u *UzumAdapter) postJSON(ctx context.Context, path string, payload interface{}) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	return u.do(ctx, http.MethodPost, path, "application/json", bytes.NewReader(body), nil)
+}
+
+// do выполняет запрос к API и разбирает JSON-ответ в out (если out не nil)
+func (u *UzumAdapter) do(ctx context.Context, method, path, contentType string, body io.Reader, out interface{}) error {
+	if u.Token == "" || u.ShopID == "" {
+		return ErrNotConfigured
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, strings.TrimRight(u.BaseURL, "/")+path, body)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", u.Token)
+	req.Header.Set("Accept", "application/json")
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+
+	client := u.HTTPClient
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("uzum: %w", err)
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, 10<<20))
+	if err != nil {
+		return fmt.Errorf("uzum: %w", err)
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("uzum: %s %s: статус %d: %s", method, path, resp.StatusCode, strings.TrimSpace(string(data)))
+	}
+	if out == nil || len(data) == 0 {
+		return nil
+	}
+	if err := json.Unmarshal(data, out); err != nil {
+		return fmt.Errorf("uzum: некорректный ответ: %w", err)
+	}
+	return nil
+}
+
+// uzumColumns - колонки файла массовой загрузки карточек Uzum Market
+var uzumColumns = []string{
+	"Артикул",
+	"ID категории Uzum",
+	"Название товара (RU)",
+	"Описание (RU)",
+	"Бренд",
+	"Модель",
+	"Цена продажи",
+	"Цена до скидки",
+	"Остаток",
+	"Фото (ссылка)",
+}
+
+// WriteUzumBulkFile формирует XLSX-файл массовой загрузки карточек
+func WriteUzumBulkFile(w io.Writer, listings []Listing) error {
+	book := excelize.NewFile()
+	defer book.Close()
+
+	const sheet = "Товары"
+	if err := book.SetSheetName(book.GetSheetName(0), sheet); err != nil {
+		return err
+	}
+
+	header := make([]interface{}, len(uzumColumns))
+	for i, title := range uzumColumns {
+		header[i] = title
+	}
+	if err := book.SetSheetRow(sheet, "A1", &header); err != nil {
+		return err
+	}
+
+	for i, l := range listings {
+		var oldPrice interface{}
+		if l.OldPrice > 0 {
+			oldPrice = l.OldPrice
+		}
+		row := []interface{}{
+			l.SKU,
+			l.ExternalCategoryID,
+			l.Product.Name,
+			l.Product.Description,
+			l.Product.Brand,
+			l.Product.Model,
+			l.Price,
+			oldPrice,
+			l.Product.Stock,
+			l.ImageURL,
+		}
+		cell, err := excelize.CoordinatesToCellName(1, i+2)
+		if err != nil {
+			return err
+		}
+		if err := book.SetSheetRow(sheet, cell, &row); err != nil {
+			return err
+		}
+	}
+
+	_, err := book.WriteTo(w)
+	return err
+}
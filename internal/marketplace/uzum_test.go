@@ -0,0 +1,204 @@
+package marketplace
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"texnousta-backend/internal/models"
+
+	"github.com/xuri/excelize/v2"
+)
+
+// newTestUzum - адаптер, направленный на тестовый сервер
+func newTestUzum(t *testing.T, handler http.HandlerFunc) *UzumAdapter {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if got := r.Header.Get("Authorization"); got != "secret" {
+			t.Errorf("Authorization = %q, ожидался токен адаптера", got)
+		}
+		handler(w, r)
+	}))
+	t.Cleanup(server.Close)
+
+	return &UzumAdapter{
+		BaseURL:    server.URL + "/",
+		Token:      "secret",
+		ShopID:     "42",
+		HTTPClient: server.Client(),
+	}
+}
+
+func TestUzumPushProducts(t *testing.T) {
+	adapter := newTestUzum(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.URL.Path != "/v1/product/42/import" {
+			t.Errorf("запрос %s %s, ожидался POST /v1/product/42/import", r.Method, r.URL.Path)
+		}
+
+		file, _, err := r.FormFile("file")
+		if err != nil {
+			t.Errorf("в запросе нет файла: %v", err)
+			return
+		}
+		defer file.Close()
+		book, err := excelize.OpenReader(file)
+		if err != nil {
+			t.Errorf("файл не XLSX: %v", err)
+			return
+		}
+		rows, err := book.GetRows("Товары")
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		if len(rows) != 2 || rows[1][0] != "TX-1" || rows[1][2] != "Холодильник" {
+			t.Errorf("строки файла = %v", rows)
+		}
+
+		fmt.Fprint(w, `{"payload":{"taskId":"task-7","accepted":1}}`)
+	})
+
+	result, err := adapter.PushProducts(context.Background(), []Listing{{
+		Product:            models.Product{Name: "Холодильник", Stock: 3},
+		SKU:                "TX-1",
+		ExternalCategoryID: "100",
+		Price:              900,
+		OldPrice:           1000,
+	}})
+	if err != nil {
+		t.Fatalf("PushProducts: %v", err)
+	}
+	if result.Sent != 1 || result.Accepted != 1 || result.TaskID != "task-7" {
+		t.Errorf("результат = %+v", result)
+	}
+}
+
+func TestUzumPushStock(t *testing.T) {
+	var prices, stocks int
+	adapter := newTestUzum(t, func(w http.ResponseWriter, r *http.Request) {
+		var body map[string][]map[string]interface{}
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			t.Errorf("тело запроса не JSON: %v", err)
+			return
+		}
+
+		switch r.URL.Path {
+		case "/v1/product/42/sendPriceData":
+			prices++
+			sku := body["skuList"]
+			if len(sku) != 2 {
+				t.Errorf("skuList = %v", sku)
+				return
+			}
+			// Без скидки полная цена равна цене продажи
+			if sku[0]["fullPrice"] != 1000.0 || sku[0]["sellPrice"] != 900.0 || sku[1]["fullPrice"] != 500.0 {
+				t.Errorf("цены = %v", sku)
+			}
+		case "/v2/fbs/sku/stocks":
+			stocks++
+			amounts := body["skuAmountList"]
+			if len(amounts) != 2 || amounts[0]["skuTitle"] != "TX-1" || amounts[0]["amount"] != 3.0 {
+				t.Errorf("остатки = %v", amounts)
+			}
+		default:
+			t.Errorf("неожиданный запрос %s %s", r.Method, r.URL.Path)
+		}
+		fmt.Fprint(w, `{}`)
+	})
+
+	err := adapter.PushStock(context.Background(), []StockUpdate{
+		{SKU: "TX-1", Price: 900, OldPrice: 1000, Stock: 3},
+		{SKU: "TX-2", Price: 500, Stock: 0},
+	})
+	if err != nil {
+		t.Fatalf("PushStock: %v", err)
+	}
+	if prices != 1 || stocks != 1 {
+		t.Errorf("запросов цен %d, остатков %d, ожидалось по одному", prices, stocks)
+	}
+}
+
+func TestUzumPullOrders(t *testing.T) {
+	since := time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)
+	placed := since.Add(time.Hour)
+
+	var pages []string
+	adapter := newTestUzum(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v2/fbs/orders" {
+			t.Errorf("неожиданный запрос %s", r.URL.Path)
+		}
+		query := r.URL.Query()
+		if query.Get("shopIds") != "42" || query.Get("dateFrom") != strconv.FormatInt(since.UnixMilli(), 10) {
+			t.Errorf("параметры = %v", query)
+		}
+		page := query.Get("page")
+		pages = append(pages, page)
+
+		// Первая страница полная, вторая - с одним заказом
+		count := uzumPageSize
+		if page != "0" {
+			count = 1
+		}
+		orders := make([]map[string]interface{}, count)
+		for i := range orders {
+			orders[i] = map[string]interface{}{
+				"id":          i + 1,
+				"status":      "CREATED",
+				"price":       900,
+				"dateCreated": placed.UnixMilli(),
+				"orderItems": []map[string]interface{}{
+					{"skuTitle": "TX-1", "productTitle": "Холодильник", "amount": 1, "sellerPrice": 900},
+				},
+			}
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{"payload": map[string]interface{}{"orders": orders}})
+	})
+
+	orders, err := adapter.PullOrders(context.Background(), since)
+	if err != nil {
+		t.Fatalf("PullOrders: %v", err)
+	}
+	if strings.Join(pages, ",") != "0,1" {
+		t.Errorf("запрошены страницы %v, ожидались 0 и 1", pages)
+	}
+	if len(orders) != uzumPageSize+1 {
+		t.Fatalf("получено %d заказов, ожидалось %d", len(orders), uzumPageSize+1)
+	}
+
+	order := orders[0]
+	if order.ExternalID != "1" || order.Status != "CREATED" || order.Total != 900 || !order.PlacedAt.Equal(placed) {
+		t.Errorf("заказ = %+v", order)
+	}
+	if len(order.Items) != 1 || order.Items[0].SKU != "TX-1" || order.Items[0].Quantity != 1 {
+		t.Errorf("позиции = %+v", order.Items)
+	}
+}
+
+func TestUzumErrorResponse(t *testing.T) {
+	adapter := newTestUzum(t, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+		fmt.Fprint(w, `{"errors":[{"message":"Неверный артикул"}]}`)
+	})
+
+	err := adapter.PushStock(context.Background(), []StockUpdate{{SKU: "TX-1", Price: 900}})
+	if err == nil {
+		t.Fatal("ожидалась ошибка при статусе 400")
+	}
+	if !strings.Contains(err.Error(), "статус 400") || !strings.Contains(err.Error(), "Неверный артикул") {
+		t.Errorf("ошибка = %v", err)
+	}
+}
+
+func TestUzumNotConfigured(t *testing.T) {
+	adapter := &UzumAdapter{BaseURL: "http://127.0.0.1:0"}
+	if _, err := adapter.PullOrders(context.Background(), time.Now()); !errors.Is(err, ErrNotConfigured) {
+		t.Errorf("ошибка = %v, ожидалась ErrNotConfigured", err)
+	}
+}
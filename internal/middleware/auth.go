@@ -4,6 +4,7 @@ import (
 	"net/http"
 	"os"
 	"strings"
+	"time"
 	"texnousta-backend/internal/database"
 	"texnousta-backend/internal/models"
 
@@ -40,8 +41,9 @@ func AuthMiddleware() gin.HandlerFunc {
 			return
 		}
 
-		// Извлечение данных пользователя из токена
-		if claims, ok := token.Claims.(jwt.MapClaims); ok {
+		// Извлечение данных пользователя из токена. Токены с назначением
+		// (scope: сессия обмена с 1С, билет на поток событий) для API не подходят.
+		if claims, ok := token.Claims.(jwt.MapClaims); ok && claims["scope"] == nil {
 			userID := uint(claims["user_id"].(float64))
 			
 			// Проверка существования пользователя в базе данных
@@ -91,4 +93,65 @@ func AdminMiddleware() gin.HandlerFunc {
 
 		c.Next()
 	}
-}
\ No newline at end of file
+}
+
+// StreamTicketScope - назначение билета на поток событий админ-панели
+const StreamTicketScope = "events-stream"
+
+// StreamTicketTTL - срок действия билета на поток событий
+const StreamTicketTTL = time.Minute
+
+// NewStreamTicket выдает короткоживущий билет на подключение к потоку
+// событий. EventSource в браузере не умеет отправлять заголовки, поэтому
+// билет передается параметром ticket: в отличие от JWT администратора его
+// попадание в журналы запросов не дает доступа к остальному API.
+func NewStreamTicket(userID uint) (string, error) {
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+		"user_id": userID,
+		"scope":   StreamTicketScope,
+		"exp":     time.Now().Add(StreamTicketTTL).Unix(),
+	})
+	return token.SignedString([]byte(os.Getenv("JWT_SECRET")))
+}
+
+// StreamTicketMiddleware проверяет билет из параметра ticket (см. NewStreamTicket).
+// Без билета запрос проверяется как обычно по заголовку Authorization.
+// Ставится перед AdminMiddleware.
+func StreamTicketMiddleware() gin.HandlerFunc {
+	auth := AuthMiddleware()
+	return func(c *gin.Context) {
+		ticket := c.Query("ticket")
+		if ticket == "" {
+			auth(c)
+			return
+		}
+
+		token, err := jwt.Parse(ticket, func(token *jwt.Token) (interface{}, error) {
+			return []byte(os.Getenv("JWT_SECRET")), nil
+		}, jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}))
+		if err != nil || !token.Valid {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Недействительный билет"})
+			c.Abort()
+			return
+		}
+
+		claims, ok := token.Claims.(jwt.MapClaims)
+		userID, idOK := claims["user_id"].(float64)
+		if !ok || !idOK || claims["scope"] != StreamTicketScope {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Недействительный билет"})
+			c.Abort()
+			return
+		}
+
+		var user models.User
+		if err := database.DB.First(&user, uint(userID)).Error; err != nil || !user.IsActive {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Пользователь не найден"})
+			c.Abort()
+			return
+		}
+
+		c.Set("user", user)
+		c.Set("user_id", user.ID)
+		c.Next()
+	}
+}
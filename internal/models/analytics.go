@@ -0,0 +1,242 @@
+package models
+
+import (
+	"time"
+)
+
+// ProductPopularity - просмотры и заказы товара за период
+type ProductPopularity struct {
+	ProductID      uint    `json:"product_id"`
+	Name           string  `json:"name"`
+	Views          int64   `json:"views"`
+	UniqueViewers  int64   `json:"unique_viewers"`
+	Orders         int64   `json:"orders"`          // заказы с этим товаром (кроме отмененных)
+	UnitsSold      int64   `json:"units_sold"`      // проданные штуки
+	ConversionRate float64 `json:"conversion_rate"` // заказы на 100 уникальных просмотров, %
+}
+
+// ProductPopularityResponse - отчет о популярности товаров
+type ProductPopularityResponse struct {
+	Days        int                 `json:"days"`
+	MostViewed  []ProductPopularity `json:"most_viewed"`
+	MostOrdered []ProductPopularity `json:"most_ordered"`
+}
+
+// PageView - просмотр страницы сайта
+type PageView struct {
+	ID        uint      `json:"id" gorm:"primaryKey"`
+	SessionID string    `json:"session_id" gorm:"size:64;not null;index"` // сессия фронтенда, без нее - IP и дата
+	IPAddress string    `json:"ip_address" gorm:"size:45;not null"`
+	UserAgent string    `json:"user_agent" gorm:"size:500"`
+	Path      string    `json:"path" gorm:"size:500;not null;index"`
+	Title     string    `json:"title" gorm:"size:300"`
+	Referrer  string    `json:"referrer" gorm:"size:1000"`
+	IsBot     bool      `json:"is_bot" gorm:"default:false"`
+	Date      string    `json:"date" gorm:"size:10;not null;index"` // YYYY-MM-DD
+	Month     string    `json:"month" gorm:"size:7;not null"`       // YYYY-MM
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// PageViewRequest - данные о просмотре страницы от фронтенда.
+// UTM-метки можно передать отдельно или оставить в query string пути.
+type PageViewRequest struct {
+	Path        string `json:"path"`
+	Title       string `json:"title"`
+	Referrer    string `json:"referrer"`
+	SessionID   string `json:"session_id"`
+	UTMSource   string `json:"utm_source"`
+	UTMMedium   string `json:"utm_medium"`
+	UTMCampaign string `json:"utm_campaign"`
+	UTMTerm     string `json:"utm_term"`
+	UTMContent  string `json:"utm_content"`
+}
+
+// PageStat - просмотры страницы
+type PageStat struct {
+	Path     string `json:"path"`
+	Views    int64  `json:"views"`
+	Sessions int64  `json:"sessions"`
+}
+
+// PageStatsResponse - отчет по страницам и сессиям
+type PageStatsResponse struct {
+	Days               int        `json:"days"`
+	Traffic            string     `json:"traffic"` // human или all
+	TotalViews         int64      `json:"total_views"`
+	Sessions           int64      `json:"sessions"`
+	AvgPagesPerSession float64    `json:"avg_pages_per_session"`
+	TopPages           []PageStat `json:"top_pages"`
+	EntryPages         []PageStat `json:"entry_pages"`
+	ExitPages          []PageStat `json:"exit_pages"`
+}
+
+// SessionAttribution - источник первого визита сессии (UTM-метки или сайт-источник)
+type SessionAttribution struct {
+	ID             uint      `json:"id" gorm:"primaryKey"`
+	SessionID      string    `json:"session_id" gorm:"size:64;not null;uniqueIndex"`
+	Source         string    `json:"source" gorm:"size:100;not null;index"` // utm_source, домен источника или direct
+	Medium         string    `json:"medium" gorm:"size:100"`                // utm_medium, referral, organic или none
+	Campaign       string    `json:"campaign" gorm:"size:200"`
+	Term           string    `json:"term" gorm:"size:200"`
+	Content        string    `json:"content" gorm:"size:200"`
+	ReferrerDomain string    `json:"referrer_domain" gorm:"size:255"`
+	LandingPath    string    `json:"landing_path" gorm:"size:500"`
+	CreatedAt      time.Time `json:"created_at" gorm:"index"`
+}
+
+// AttributionStat - сессии и конверсии по источнику и кампании
+type AttributionStat struct {
+	Source         string  `json:"source"`
+	Medium         string  `json:"medium,omitempty"`
+	Campaign       string  `json:"campaign,omitempty"`
+	Sessions       int64   `json:"sessions"`
+	ContactForms   int64   `json:"contact_forms"`  // обращения, включая оставленные телефоны
+	PhoneContacts  int64   `json:"phone_contacts"` // оставленные телефоны
+	PhoneClicks    int64   `json:"phone_clicks"`
+	Orders         int64   `json:"orders"` // заказы, кроме отмененных
+	Revenue        float64 `json:"revenue"`
+	ConversionRate float64 `json:"conversion_rate"` // заказы на 100 сессий, %
+}
+
+// AttributionStatsResponse - отчет по источникам трафика
+type AttributionStatsResponse struct {
+	Days  int               `json:"days"`
+	Group string            `json:"group"` // source или campaign
+	Stats []AttributionStat `json:"stats"`
+}
+
+// DeviceStat - число визитов для устройства, ОС или браузера
+type DeviceStat struct {
+	Name  string  `json:"name"`
+	Count int64   `json:"count"`
+	Share float64 `json:"share"` // доля, %
+}
+
+// DeviceStatsResponse - разбивка по устройствам, ОС и браузерам
+type DeviceStatsResponse struct {
+	Days     int          `json:"days"`
+	Source   string       `json:"source"`  // visitors или phone-clicks
+	Traffic  string       `json:"traffic"` // human или all
+	Total    int64        `json:"total"`
+	Bots     int64        `json:"bots"`
+	Devices  []DeviceStat `json:"devices"`  // включая ботов
+	OS       []DeviceStat `json:"os"`       // без ботов
+	Browsers []DeviceStat `json:"browsers"` // без ботов
+}
+
+// AnalyticsRollup - предрассчитанные показатели за день, месяц, последние дни или все время.
+// Считаются фоновой задачей по сырым записям, чтобы отчеты не пересчитывали
+// COUNT(DISTINCT) по всей таблице и переживали удаление старых записей.
+type AnalyticsRollup struct {
+	ID          uint      `json:"id" gorm:"primaryKey"`
+	Metric      string    `json:"metric" gorm:"size:30;not null;uniqueIndex:idx_analytics_rollup"`     // visitors, phone_clicks
+	Period      string    `json:"period" gorm:"size:10;not null;uniqueIndex:idx_analytics_rollup"`     // day, month, window, all
+	PeriodKey   string    `json:"period_key" gorm:"size:10;not null;uniqueIndex:idx_analytics_rollup"` // YYYY-MM-DD, YYYY-MM, число дней или all
+	UniqueAll   int64     `json:"unique_all"`                                                          // уникальные IP, включая ботов
+	TotalAll    int64     `json:"total_all"`                                                           // просмотры страниц или клики, включая ботов
+	UniqueHuman int64     `json:"unique_human"`
+	TotalHuman  int64     `json:"total_human"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+// AnalyticsSalt - соль для хеширования IP в режиме приватности.
+// Хранятся только соли текущего дня и месяца: после их смены хеши нельзя
+// сопоставить с IP.
+type AnalyticsSalt struct {
+	ID        uint      `json:"id" gorm:"primaryKey"`
+	Date      string    `json:"date" gorm:"size:10;not null;uniqueIndex"` // YYYY-MM-DD или YYYY-MM для месячной соли
+	Salt      string    `json:"-" gorm:"size:64;not null"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// AnonymizeRequest - запрос на обезличивание или удаление сохраненной статистики
+type AnonymizeRequest struct {
+	Action string `json:"action" binding:"required,oneof=anonymize purge"`
+	Method string `json:"method" binding:"omitempty,oneof=hash truncate"` // для anonymize, по умолчанию hash
+	Before string `json:"before"`                                         // YYYY-MM-DD, по умолчанию - все записи
+}
+
+// ClientEvent - событие с сайта (добавление в корзину, клик по баннеру и т.д.).
+// Свойства хранятся JSON-строкой и проверяются по схеме события (internal/events).
+type ClientEvent struct {
+	ID         uint      `json:"id" gorm:"primaryKey"`
+	Name       string    `json:"name" gorm:"size:50;not null;index:idx_client_event_name_date"`
+	SessionID  string    `json:"session_id" gorm:"size:64;index"`
+	Path       string    `json:"path" gorm:"size:500"`
+	Properties string    `json:"properties" gorm:"type:text"`
+	IsBot      bool      `json:"is_bot" gorm:"default:false"`
+	Date       string    `json:"date" gorm:"size:10;not null;index:idx_client_event_name_date"` // YYYY-MM-DD
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+// ClientEventInput - событие в запросе от фронтенда
+type ClientEventInput struct {
+	Name       string                 `json:"name" binding:"required"`
+	Path       string                 `json:"path"`
+	Properties map[string]interface{} `json:"properties"`
+	Timestamp  *time.Time             `json:"timestamp"` // время на клиенте, если событие отправлено пачкой позже
+}
+
+// ClientEventBatch - пачка событий
+type ClientEventBatch struct {
+	SessionID string             `json:"session_id"`
+	Events    []ClientEventInput `json:"events" binding:"required,min=1,max=50"`
+}
+
+// ClientEventStat - число событий по значению группировки
+type ClientEventStat struct {
+	Key      string `json:"key"`
+	Count    int64  `json:"count"`
+	Sessions int64  `json:"sessions"`
+}
+
+// FunnelStep - шаг воронки
+type FunnelStep struct {
+	Step               string  `json:"step"`
+	Sessions           int64   `json:"sessions"`             // сессии, дошедшие до шага
+	ConversionRate     float64 `json:"conversion_rate"`      // от первого шага, %
+	StepConversionRate float64 `json:"step_conversion_rate"` // от предыдущего шага, %
+	DropOff            int64   `json:"drop_off"`             // ушли после предыдущего шага
+	DropOffRate        float64 `json:"drop_off_rate"`        // доля ушедших после предыдущего шага, %
+}
+
+// FunnelResponse - отчет по воронке
+type FunnelResponse struct {
+	Funnel  string       `json:"funnel"` // имя готовой воронки или custom
+	From    string       `json:"from"`
+	To      string       `json:"to"`
+	Ordered bool         `json:"ordered"` // шаги должны идти по порядку
+	Traffic string       `json:"traffic"`
+	Steps   []FunnelStep `json:"steps"`
+}
+
+// PhoneClickRequest - данные о кнопке телефона, по которой кликнули
+type PhoneClickRequest struct {
+	Path      string `json:"path"`      // страница; без нее берется из Referer
+	ButtonID  string `json:"button_id"` // id кнопки
+	Placement string `json:"placement"` // расположение: header, footer, product, contacts, floating
+	ProductID *uint  `json:"product_id"`
+}
+
+// PhoneClickGroup - клики по телефону в разрезе расположения кнопки или страницы
+type PhoneClickGroup struct {
+	Key      string `json:"key"`
+	Clicks   int64  `json:"clicks"`
+	Sessions int64  `json:"sessions"`
+}
+
+// PhoneClickProduct - клики по телефону со страницы товара
+type PhoneClickProduct struct {
+	ProductID uint   `json:"product_id"`
+	Name      string `json:"name"`
+	Clicks    int64  `json:"clicks"`
+	Sessions  int64  `json:"sessions"`
+}
+
+// RegionStat - посетители или клики по телефону из региона (по GeoIP)
+type RegionStat struct {
+	Country string  `json:"country"` // unknown - не определено
+	Region  string  `json:"region"`  // unknown - не определено
+	Count   int64   `json:"count"`
+	Share   float64 `json:"share"` // доля, %
+}
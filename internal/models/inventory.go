@@ -0,0 +1,117 @@
+package models
+
+import (
+	"time"
+)
+
+// StockMovement - запись журнала движения товара на складе.
+// Остаток Product.Stock всегда равен сумме Quantity всех движений товара,
+// а ProductStock.Quantity - сумме движений товара по этому складу.
+type StockMovement struct {
+	ID                   uint      `json:"id" gorm:"primaryKey"`
+	ProductID            uint      `json:"product_id" gorm:"not null;index"`
+	WarehouseID          *uint     `json:"warehouse_id" gorm:"index"`
+	Type                 string    `json:"type" gorm:"size:20;not null"` // receipt, sale, return, adjustment, reservation, release
+	Quantity             int       `json:"quantity" gorm:"not null"`     // со знаком: приход > 0, расход < 0
+	BalanceAfter         int       `json:"balance_after"`                // общий остаток товара после движения
+	LocationBalanceAfter int       `json:"location_balance_after"`       // остаток на складе движения после движения
+	Reason               string    `json:"reason" gorm:"size:255"`
+	ActorID              *uint     `json:"actor_id"`
+	ActorName            string    `json:"actor_name" gorm:"size:100"`
+	OrderID              *uint     `json:"order_id,omitempty" gorm:"index"`
+	CreatedAt            time.Time `json:"created_at"`
+
+	// Связи
+	Warehouse *Warehouse `json:"warehouse,omitempty" gorm:"foreignKey:WarehouseID"`
+}
+
+// StockMovementRequest - структура для проведения движения товара.
+// Резерв и снятие резерва проводятся только при оформлении и отмене заказа.
+type StockMovementRequest struct {
+	Type        string `json:"type" binding:"required,oneof=receipt sale return adjustment"`
+	Quantity    int    `json:"quantity" binding:"required,ne=0"` // для adjustment со знаком, для остальных - количество единиц
+	Reason      string `json:"reason" binding:"required"`
+	OrderID     *uint  `json:"order_id"`
+	WarehouseID *uint  `json:"warehouse_id"` // по умолчанию - основной склад
+}
+
+// Warehouse - склад или магазин (точка самовывоза)
+type Warehouse struct {
+	ID            uint      `json:"id" gorm:"primaryKey"`
+	Name          string    `json:"name" gorm:"size:100;not null"`
+	Type          string    `json:"type" gorm:"size:20;not null;default:'warehouse'"` // warehouse, store
+	City          string    `json:"city" gorm:"size:100"`
+	Address       string    `json:"address" gorm:"size:255"`
+	Phone         string    `json:"phone" gorm:"size:20"`
+	WorkingHours  string    `json:"working_hours" gorm:"size:100"`
+	IsPickupPoint bool      `json:"is_pickup_point" gorm:"default:false"`
+	IsDefault     bool      `json:"is_default" gorm:"default:false"` // склад для доставки и движений без указания склада
+	IsActive      bool      `json:"is_active" gorm:"default:true"`
+	CreatedAt     time.Time `json:"created_at"`
+	UpdatedAt     time.Time `json:"updated_at"`
+}
+
+// WarehouseRequest - структура для создания/обновления склада
+type WarehouseRequest struct {
+	Name          string `json:"name" binding:"required"`
+	Type          string `json:"type" binding:"required,oneof=warehouse store"`
+	City          string `json:"city"`
+	Address       string `json:"address"`
+	Phone         string `json:"phone"`
+	WorkingHours  string `json:"working_hours"`
+	IsPickupPoint bool   `json:"is_pickup_point"`
+	IsDefault     bool   `json:"is_default"`
+	IsActive      bool   `json:"is_active"`
+}
+
+// ProductStock - остаток товара на конкретном складе.
+// Product.Stock равен сумме Quantity по всем складам.
+type ProductStock struct {
+	ID          uint      `json:"id" gorm:"primaryKey"`
+	ProductID   uint      `json:"product_id" gorm:"not null;uniqueIndex:idx_product_warehouse"`
+	WarehouseID uint      `json:"warehouse_id" gorm:"not null;uniqueIndex:idx_product_warehouse"`
+	Quantity    int       `json:"quantity" gorm:"not null;default:0"`
+	UpdatedAt   time.Time `json:"updated_at"`
+
+	// Связи
+	Warehouse Warehouse `json:"warehouse,omitempty" gorm:"foreignKey:WarehouseID"`
+}
+
+// LocationStock - наличие товара в точке (для карточки товара)
+type LocationStock struct {
+	WarehouseID   uint   `json:"warehouse_id"`
+	Name          string `json:"name"`
+	Type          string `json:"type"`
+	City          string `json:"city"`
+	Address       string `json:"address"`
+	IsPickupPoint bool   `json:"is_pickup_point"`
+	Quantity      int    `json:"quantity"`
+	Label         string `json:"label"` // например: "3 шт. — Магазин Чиланзар"
+}
+
+// StockSubscription - подписка покупателя на поступление товара
+type StockSubscription struct {
+	ID         uint       `json:"id" gorm:"primaryKey"`
+	ProductID  uint       `json:"product_id" gorm:"not null;index"`
+	Phone      string     `json:"phone" gorm:"size:20"`
+	Email      string     `json:"email" gorm:"size:100"`
+	NotifiedAt *time.Time `json:"notified_at"` // nil - покупатель еще ждет поступления
+	CreatedAt  time.Time  `json:"created_at"`
+}
+
+// StockSubscriptionRequest - структура для подписки на поступление товара
+type StockSubscriptionRequest struct {
+	Phone string `json:"phone" binding:"required_without=Email"`
+	Email string `json:"email" binding:"omitempty,email"`
+}
+
+// AdminNotification - уведомление для администратора (например, о низком остатке)
+type AdminNotification struct {
+	ID        uint      `json:"id" gorm:"primaryKey"`
+	Type      string    `json:"type" gorm:"size:50;not null"` // low_stock
+	Title     string    `json:"title" gorm:"size:200;not null"`
+	Message   string    `json:"message" gorm:"type:text"`
+	ProductID *uint     `json:"product_id,omitempty"`
+	IsRead    bool      `json:"is_read" gorm:"default:false"`
+	CreatedAt time.Time `json:"created_at"`
+}
@@ -0,0 +1,53 @@
+package models
+
+import (
+	"time"
+)
+
+// MarketplaceCategory - соответствие нашей категории категории маркетплейса.
+// Товары выгружаются на маркетплейс только из сопоставленных категорий.
+type MarketplaceCategory struct {
+	ID                   uint      `json:"id" gorm:"primaryKey"`
+	Marketplace          string    `json:"marketplace" gorm:"size:50;not null;uniqueIndex:idx_marketplace_category"`
+	CategoryID           uint      `json:"category_id" gorm:"not null;uniqueIndex:idx_marketplace_category"`
+	ExternalCategoryID   string    `json:"external_category_id" gorm:"size:64;not null"`
+	ExternalCategoryName string    `json:"external_category_name" gorm:"size:255"`
+	CreatedAt            time.Time `json:"created_at"`
+	UpdatedAt            time.Time `json:"updated_at"`
+
+	// Связи
+	Category Category `json:"category,omitempty" gorm:"foreignKey:CategoryID"`
+}
+
+// MarketplaceCategoryRequest - структура для сопоставления категории
+type MarketplaceCategoryRequest struct {
+	CategoryID           uint   `json:"category_id" binding:"required"`
+	ExternalCategoryID   string `json:"external_category_id" binding:"required"`
+	ExternalCategoryName string `json:"external_category_name"`
+}
+
+// MarketplaceOrder - заказ, полученный с маркетплейса
+type MarketplaceOrder struct {
+	ID          uint      `json:"id" gorm:"primaryKey"`
+	Marketplace string    `json:"marketplace" gorm:"size:50;not null;uniqueIndex:idx_marketplace_order"`
+	ExternalID  string    `json:"external_id" gorm:"size:64;not null;uniqueIndex:idx_marketplace_order"`
+	Status      string    `json:"status" gorm:"size:50"`
+	Total       float64   `json:"total"`
+	PlacedAt    time.Time `json:"placed_at"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+
+	// Связи
+	Items []MarketplaceOrderItem `json:"items,omitempty" gorm:"foreignKey:MarketplaceOrderID"`
+}
+
+// MarketplaceOrderItem - позиция заказа с маркетплейса
+type MarketplaceOrderItem struct {
+	ID                 uint    `json:"id" gorm:"primaryKey"`
+	MarketplaceOrderID uint    `json:"marketplace_order_id" gorm:"not null;index"`
+	ProductID          *uint   `json:"product_id"` // пусто, если артикул не найден у нас
+	SKU                string  `json:"sku" gorm:"size:64"`
+	Name               string  `json:"name" gorm:"size:255"`
+	Quantity           int     `json:"quantity"`
+	Price              float64 `json:"price"`
+}
@@ -19,13 +19,15 @@ type User struct {
 
 // Category - модель категории товаров
 type Category struct {
-	ID          uint      `json:"id" gorm:"primaryKey"`
-	Name        string    `json:"name" gorm:"size:100;not null"`
-	Description string    `json:"description" gorm:"type:text"`
-	Image       string    `json:"image" gorm:"size:255"`
-	IsActive    bool      `json:"is_active" gorm:"default:true"`
-	CreatedAt   time.Time `json:"created_at"`
-	UpdatedAt   time.Time `json:"updated_at"`
+	ID               uint      `json:"id" gorm:"primaryKey"`
+	Name             string    `json:"name" gorm:"size:100;not null"`
+	Description      string    `json:"description" gorm:"type:text"`
+	Image            string    `json:"image" gorm:"size:255"`
+	IsActive         bool      `json:"is_active" gorm:"default:true"`
+	ExcludeFromFeeds bool      `json:"exclude_from_feeds" gorm:"default:false"` // товары категории не выгружаются в фиды
+	ExternalID       string    `json:"external_id" gorm:"size:64;index"`        // Ид группы в 1С
+	CreatedAt        time.Time `json:"created_at"`
+	UpdatedAt        time.Time `json:"updated_at"`
 	
 	// Связи
 	Products []Product `json:"products,omitempty" gorm:"foreignKey:CategoryID"`
@@ -41,13 +43,31 @@ type Product struct {
 	Image       string    `json:"image" gorm:"size:255"`
 	CategoryID  uint      `json:"category_id" gorm:"not null"`
 	Brand       string    `json:"brand" gorm:"size:100"`
-	Model       string    `json:"model" gorm:"size:100"`
+	Model       string    `json:"model" gorm:"size:100;index"`
+	SKU         string    `json:"sku" gorm:"size:64;index"` // артикул
 	Stock       int       `json:"stock" gorm:"default:0"`
 	IsActive    bool      `json:"is_active" gorm:"default:true"`
 	IsFeatured  bool      `json:"is_featured" gorm:"default:false"`
 	CreatedAt   time.Time `json:"created_at"`
 	UpdatedAt   time.Time `json:"updated_at"`
 	
+	// Оповещение о низком остатке (порог 0 - без оповещений)
+	LowStockThreshold int  `json:"low_stock_threshold" gorm:"default:0"`
+	LowStockAlerted   bool `json:"-" gorm:"default:false"` // оповещение отправлено, ждем пополнения
+	
+	// Товар не выгружается в товарные фиды (Яндекс Маркет, Google Merchant)
+	ExcludeFromFeeds bool `json:"exclude_from_feeds" gorm:"default:false"`
+	
+	// Ид товара в 1С (обмен CommerceML)
+	ExternalID string `json:"external_id" gorm:"size:64;index"`
+	
+	// Цена с учетом акций (вычисляется при выдаче, в базе не хранится)
+	FinalPrice float64           `json:"final_price" gorm:"-"`
+	Promotion  *AppliedPromotion `json:"promotion,omitempty" gorm:"-"`
+	
+	// Наличие по складам и магазинам (заполняется в карточке товара)
+	Availability []LocationStock `json:"availability,omitempty" gorm:"-"`
+	
 	// Связи
 	Category Category `json:"category,omitempty" gorm:"foreignKey:CategoryID"`
 }
@@ -62,21 +82,28 @@ type Order struct {
 	ShippingAddress string `json:"shipping_address" gorm:"type:text;not null"`
 	Phone      string      `json:"phone" gorm:"size:20;not null"`
 	Notes      string      `json:"notes" gorm:"type:text"`
+	DeliveryType string    `json:"delivery_type" gorm:"size:20;default:'delivery'"` // delivery, pickup
+	WarehouseID  *uint     `json:"warehouse_id"` // склад отгрузки или точка самовывоза
+	ExportedAt   *time.Time `json:"exported_at"` // когда заказ выгружен в 1С
+	SessionID  string      `json:"session_id" gorm:"size:64;index"` // сессия сайта для атрибуции рекламы
 	CreatedAt  time.Time   `json:"created_at"`
 	UpdatedAt  time.Time   `json:"updated_at"`
 	
 	// Связи
 	User       User        `json:"user,omitempty" gorm:"foreignKey:UserID"`
 	OrderItems []OrderItem `json:"order_items,omitempty" gorm:"foreignKey:OrderID"`
+	Warehouse  *Warehouse  `json:"warehouse,omitempty" gorm:"foreignKey:WarehouseID"`
 }
 
 // OrderItem - модель позиции заказа
 type OrderItem struct {
-	ID        uint    `json:"id" gorm:"primaryKey"`
-	OrderID   uint    `json:"order_id" gorm:"not null"`
-	ProductID uint    `json:"product_id" gorm:"not null"`
-	Quantity  int     `json:"quantity" gorm:"not null"`
-	Price     float64 `json:"price" gorm:"not null"`
+	ID          uint    `json:"id" gorm:"primaryKey"`
+	OrderID     uint    `json:"order_id" gorm:"not null"`
+	ProductID   uint    `json:"product_id" gorm:"not null"`
+	Quantity    int     `json:"quantity" gorm:"not null"`
+	Price       float64 `json:"price" gorm:"not null"` // цена за единицу с учетом акции
+	BasePrice   float64 `json:"base_price"`            // цена за единицу до акции
+	PromotionID *uint   `json:"promotion_id,omitempty"`
 	
 	// Связи
 	Order   Order   `json:"-" gorm:"foreignKey:OrderID"`
@@ -92,6 +119,7 @@ type ContactForm struct {
 	Subject   string    `json:"subject" gorm:"size:200;not null"`
 	Message   string    `json:"message" gorm:"type:text;not null"`
 	IsRead    bool      `json:"is_read" gorm:"default:false"`
+	SessionID string    `json:"session_id" gorm:"size:64;index"` // сессия сайта для атрибуции рекламы
 	CreatedAt time.Time `json:"created_at"`
 }
 
@@ -111,56 +139,98 @@ type RegisterRequest struct {
 
 // ProductRequest - структура для создания/обновления товара
 type ProductRequest struct {
-	Name        string   `json:"name" binding:"required"`
-	Description string   `json:"description"`
-	Price       float64  `json:"price" binding:"required,gt=0"`
-	OldPrice    float64  `json:"old_price"`
-	CategoryID  uint     `json:"category_id" binding:"required"`
-	Brand       string   `json:"brand"`
-	Model       string   `json:"model"`
-	Stock       int      `json:"stock"`
-	IsActive    bool     `json:"is_active"`
-	IsFeatured  bool     `json:"is_featured"`
+	Name              string  `json:"name" binding:"required"`
+	Description       string  `json:"description"`
+	Price             float64 `json:"price" binding:"required,gt=0"`
+	OldPrice          float64 `json:"old_price"`
+	CategoryID        uint    `json:"category_id" binding:"required"`
+	Brand             string  `json:"brand"`
+	Model             string  `json:"model"`
+	SKU               string  `json:"sku"`
+	Stock             int     `json:"stock"`
+	IsActive          bool    `json:"is_active"`
+	IsFeatured        bool    `json:"is_featured"`
+	LowStockThreshold int     `json:"low_stock_threshold" binding:"gte=0"`
+	ExcludeFromFeeds  bool    `json:"exclude_from_feeds"`
+}
+
+// OrderItemRequest - позиция в запросе оформления заказа
+type OrderItemRequest struct {
+	ProductID uint `json:"product_id" binding:"required"`
+	Quantity  int  `json:"quantity" binding:"required,gt=0"`
+}
+
+// OrderRequest - структура для оформления заказа
+type OrderRequest struct {
+	Items           []OrderItemRequest `json:"items" binding:"required,min=1,dive"`
+	ShippingAddress string             `json:"shipping_address" binding:"required_without=PickupPointID"`
+	Phone           string             `json:"phone" binding:"required"`
+	Notes           string             `json:"notes"`
+	PickupPointID   *uint              `json:"pickup_point_id"` // самовывоз; без него - доставка с основного склада
 }
 
 // CategoryRequest - структура для создания/обновления категории
 type CategoryRequest struct {
-	Name        string `json:"name" binding:"required"`
-	Description string `json:"description"`
-	IsActive    bool   `json:"is_active"`
+	Name             string `json:"name" binding:"required"`
+	Description      string `json:"description"`
+	IsActive         bool   `json:"is_active"`
+	ExcludeFromFeeds bool   `json:"exclude_from_feeds"`
 }
 
 // VisitorStat - модель для отслеживания посетителей
 type VisitorStat struct {
-	ID        uint      `json:"id" gorm:"primaryKey"`
-	IPAddress string    `json:"ip_address" gorm:"size:45;not null"`
-	UserAgent string    `json:"user_agent" gorm:"size:500"`
-	Date      string    `json:"date" gorm:"size:10;not null"` // YYYY-MM-DD
-	Month     string    `json:"month" gorm:"size:7;not null"` // YYYY-MM
-	CreatedAt time.Time `json:"created_at"`
+	ID         uint      `json:"id" gorm:"primaryKey"`
+	IPAddress  string    `json:"ip_address" gorm:"size:45;not null"`
+	VisitorKey string    `json:"-" gorm:"size:45;index"` // для уникальных за месяц: IP или хеш с месячной солью
+	UserAgent  string    `json:"user_agent" gorm:"size:500"`
+	DeviceType string    `json:"device_type" gorm:"size:20;index"` // desktop, mobile, tablet, bot, unknown
+	OS         string    `json:"os" gorm:"size:50"`
+	Browser    string    `json:"browser" gorm:"size:50"`
+	IsBot      bool      `json:"is_bot" gorm:"default:false"`
+	Country    string    `json:"country" gorm:"size:2"` // код страны по GeoIP
+	Region     string    `json:"region" gorm:"size:100"`
+	City       string    `json:"city" gorm:"size:100"`
+	Date       string    `json:"date" gorm:"size:10;not null"` // YYYY-MM-DD
+	Month      string    `json:"month" gorm:"size:7;not null"` // YYYY-MM
+	CreatedAt  time.Time `json:"created_at"`
 }
 
 // PhoneContact - модель для хранения только телефонных номеров
 type PhoneContact struct {
 	ID        uint      `json:"id" gorm:"primaryKey"`
 	Phone     string    `json:"phone" gorm:"size:20;not null"`
+	SessionID string    `json:"session_id" gorm:"size:64;index"`
 	CreatedAt time.Time `json:"created_at"`
 }
 
 // PhoneClickStat - модель для отслеживания кликов по кнопке телефона
 type PhoneClickStat struct {
-	ID        uint      `json:"id" gorm:"primaryKey"`
-	IPAddress string    `json:"ip_address" gorm:"size:45;not null"`
-	UserAgent string    `json:"user_agent" gorm:"size:500"`
-	Date      string    `json:"date" gorm:"size:10;not null"` // YYYY-MM-DD
-	CreatedAt time.Time `json:"created_at"`
+	ID         uint      `json:"id" gorm:"primaryKey"`
+	IPAddress  string    `json:"ip_address" gorm:"size:45;not null"`
+	VisitorKey string    `json:"-" gorm:"size:45;index"` // для уникальных за месяц: IP или хеш с месячной солью
+	UserAgent  string    `json:"user_agent" gorm:"size:500"`
+	DeviceType string    `json:"device_type" gorm:"size:20;index"` // desktop, mobile, tablet, bot, unknown
+	OS         string    `json:"os" gorm:"size:50"`
+	Browser    string    `json:"browser" gorm:"size:50"`
+	IsBot      bool      `json:"is_bot" gorm:"default:false"`
+	Country    string    `json:"country" gorm:"size:2"` // код страны по GeoIP
+	Region     string    `json:"region" gorm:"size:100"`
+	City       string    `json:"city" gorm:"size:100"`
+	Date       string    `json:"date" gorm:"size:10;not null"` // YYYY-MM-DD
+	SessionID  string    `json:"session_id" gorm:"size:64;index"`
+	Path       string    `json:"path" gorm:"size:500"`              // страница, на которой нажата кнопка
+	ButtonID   string    `json:"button_id" gorm:"size:100"`         // id кнопки на фронтенде
+	Placement  string    `json:"placement" gorm:"size:50;index"`    // header, footer, product, ...
+	ProductID  *uint     `json:"product_id,omitempty" gorm:"index"` // товар, со страницы которого звонили
+	CreatedAt  time.Time `json:"created_at"`
 }
 
 // VisitorStatsResponse - ответ для статистики посетителей
 type VisitorStatsResponse struct {
 	DailyStats   []DailyStat   `json:"daily_stats"`
 	MonthlyStats []MonthlyStat `json:"monthly_stats"`
-	TotalUnique  int64         `json:"total_unique"`
+	TotalUnique  int64         `json:"total_unique"` // уникальные посетители за все время
+	ByRegion     []RegionStat  `json:"by_region"`
 }
 
 // DailyStat - дневная статистика
@@ -179,9 +249,13 @@ type MonthlyStat struct {
 
 // PhoneClickStatsResponse - ответ для статистики кликов по телефону
 type PhoneClickStatsResponse struct {
-	TotalClicks  int64       `json:"total_clicks"`
-	DailyClicks  []DailyStat `json:"daily_clicks"`
-	UniqueClicks int64       `json:"unique_clicks"`
+	TotalClicks  int64               `json:"total_clicks"`
+	DailyClicks  []DailyStat         `json:"daily_clicks"`
+	UniqueClicks int64               `json:"unique_clicks"` // уникальные посетители, звонившие за период
+	ByPlacement  []PhoneClickGroup   `json:"by_placement"`
+	ByPage       []PhoneClickGroup   `json:"by_page"`
+	ByProduct    []PhoneClickProduct `json:"by_product"`
+	ByRegion     []RegionStat        `json:"by_region"`
 }
 
 // AdminLoginRequest - запрос для входа в админ панель
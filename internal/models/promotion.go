@@ -0,0 +1,75 @@
+package models
+
+import (
+	"time"
+)
+
+// Promotion - правило акции (скидка на категорию, бренд, товар или комплект)
+type Promotion struct {
+	ID          uint    `json:"id" gorm:"primaryKey"`
+	Name        string  `json:"name" gorm:"size:200;not null"`
+	Description string  `json:"description" gorm:"type:text"`
+	Type        string  `json:"type" gorm:"size:20;not null"`  // percent, fixed
+	Value       float64 `json:"value" gorm:"not null"`         // процент или сумма скидки
+	Scope       string  `json:"scope" gorm:"size:20;not null"` // all, category, brand, product, bundle
+	CategoryID  *uint   `json:"category_id"`                   // для scope=category и целевая категория для bundle
+	ProductID   *uint   `json:"product_id"`
+	Brand       string  `json:"brand" gorm:"size:100"`
+	// TriggerCategoryID - для bundle: скидка на товар из CategoryID действует,
+	// только если в корзине есть товар из этой категории
+	TriggerCategoryID *uint      `json:"trigger_category_id"`
+	IsFlash           bool       `json:"is_flash" gorm:"default:false"`
+	StartsAt          *time.Time `json:"starts_at"`
+	EndsAt            *time.Time `json:"ends_at"`
+	IsActive          bool       `json:"is_active" gorm:"default:true"`
+	CreatedAt         time.Time  `json:"created_at"`
+	UpdatedAt         time.Time  `json:"updated_at"`
+}
+
+// PromotionRequest - структура для создания/обновления акции
+type PromotionRequest struct {
+	Name              string     `json:"name" binding:"required"`
+	Description       string     `json:"description"`
+	Type              string     `json:"type" binding:"required,oneof=percent fixed"`
+	Value             float64    `json:"value" binding:"required,gt=0"`
+	Scope             string     `json:"scope" binding:"required,oneof=all category brand product bundle"`
+	CategoryID        *uint      `json:"category_id"`
+	ProductID         *uint      `json:"product_id"`
+	Brand             string     `json:"brand"`
+	TriggerCategoryID *uint      `json:"trigger_category_id"`
+	IsFlash           bool       `json:"is_flash"`
+	StartsAt          *time.Time `json:"starts_at"`
+	EndsAt            *time.Time `json:"ends_at"`
+	IsActive          bool       `json:"is_active"`
+}
+
+// AppliedPromotion - акция, примененная к цене товара
+type AppliedPromotion struct {
+	ID       uint       `json:"id"`
+	Name     string     `json:"name"`
+	Type     string     `json:"type"`
+	Value    float64    `json:"value"`
+	Discount float64    `json:"discount"` // размер скидки на единицу товара
+	IsFlash  bool       `json:"is_flash"`
+	EndsAt   *time.Time `json:"ends_at,omitempty"`
+	EndsIn   int64      `json:"ends_in,omitempty"` // секунд до окончания (для таймера флеш-распродажи)
+}
+
+// DisplayPrices - цена продажи с учетом акций и цена до скидки для
+// зачеркнутой цены (0, если скидки нет). Ожидает цену с учетом акций
+// (promotions.ApplyToProduct), без нее используется Price. Одна логика
+// для витрины, фидов, маркетплейсов и разметки schema.org.
+func (p *Product) DisplayPrices() (price, oldPrice float64) {
+	price = p.FinalPrice
+	if price == 0 {
+		price = p.Price
+	}
+	oldPrice = p.OldPrice
+	if price < p.Price && oldPrice < p.Price {
+		oldPrice = p.Price
+	}
+	if oldPrice <= price {
+		oldPrice = 0
+	}
+	return price, oldPrice
+}
@@ -0,0 +1,26 @@
+package models
+
+import (
+	"time"
+)
+
+// ProductView - просмотр карточки товара. Сессия берется из заголовка
+// X-Session-ID, который фронтенд хранит в localStorage; без него просмотр
+// учитывается по IP.
+type ProductView struct {
+	ID        uint      `json:"id" gorm:"primaryKey"`
+	ProductID uint      `json:"product_id" gorm:"not null;index"`
+	SessionID string    `json:"session_id" gorm:"size:64;index"`
+	IPAddress string    `json:"ip_address" gorm:"size:45"`
+	CreatedAt time.Time `json:"created_at" gorm:"index"`
+}
+
+// ProductRelation - предрассчитанная связь товаров для рекомендаций
+type ProductRelation struct {
+	ID        uint      `json:"id" gorm:"primaryKey"`
+	ProductID uint      `json:"product_id" gorm:"not null;uniqueIndex:idx_product_relation"`
+	RelatedID uint      `json:"related_id" gorm:"not null;uniqueIndex:idx_product_relation"`
+	Kind      string    `json:"kind" gorm:"size:20;not null;uniqueIndex:idx_product_relation"` // bought_together, viewed_together
+	Score     float64   `json:"score"`                                                         // число общих заказов или сессий
+	UpdatedAt time.Time `json:"updated_at"`
+}
@@ -0,0 +1,31 @@
+package models
+
+import (
+	"time"
+)
+
+// ReportSchedule - расписание отправки отчета о работе магазина
+type ReportSchedule struct {
+	ID        uint       `json:"id" gorm:"primaryKey"`
+	Name      string     `json:"name" gorm:"size:200;not null"`
+	Cron      string     `json:"cron" gorm:"size:100;not null"`      // минута час день месяц день_недели, например "0 9 * * 1"
+	Period    string     `json:"period" gorm:"size:10;not null"`     // day, week, month - за какой завершенный период отчет
+	Channel   string     `json:"channel" gorm:"size:20;not null"`    // email, telegram
+	Recipient string     `json:"recipient" gorm:"size:500;not null"` // адреса через запятую или chat_id
+	IsActive  bool       `json:"is_active" gorm:"default:true"`
+	NextRunAt *time.Time `json:"next_run_at" gorm:"index"`
+	LastRunAt *time.Time `json:"last_run_at"`
+	LastError string     `json:"last_error" gorm:"type:text"`
+	CreatedAt time.Time  `json:"created_at"`
+	UpdatedAt time.Time  `json:"updated_at"`
+}
+
+// ReportScheduleRequest - структура для создания/обновления расписания отчета
+type ReportScheduleRequest struct {
+	Name      string `json:"name" binding:"required"`
+	Cron      string `json:"cron" binding:"required"`
+	Period    string `json:"period" binding:"required,oneof=day week month"`
+	Channel   string `json:"channel" binding:"required,oneof=email telegram"`
+	Recipient string `json:"recipient" binding:"required"`
+	IsActive  bool   `json:"is_active"`
+}
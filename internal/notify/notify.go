@@ -0,0 +1,74 @@
+// Package notify отправляет уведомления покупателям и администраторам.
+//
+// Способ доставки подключается через интерфейс Notifier. По умолчанию
+// уведомления только пишутся в лог; рабочий транспорт устанавливается
+// при запуске через SetNotifier (см. FromEnv).
+package notify
+
+import (
+	"context"
+	"log"
+	"sync"
+)
+
+// Каналы доставки
+const (
+	ChannelEmail    = "email"
+	ChannelSMS      = "sms"
+	ChannelTelegram = "telegram"
+	ChannelAdmin    = "admin" // служебные уведомления администраторам
+)
+
+// Message - уведомление
+type Message struct {
+	Channel     string // email, sms, telegram, admin
+	To          string // адрес, телефон или чат Telegram; для admin может быть пустым
+	Subject     string
+	Text        string
+	HTML        string // HTML-версия письма, если транспорт ее поддерживает
+	Attachments []Attachment
+}
+
+// Attachment - вложенный файл
+type Attachment struct {
+	Name        string
+	ContentType string
+	Data        []byte
+}
+
+// Notifier доставляет уведомления
+type Notifier interface {
+	Send(ctx context.Context, msg Message) error
+}
+
+// LogNotifier пишет уведомления в лог вместо отправки
+type LogNotifier struct{}
+
+// Send пишет уведомление в лог
+func (LogNotifier) Send(_ context.Context, msg Message) error {
+	log.Printf("📨 Уведомление [%s] %s: %s — %s", msg.Channel, msg.To, msg.Subject, msg.Text)
+	for _, a := range msg.Attachments {
+		log.Printf("📎 Вложение %s (%d байт) не отправлено: транспорт не настроен", a.Name, len(a.Data))
+	}
+	return nil
+}
+
+var (
+	mu      sync.RWMutex
+	current Notifier = LogNotifier{}
+)
+
+// SetNotifier устанавливает транспорт уведомлений
+func SetNotifier(n Notifier) {
+	mu.Lock()
+	defer mu.Unlock()
+	current = n
+}
+
+// Send отправляет уведомление через установленный транспорт
+func Send(ctx context.Context, msg Message) error {
+	mu.RLock()
+	n := current
+	mu.RUnlock()
+	return n.Send(ctx, msg)
+}
@@ -0,0 +1,58 @@
+package notify
+
+import (
+	"context"
+	"log"
+	"os"
+)
+
+// Router выбирает транспорт по каналу уведомления
+type Router struct {
+	Channels map[string]Notifier
+	Fallback Notifier // для каналов без транспорта
+}
+
+// Send отправляет уведомление транспортом его канала
+func (r Router) Send(ctx context.Context, msg Message) error {
+	if n, ok := r.Channels[msg.Channel]; ok {
+		return n.Send(ctx, msg)
+	}
+	if r.Fallback != nil {
+		return r.Fallback.Send(ctx, msg)
+	}
+	return LogNotifier{}.Send(ctx, msg)
+}
+
+// FromEnv собирает транспорт по переменным окружения:
+// NOTIFY_STUB_DIR - вместо отправки все уведомления сохраняются в файлы;
+// SMTP_HOST, SMTP_PORT, SMTP_USERNAME, SMTP_PASSWORD, SMTP_FROM - письма;
+// TELEGRAM_BOT_TOKEN - сообщения в Telegram. Остальные каналы пишутся в лог.
+func FromEnv() Notifier {
+	if dir := os.Getenv("NOTIFY_STUB_DIR"); dir != "" {
+		log.Printf("📨 Уведомления сохраняются в %s без отправки", dir)
+		return &StubNotifier{Dir: dir}
+	}
+
+	router := Router{Channels: make(map[string]Notifier), Fallback: LogNotifier{}}
+	if host := os.Getenv("SMTP_HOST"); host != "" {
+		port := os.Getenv("SMTP_PORT")
+		if port == "" {
+			port = "587"
+		}
+		from := os.Getenv("SMTP_FROM")
+		if from == "" {
+			from = os.Getenv("SMTP_USERNAME")
+		}
+		router.Channels[ChannelEmail] = SMTPNotifier{
+			Host:     host,
+			Port:     port,
+			Username: os.Getenv("SMTP_USERNAME"),
+			Password: os.Getenv("SMTP_PASSWORD"),
+			From:     from,
+		}
+	}
+	if token := os.Getenv("TELEGRAM_BOT_TOKEN"); token != "" {
+		router.Channels[ChannelTelegram] = TelegramNotifier{Token: token}
+	}
+	return router
+}
@@ -0,0 +1,144 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/tls"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"mime"
+	"net"
+	"net/smtp"
+	"strings"
+	"time"
+)
+
+// SMTPNotifier отправляет письма через SMTP. Порт 465 - TLS сразу,
+// остальные - STARTTLS, если сервер его поддерживает.
+type SMTPNotifier struct {
+	Host     string
+	Port     string
+	Username string
+	Password string
+	From     string
+}
+
+// Send отправляет письмо
+func (n SMTPNotifier) Send(ctx context.Context, msg Message) error {
+	if msg.To == "" {
+		return fmt.Errorf("не указан адрес получателя")
+	}
+	body, err := n.buildMessage(msg)
+	if err != nil {
+		return err
+	}
+
+	addr := net.JoinHostPort(n.Host, n.Port)
+	dialer := &net.Dialer{Timeout: 30 * time.Second}
+	conn, err := dialer.DialContext(ctx, "tcp", addr)
+	if err != nil {
+		return err
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	}
+	if n.Port == "465" {
+		conn = tls.Client(conn, &tls.Config{ServerName: n.Host})
+	}
+
+	client, err := smtp.NewClient(conn, n.Host)
+	if err != nil {
+		conn.Close()
+		return err
+	}
+	defer client.Close()
+
+	if ok, _ := client.Extension("STARTTLS"); ok && n.Port != "465" {
+		if err := client.StartTLS(&tls.Config{ServerName: n.Host}); err != nil {
+			return err
+		}
+	}
+	if n.Username != "" {
+		if err := client.Auth(smtp.PlainAuth("", n.Username, n.Password, n.Host)); err != nil {
+			return err
+		}
+	}
+	if err := client.Mail(n.From); err != nil {
+		return err
+	}
+	for _, to := range strings.Split(msg.To, ",") {
+		if err := client.Rcpt(strings.TrimSpace(to)); err != nil {
+			return err
+		}
+	}
+	w, err := client.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(body); err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+	return client.Quit()
+}
+
+// buildMessage собирает MIME-письмо: текст и HTML как альтернативы, вложения отдельными частями
+func (n SMTPNotifier) buildMessage(msg Message) ([]byte, error) {
+	mixed, err := boundary()
+	if err != nil {
+		return nil, err
+	}
+	alternative, err := boundary()
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "From: %s\r\n", n.From)
+	fmt.Fprintf(&buf, "To: %s\r\n", msg.To)
+	fmt.Fprintf(&buf, "Subject: %s\r\n", mime.BEncoding.Encode("utf-8", msg.Subject))
+	fmt.Fprintf(&buf, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	buf.WriteString("MIME-Version: 1.0\r\n")
+	fmt.Fprintf(&buf, "Content-Type: multipart/mixed; boundary=%q\r\n\r\n", mixed)
+
+	fmt.Fprintf(&buf, "--%s\r\nContent-Type: multipart/alternative; boundary=%q\r\n\r\n", mixed, alternative)
+	writePart(&buf, alternative, "text/plain; charset=utf-8", "", []byte(msg.Text))
+	if msg.HTML != "" {
+		writePart(&buf, alternative, "text/html; charset=utf-8", "", []byte(msg.HTML))
+	}
+	fmt.Fprintf(&buf, "--%s--\r\n", alternative)
+
+	for _, a := range msg.Attachments {
+		disposition := fmt.Sprintf("attachment; filename=%q", mime.BEncoding.Encode("utf-8", a.Name))
+		writePart(&buf, mixed, a.ContentType, disposition, a.Data)
+	}
+	fmt.Fprintf(&buf, "--%s--\r\n", mixed)
+	return buf.Bytes(), nil
+}
+
+// writePart пишет часть письма в base64 со строками по 76 символов
+func writePart(buf *bytes.Buffer, boundary, contentType, disposition string, data []byte) {
+	fmt.Fprintf(buf, "--%s\r\nContent-Type: %s\r\nContent-Transfer-Encoding: base64\r\n", boundary, contentType)
+	if disposition != "" {
+		fmt.Fprintf(buf, "Content-Disposition: %s\r\n", disposition)
+	}
+	buf.WriteString("\r\n")
+	encoded := base64.StdEncoding.EncodeToString(data)
+	for len(encoded) > 76 {
+		buf.WriteString(encoded[:76] + "\r\n")
+		encoded = encoded[76:]
+	}
+	buf.WriteString(encoded + "\r\n")
+}
+
+func boundary() (string, error) {
+	b := make([]byte, 12)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return "texnousta-" + hex.EncodeToString(b), nil
+}
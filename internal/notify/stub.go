@@ -0,0 +1,65 @@
+package notify
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+)
+
+// StubNotifier ничего не отправляет: запоминает уведомления и, если задан
+// каталог, сохраняет каждое в файлы (текст, HTML и вложения). Нужен для
+// проверки рассылок локально и в тестах.
+type StubNotifier struct {
+	Dir string
+
+	mu       sync.Mutex
+	messages []Message
+}
+
+// unsafeFileChars - символы, которые нельзя оставлять в имени файла
+var unsafeFileChars = regexp.MustCompile(`[^a-zA-Z0-9._-]+`)
+
+// Send сохраняет уведомление
+func (n *StubNotifier) Send(_ context.Context, msg Message) error {
+	n.mu.Lock()
+	n.messages = append(n.messages, msg)
+	seq := len(n.messages)
+	n.mu.Unlock()
+
+	if n.Dir == "" {
+		return nil
+	}
+	if err := os.MkdirAll(n.Dir, 0o755); err != nil {
+		return err
+	}
+
+	prefix := fmt.Sprintf("%s-%03d-%s", time.Now().Format("20060102-150405"), seq, msg.Channel)
+	text := fmt.Sprintf("Channel: %s\nTo: %s\nSubject: %s\n\n%s\n", msg.Channel, msg.To, msg.Subject, msg.Text)
+	if err := os.WriteFile(filepath.Join(n.Dir, prefix+".txt"), []byte(text), 0o644); err != nil {
+		return err
+	}
+	if msg.HTML != "" {
+		if err := os.WriteFile(filepath.Join(n.Dir, prefix+".html"), []byte(msg.HTML), 0o644); err != nil {
+			return err
+		}
+	}
+	for _, a := range msg.Attachments {
+		name := strings.Trim(unsafeFileChars.ReplaceAllString(a.Name, "_"), "_")
+		if err := os.WriteFile(filepath.Join(n.Dir, prefix+"-"+name), a.Data, 0o644); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Messages - сохраненные уведомления
+func (n *StubNotifier) Messages() []Message {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	return append([]Message(nil), n.messages...)
+}
@@ -0,0 +1,119 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"strings"
+	"unicode/utf8"
+)
+
+// telegramAPI - адрес Bot API по умолчанию
+const telegramAPI = "https://api.telegram.org"
+
+// Ограничения Bot API на длину текста
+const (
+	telegramTextLimit    = 4096
+	telegramCaptionLimit = 1024
+)
+
+// TelegramNotifier отправляет сообщения боту Telegram. Получатель - chat_id
+// (число или @канал). Текст уходит сообщением, вложения - документами.
+type TelegramNotifier struct {
+	Token  string
+	APIURL string // по умолчанию https://api.telegram.org
+	Client *http.Client
+}
+
+// Send отправляет сообщение и вложения
+func (n TelegramNotifier) Send(ctx context.Context, msg Message) error {
+	if msg.To == "" {
+		return fmt.Errorf("не указан чат Telegram")
+	}
+
+	text := msg.Text
+	if msg.Subject != "" {
+		text = msg.Subject + "\n\n" + text
+	}
+	if err := n.call(ctx, "sendMessage", map[string]string{
+		"chat_id": msg.To,
+		"text":    limitRunes(text, telegramTextLimit),
+	}, nil); err != nil {
+		return err
+	}
+
+	for _, a := range msg.Attachments {
+		if err := n.call(ctx, "sendDocument", map[string]string{
+			"chat_id": msg.To,
+			"caption": limitRunes(msg.Subject, telegramCaptionLimit),
+		}, &a); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// call вызывает метод Bot API; с вложением запрос отправляется как multipart
+func (n TelegramNotifier) call(ctx context.Context, method string, fields map[string]string, file *Attachment) error {
+	var body bytes.Buffer
+	writer := multipart.NewWriter(&body)
+	for k, v := range fields {
+		if err := writer.WriteField(k, v); err != nil {
+			return err
+		}
+	}
+	if file != nil {
+		part, err := writer.CreateFormFile("document", file.Name)
+		if err != nil {
+			return err
+		}
+		if _, err := part.Write(file.Data); err != nil {
+			return err
+		}
+	}
+	if err := writer.Close(); err != nil {
+		return err
+	}
+
+	api := n.APIURL
+	if api == "" {
+		api = telegramAPI
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, strings.TrimRight(api, "/")+"/bot"+n.Token+"/"+method, &body)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", writer.FormDataContentType())
+
+	client := n.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	var result struct {
+		OK          bool   `json:"ok"`
+		Description string `json:"description"`
+	}
+	data, _ := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err := json.Unmarshal(data, &result); err != nil || !result.OK {
+		return fmt.Errorf("telegram %s: HTTP %d: %s", method, resp.StatusCode, result.Description)
+	}
+	return nil
+}
+
+func limitRunes(s string, limit int) string {
+	if utf8.RuneCountInString(s) <= limit {
+		return s
+	}
+	runes := []rune(s)
+	return string(runes[:limit-1]) + "…"
+}
@@ -0,0 +1,115 @@
+package promotions
+
+import (
+	"time"
+
+	"texnousta-backend/internal/models"
+)
+
+// CartLine - позиция корзины для расчета цены при оформлении заказа
+type CartLine struct {
+	Product  models.Product
+	Quantity int
+}
+
+// PricedLine - позиция корзины с рассчитанной ценой.
+// Если к части единиц применилась акция-комплект, позиция разбивается на две.
+type PricedLine struct {
+	Product   models.Product
+	Quantity  int
+	BasePrice float64
+	UnitPrice float64
+	Promotion *models.AppliedPromotion
+}
+
+// PriceCart рассчитывает цены позиций корзины с учетом всех действующих акций
+func PriceCart(lines []CartLine) ([]PricedLine, float64, error) {
+	now := time.Now()
+	promos, err := Active(now)
+	if err != nil {
+		return nil, 0, err
+	}
+	priced := priceCart(lines, promos, now)
+
+	var total float64
+	for _, line := range priced {
+		total += line.UnitPrice * float64(line.Quantity)
+	}
+	return priced, round(total), nil
+}
+
+func priceCart(lines []CartLine, promos []models.Promotion, now time.Time) []PricedLine {
+	// Количество единиц товара по категориям - для проверки условий комплекта
+	inCategory := make(map[uint]int)
+	for _, line := range lines {
+		inCategory[line.Product.CategoryID] += line.Quantity
+	}
+
+	// Сколько единиц каждой акции-комплекта еще можно выдать:
+	// одна единица со скидкой на каждую единицу товара-условия
+	bundleLeft := make(map[uint]int)
+	for _, promo := range promos {
+		if promo.Scope == ScopeBundle && promo.TriggerCategoryID != nil {
+			bundleLeft[promo.ID] = inCategory[*promo.TriggerCategoryID]
+		}
+	}
+
+	var priced []PricedLine
+	for _, line := range lines {
+		product := line.Product
+		apply(&product, promos, now)
+
+		regular := PricedLine{
+			Product:   product,
+			Quantity:  line.Quantity,
+			BasePrice: product.Price,
+			UnitPrice: product.FinalPrice,
+			Promotion: product.Promotion,
+		}
+
+		bundle, discount := bestBundle(&line.Product, promos, bundleLeft)
+		if bundle == nil || product.Price-discount >= product.FinalPrice {
+			priced = append(priced, regular)
+			continue
+		}
+
+		qty := line.Quantity
+		if qty > bundleLeft[bundle.ID] {
+			qty = bundleLeft[bundle.ID]
+		}
+		bundleLeft[bundle.ID] -= qty
+
+		priced = append(priced, PricedLine{
+			Product:   product,
+			Quantity:  qty,
+			BasePrice: product.Price,
+			UnitPrice: round(product.Price - discount),
+			Promotion: describe(bundle, discount, now),
+		})
+		if rest := line.Quantity - qty; rest > 0 {
+			regular.Quantity = rest
+			priced = append(priced, regular)
+		}
+	}
+	return priced
+}
+
+// bestBundle выбирает акцию-комплект с максимальной скидкой, условие которой выполнено
+func bestBundle(product *models.Product, promos []models.Promotion, left map[uint]int) (*models.Promotion, float64) {
+	var best *models.Promotion
+	var bestDiscount float64
+	for i := range promos {
+		promo := &promos[i]
+		if promo.Scope != ScopeBundle || left[promo.ID] <= 0 || !matches(promo, product) {
+			continue
+		}
+		// Товар не может быть условием комплекта для самого себя
+		if promo.TriggerCategoryID != nil && *promo.TriggerCategoryID == product.CategoryID {
+			continue
+		}
+		if d := discountFor(promo, product.Price); d > bestDiscount {
+			best, bestDiscount = promo, d
+		}
+	}
+	return best, bestDiscount
+}
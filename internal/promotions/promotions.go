@@ -0,0 +1,139 @@
+// Package promotions вычисляет цены товаров с учетом акций.
+//
+// Правила акций хранятся в таблице promotions и применяются дважды:
+// при выдаче каталога (GetProducts/GetProduct) и при оформлении заказа.
+// Из нескольких подходящих правил выбирается то, что дает наибольшую скидку.
+package promotions
+
+import (
+	"math"
+	"time"
+
+	"texnousta-backend/internal/database"
+	"texnousta-backend/internal/models"
+)
+
+// Типы скидок
+const (
+	TypePercent = "percent"
+	TypeFixed   = "fixed"
+)
+
+// Области действия акций
+const (
+	ScopeAll      = "all"
+	ScopeCategory = "category"
+	ScopeBrand    = "brand"
+	ScopeProduct  = "product"
+	ScopeBundle   = "bundle"
+)
+
+// Active загружает акции, действующие в момент now
+func Active(now time.Time) ([]models.Promotion, error) {
+	var promos []models.Promotion
+	err := database.DB.
+		Where("is_active = ?", true).
+		Where("starts_at IS NULL OR starts_at <= ?", now).
+		Where("ends_at IS NULL OR ends_at > ?", now).
+		Find(&promos).Error
+	return promos, err
+}
+
+// ApplyToProducts заполняет FinalPrice и Promotion у списка товаров.
+// Если акции загрузить не удалось, товары отдаются по базовой цене.
+func ApplyToProducts(products []models.Product) {
+	now := time.Now()
+	promos, _ := Active(now)
+	for i := range products {
+		apply(&products[i], promos, now)
+	}
+}
+
+// ApplyToProduct заполняет FinalPrice и Promotion у одного товара
+func ApplyToProduct(product *models.Product) {
+	now := time.Now()
+	promos, _ := Active(now)
+	apply(product, promos, now)
+}
+
+func apply(product *models.Product, promos []models.Promotion, now time.Time) {
+	product.FinalPrice = product.Price
+	product.Promotion = nil
+
+	best, discount := bestPromotion(product, promos)
+	if best == nil {
+		return
+	}
+	product.FinalPrice = round(product.Price - discount)
+	product.Promotion = describe(best, discount, now)
+}
+
+// bestPromotion выбирает акцию с максимальной скидкой для товара.
+// Акции-комплекты здесь не учитываются: они зависят от состава корзины.
+func bestPromotion(product *models.Product, promos []models.Promotion) (*models.Promotion, float64) {
+	var best *models.Promotion
+	var bestDiscount float64
+	for i := range promos {
+		promo := &promos[i]
+		if promo.Scope == ScopeBundle || !matches(promo, product) {
+			continue
+		}
+		if d := discountFor(promo, product.Price); d > bestDiscount {
+			best, bestDiscount = promo, d
+		}
+	}
+	return best, bestDiscount
+}
+
+// matches проверяет, попадает ли товар под область действия акции
+func matches(promo *models.Promotion, product *models.Product) bool {
+	switch promo.Scope {
+	case ScopeAll:
+		return true
+	case ScopeCategory, ScopeBundle:
+		return promo.CategoryID != nil && *promo.CategoryID == product.CategoryID
+	case ScopeBrand:
+		return promo.Brand != "" && promo.Brand == product.Brand
+	case ScopeProduct:
+		return promo.ProductID != nil && *promo.ProductID == product.ID
+	}
+	return false
+}
+
+// discountFor возвращает размер скидки на единицу товара, не больше самой цены
+func discountFor(promo *models.Promotion, price float64) float64 {
+	var d float64
+	switch promo.Type {
+	case TypePercent:
+		d = price * promo.Value / 100
+	case TypeFixed:
+		d = promo.Value
+	}
+	if d > price {
+		d = price
+	}
+	if d < 0 {
+		d = 0
+	}
+	return round(d)
+}
+
+func describe(promo *models.Promotion, discount float64, now time.Time) *models.AppliedPromotion {
+	applied := &models.AppliedPromotion{
+		ID:       promo.ID,
+		Name:     promo.Name,
+		Type:     promo.Type,
+		Value:    promo.Value,
+		Discount: discount,
+		IsFlash:  promo.IsFlash,
+		EndsAt:   promo.EndsAt,
+	}
+	if promo.IsFlash && promo.EndsAt != nil {
+		applied.EndsIn = int64(promo.EndsAt.Sub(now).Seconds())
+	}
+	return applied
+}
+
+func round(v float64) float64 {
+	return math.Round(v*100) / 100
+}
@@ -0,0 +1,92 @@
+// Package realtime рассылает события сайта подключенным администраторам.
+//
+// Hub - простая шина публикации/подписки внутри процесса: обработчики
+// публикуют события, а каждое открытое SSE-соединение получает их через свой
+// канал. Медленный подписчик не задерживает остальных: если его буфер
+// заполнен, событие для него пропускается.
+package realtime
+
+import (
+	"sync"
+	"time"
+)
+
+// Типы событий
+const (
+	EventVisit        = "visit"
+	EventPhoneClick   = "phone_click"
+	EventContact      = "contact"
+	EventQuickContact = "quick_contact"
+	EventPhoneContact = "phone_contact"
+)
+
+// subscriberBuffer - сколько событий может ждать отправки одному подписчику
+const subscriberBuffer = 64
+
+// Event - событие для администраторов
+type Event struct {
+	ID   uint64      `json:"id"`
+	Type string      `json:"type"`
+	Time time.Time   `json:"time"`
+	Data interface{} `json:"data"`
+}
+
+// Hub рассылает события всем подписчикам
+type Hub struct {
+	mu          sync.RWMutex
+	subscribers map[chan Event]struct{}
+	lastID      uint64
+}
+
+// NewHub создает шину событий
+func NewHub() *Hub {
+	return &Hub{subscribers: make(map[chan Event]struct{})}
+}
+
+// Default - шина событий сервера
+var Default = NewHub()
+
+// Subscribe подписывает на события. Возвращенную функцию нужно вызвать
+// при отключении клиента.
+func (h *Hub) Subscribe() (<-chan Event, func()) {
+	ch := make(chan Event, subscriberBuffer)
+
+	h.mu.Lock()
+	h.subscribers[ch] = struct{}{}
+	h.mu.Unlock()
+
+	var once sync.Once
+	return ch, func() {
+		once.Do(func() {
+			h.mu.Lock()
+			delete(h.subscribers, ch)
+			h.mu.Unlock()
+		})
+	}
+}
+
+// Publish отправляет событие всем подписчикам, не дожидаясь медленных
+func (h *Hub) Publish(eventType string, data interface{}) {
+	h.mu.Lock()
+	h.lastID++
+	event := Event{ID: h.lastID, Type: eventType, Time: time.Now(), Data: data}
+	for ch := range h.subscribers {
+		select {
+		case ch <- event:
+		default:
+		}
+	}
+	h.mu.Unlock()
+}
+
+// Subscribers - число подключенных клиентов
+func (h *Hub) Subscribers() int {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	return len(h.subscribers)
+}
+
+// Publish отправляет событие через шину по умолчанию
+func Publish(eventType string, data interface{}) {
+	Default.Publish(eventType, data)
+}
@@ -0,0 +1,147 @@
+// Package recommend рассчитывает рекомендации товаров.
+//
+// "Часто покупают вместе" - пары товаров из одних заказов, "С этим товаром
+// смотрят" - пары товаров, просмотренных в одной сессии. Пары пересчитываются
+// периодически и хранятся в models.ProductRelation. Если истории мало,
+// список дополняется товарами той же категории и бренда в близком ценовом
+// диапазоне.
+package recommend
+
+import (
+	"log"
+	"os"
+	"sort"
+	"time"
+
+	"texnousta-backend/internal/models"
+
+	"gorm.io/gorm"
+)
+
+const (
+	// KindBoughtTogether - товары из одних заказов
+	KindBoughtTogether = "bought_together"
+	// KindViewedTogether - товары, просмотренные в одной сессии
+	KindViewedTogether = "viewed_together"
+)
+
+const (
+	// maxPerProduct - сколько связей хранить для одного товара
+	maxPerProduct = 20
+	// minViewSessions - минимум общих сессий, чтобы связь по просмотрам считалась
+	minViewSessions = 2
+	// viewWindow - за какой период учитываются просмотры
+	viewWindow = 90 * 24 * time.Hour
+	// priceBand - допустимое отклонение цены похожего товара
+	priceBand = 0.3
+)
+
+// pair - строка результата подсчета пар
+type pair struct {
+	ProductID uint
+	RelatedID uint
+	Score     float64
+}
+
+// Refresh пересчитывает таблицу связей товаров
+func Refresh(db *gorm.DB) error {
+	var bought []pair
+	if err := db.Raw(`SELECT a.product_id AS product_id, b.product_id AS related_id, COUNT(DISTINCT a.order_id) AS score
+		FROM order_items a
+		JOIN order_items b ON b.order_id = a.order_id AND b.product_id <> a.product_id
+		JOIN orders o ON o.id = a.order_id
+		WHERE o.status <> ?
+		GROUP BY a.product_id, b.product_id`, "cancelled").
+		Scan(&bought).Error; err != nil {
+		return err
+	}
+
+	var viewed []pair
+	since := time.Now().Add(-viewWindow)
+	if err := db.Raw(`SELECT a.product_id AS product_id, b.product_id AS related_id, COUNT(DISTINCT a.session_id) AS score
+		FROM product_views a
+		JOIN product_views b ON b.session_id = a.session_id AND b.product_id <> a.product_id
+		WHERE a.session_id <> '' AND a.created_at >= ? AND b.created_at >= ?
+		GROUP BY a.product_id, b.product_id
+		HAVING COUNT(DISTINCT a.session_id) >= ?`, since, since, minViewSessions).
+		Scan(&viewed).Error; err != nil {
+		return err
+	}
+
+	now := time.Now()
+	var relations []models.ProductRelation
+	relations = append(relations, top(bought, KindBoughtTogether, now)...)
+	relations = append(relations, top(viewed, KindViewedTogether, now)...)
+
+	return db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("1 = 1").Delete(&models.ProductRelation{}).Error; err != nil {
+			return err
+		}
+		if len(relations) == 0 {
+			return nil
+		}
+		return tx.CreateInBatches(relations, 500).Error
+	})
+}
+
+// top оставляет для каждого товара maxPerProduct самых сильных связей
+func top(pairs []pair, kind string, now time.Time) []models.ProductRelation {
+	sort.Slice(pairs, func(i, j int) bool {
+		if pairs[i].ProductID != pairs[j].ProductID {
+			return pairs[i].ProductID < pairs[j].ProductID
+		}
+		if pairs[i].Score != pairs[j].Score {
+			return pairs[i].Score > pairs[j].Score
+		}
+		return pairs[i].RelatedID < pairs[j].RelatedID
+	})
+
+	var relations []models.ProductRelation
+	count := 0
+	for i, p := range pairs {
+		if i == 0 || p.ProductID != pairs[i-1].ProductID {
+			count = 0
+		}
+		if count >= maxPerProduct {
+			continue
+		}
+		count++
+		relations = append(relations, models.ProductRelation{
+			ProductID: p.ProductID,
+			RelatedID: p.RelatedID,
+			Kind:      kind,
+			Score:     p.Score,
+			UpdatedAt: now,
+		})
+	}
+	return relations
+}
+
+// StartRefresher пересчитывает связи сразу и затем с интервалом
+// RECOMMEND_REFRESH_INTERVAL (по умолчанию раз в час, "0" - отключить)
+func StartRefresher(db *gorm.DB) {
+	interval := time.Hour
+	if v := os.Getenv("RECOMMEND_REFRESH_INTERVAL"); v != "" {
+		d, err := time.ParseDuration(v)
+		if err != nil {
+			log.Printf("Некорректный RECOMMEND_REFRESH_INTERVAL %q, используется %s", v, interval)
+		} else {
+			interval = d
+		}
+	}
+	if interval <= 0 {
+		return
+	}
+
+	go func() {
+		for {
+			started := time.Now()
+			if err := Refresh(db); err != nil {
+				log.Printf("Ошибка пересчета рекомендаций: %v", err)
+			} else {
+				log.Printf("Рекомендации пересчитаны за %s", time.Since(started).Round(time.Millisecond))
+			}
+			time.Sleep(interval)
+		}
+	}()
+}
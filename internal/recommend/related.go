@@ -0,0 +1,100 @@
+package recommend
+
+import (
+	"texnousta-backend/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// Result - рекомендации для карточки товара
+type Result struct {
+	BoughtTogether []models.Product `json:"bought_together"`
+	Related        []models.Product `json:"related"`
+}
+
+// Related возвращает рекомендации для товара: "часто покупают вместе"
+// и "похожие" (по просмотрам, дополненные товарами той же категории).
+// Цены акций не применяются - это делает вызывающий код.
+func Related(db *gorm.DB, product *models.Product, limit int) (*Result, error) {
+	bought, err := related(db, product.ID, KindBoughtTogether, limit, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	exclude := []uint{product.ID}
+	for _, p := range bought {
+		exclude = append(exclude, p.ID)
+	}
+	viewed, err := related(db, product.ID, KindViewedTogether, limit, exclude)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &Result{BoughtTogether: bought, Related: viewed}
+	if len(result.Related) < limit {
+		for _, p := range viewed {
+			exclude = append(exclude, p.ID)
+		}
+		similar, err := Similar(db, product, limit-len(result.Related), exclude)
+		if err != nil {
+			return nil, err
+		}
+		result.Related = append(result.Related, similar...)
+	}
+	return result, nil
+}
+
+// related - активные товары из предрассчитанных связей по убыванию силы связи
+func related(db *gorm.DB, productID uint, kind string, limit int, exclude []uint) ([]models.Product, error) {
+	query := db.Model(&models.Product{}).
+		Joins("JOIN product_relations ON product_relations.related_id = products.id").
+		Where("product_relations.product_id = ? AND product_relations.kind = ?", productID, kind).
+		Where("products.is_active = ?", true)
+	if len(exclude) > 0 {
+		query = query.Where("products.id NOT IN ?", exclude)
+	}
+
+	var products []models.Product
+	err := query.Preload("Category").
+		Order("product_relations.score DESC, products.id ASC").
+		Limit(limit).
+		Find(&products).Error
+	return products, err
+}
+
+// Similar подбирает товары той же категории в ценовом диапазоне ±30%:
+// сначала того же бренда, затем остальные, ближайшие по цене - первыми
+func Similar(db *gorm.DB, product *models.Product, limit int, exclude []uint) ([]models.Product, error) {
+	if limit <= 0 {
+		return nil, nil
+	}
+
+	base := func() *gorm.DB {
+		query := db.Model(&models.Product{}).
+			Where("is_active = ? AND category_id = ?", true, product.CategoryID).
+			Where("price BETWEEN ? AND ?", product.Price*(1-priceBand), product.Price*(1+priceBand)).
+			Where("id NOT IN ?", append([]uint{product.ID}, exclude...))
+		return query.Preload("Category").
+			Order(gorm.Expr("ABS(price - ?) ASC, id ASC", product.Price)).
+			Limit(limit)
+	}
+
+	var products []models.Product
+	if product.Brand != "" {
+		if err := base().Where("brand = ?", product.Brand).Find(&products).Error; err != nil {
+			return nil, err
+		}
+		for _, p := range products {
+			exclude = append(exclude, p.ID)
+		}
+	}
+
+	if len(products) < limit {
+		var others []models.Product
+		if err := base().Limit(limit - len(products)).Find(&others).Error; err != nil {
+			return nil, err
+		}
+		products = append(products, others...)
+	}
+	return products, nil
+}
@@ -0,0 +1,263 @@
+package reports
+
+import (
+	"bytes"
+	"fmt"
+	"html/template"
+	"math"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/jung-kurt/gofpdf"
+)
+
+// Row - строка таблицы отчета
+type Row struct {
+	Label    string
+	Current  string
+	Previous string
+	Change   string // изменение к предыдущему периоду
+}
+
+// Rows - показатели отчета для вывода
+func (r *Report) Rows() []Row {
+	cur, prev := r.Current, r.Previous
+	count := func(label string, c, p int64) Row {
+		return Row{label, formatInt(c), formatInt(p), change(float64(c), float64(p))}
+	}
+	money := func(label string, c, p float64) Row {
+		return Row{label, r.formatMoney(c), r.formatMoney(p), change(c, p)}
+	}
+
+	return []Row{
+		count("Посетители", cur.Visitors, prev.Visitors),
+		count("Просмотры страниц", cur.PageViews, prev.PageViews),
+		count("Клики по телефону", cur.PhoneClicks, prev.PhoneClicks),
+		count("Новые обращения", cur.Contacts, prev.Contacts),
+		count("  в т.ч. оставили телефон", cur.PhoneContacts, prev.PhoneContacts),
+		count("Заказы", cur.Orders, prev.Orders),
+		money("Выручка", cur.Revenue, prev.Revenue),
+		money("Средний чек", average(cur.Revenue, cur.Orders), average(prev.Revenue, prev.Orders)),
+		{
+			"Конверсия в заказ",
+			formatPercent(rate(cur.Orders, cur.Visitors)),
+			formatPercent(rate(prev.Orders, prev.Visitors)),
+			change(rate(cur.Orders, cur.Visitors), rate(prev.Orders, prev.Visitors)),
+		},
+	}
+}
+
+// Text - отчет простым текстом (для Telegram и текстовой версии письма)
+func (r *Report) Text() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Период: %s\n\n", r.PeriodLabel())
+	for _, row := range r.Rows() {
+		fmt.Fprintf(&b, "%s: %s (было %s, %s)\n", strings.TrimSpace(row.Label), row.Current, row.Previous, row.Change)
+	}
+	return b.String()
+}
+
+var htmlTemplate = template.Must(template.New("report").Parse(`<!DOCTYPE html>
+<html lang="ru">
+<head><meta charset="utf-8"><title>{{.Title}}</title></head>
+<body style="font-family: Arial, sans-serif; color: #222;">
+<h2 style="margin-bottom: 4px;">{{.Title}}</h2>
+<p style="color: #666; margin-top: 0;">Период: {{.Period}}. Сравнение с предыдущим периодом.</p>
+<table cellpadding="8" cellspacing="0" style="border-collapse: collapse; min-width: 480px;">
+<tr style="background: #f0f0f0; text-align: left;">
+<th style="border: 1px solid #ddd;">Показатель</th>
+<th style="border: 1px solid #ddd; text-align: right;">За период</th>
+<th style="border: 1px solid #ddd; text-align: right;">Предыдущий</th>
+<th style="border: 1px solid #ddd; text-align: right;">Изменение</th>
+</tr>
+{{range .Rows}}<tr>
+<td style="border: 1px solid #ddd;">{{.Label}}</td>
+<td style="border: 1px solid #ddd; text-align: right;"><b>{{.Current}}</b></td>
+<td style="border: 1px solid #ddd; text-align: right; color: #666;">{{.Previous}}</td>
+<td style="border: 1px solid #ddd; text-align: right;">{{.Change}}</td>
+</tr>
+{{end}}</table>
+<p style="color: #999; font-size: 12px;">Посетители и клики - без ботов. Сформирован {{.Generated}}.</p>
+</body>
+</html>
+`))
+
+// HTML - отчет в HTML для письма
+func (r *Report) HTML() (string, error) {
+	var buf bytes.Buffer
+	err := htmlTemplate.Execute(&buf, map[string]interface{}{
+		"Title":     r.Title(),
+		"Period":    r.PeriodLabel(),
+		"Rows":      r.Rows(),
+		"Generated": r.GeneratedAt.Format("02.01.2006 15:04"),
+	})
+	return buf.String(), err
+}
+
+// fontCandidates - где искать TrueType-шрифт с кириллицей, если REPORT_PDF_FONT не задан
+var fontCandidates = []string{
+	"/usr/share/fonts/truetype/dejavu/DejaVuSans.ttf",
+	"/usr/share/fonts/TTF/DejaVuSans.ttf",
+	"/usr/share/fonts/dejavu/DejaVuSans.ttf",
+	"/Library/Fonts/Arial Unicode.ttf",
+	"C:\\Windows\\Fonts\\arial.ttf",
+}
+
+// pdfFont - шрифт с кириллицей (REPORT_PDF_FONT или системный). Полужирный
+// ищется рядом с суффиксом -Bold. Пустой путь - шрифта нет.
+func pdfFont() (regular, bold string) {
+	candidates := fontCandidates
+	if path := os.Getenv("REPORT_PDF_FONT"); path != "" {
+		candidates = []string{path}
+	}
+	for _, path := range candidates {
+		if _, err := os.Stat(path); err != nil {
+			continue
+		}
+		bold = path
+		if b := strings.TrimSuffix(path, ".ttf") + "-Bold.ttf"; b != path {
+			if _, err := os.Stat(b); err == nil {
+				bold = b
+			}
+		}
+		return path, bold
+	}
+	return "", ""
+}
+
+// PDF - отчет в PDF. Без шрифта с кириллицей текст выводится транслитом
+// встроенным шрифтом Helvetica.
+func (r *Report) PDF() ([]byte, error) {
+	// gofpdf ищет файлы шрифтов относительно своей папки шрифтов
+	regular, bold := pdfFont()
+	pdf := gofpdf.New("P", "mm", "A4", filepath.Dir(regular))
+	pdf.SetTitle(r.Title(), true)
+	pdf.SetCreator(r.Shop, true)
+
+	family, text := "Helvetica", transliterate
+	if regular != "" {
+		family, text = "Report", func(s string) string { return s }
+		pdf.AddUTF8Font(family, "", filepath.Base(regular))
+		pdf.AddUTF8Font(family, "B", filepath.Base(bold))
+	}
+
+	pdf.AddPage()
+	pdf.SetFont(family, "B", 16)
+	pdf.MultiCell(0, 8, text(r.Title()), "", "L", false)
+	pdf.SetFont(family, "", 10)
+	pdf.SetTextColor(100, 100, 100)
+	pdf.CellFormat(0, 8, text("Период: "+r.PeriodLabel()+". Сравнение с предыдущим периодом."), "", 1, "L", false, 0, "")
+	pdf.Ln(4)
+
+	widths := []float64{70, 40, 40, 30}
+	pdf.SetTextColor(0, 0, 0)
+	pdf.SetFillColor(240, 240, 240)
+	pdf.SetFont(family, "B", 10)
+	for i, title := range []string{"Показатель", "За период", "Предыдущий", "Изменение"} {
+		align := "R"
+		if i == 0 {
+			align = "L"
+		}
+		pdf.CellFormat(widths[i], 8, text(title), "1", 0, align, true, 0, "")
+	}
+	pdf.Ln(-1)
+
+	pdf.SetFont(family, "", 10)
+	for _, row := range r.Rows() {
+		pdf.CellFormat(widths[0], 8, text(row.Label), "1", 0, "L", false, 0, "")
+		pdf.CellFormat(widths[1], 8, text(row.Current), "1", 0, "R", false, 0, "")
+		pdf.CellFormat(widths[2], 8, text(row.Previous), "1", 0, "R", false, 0, "")
+		pdf.CellFormat(widths[3], 8, text(row.Change), "1", 1, "R", false, 0, "")
+	}
+
+	pdf.Ln(4)
+	pdf.SetFont(family, "", 8)
+	pdf.SetTextColor(150, 150, 150)
+	pdf.CellFormat(0, 6, text("Посетители и клики - без ботов. Сформирован "+r.GeneratedAt.Format("02.01.2006 15:04")+"."), "", 1, "L", false, 0, "")
+
+	var buf bytes.Buffer
+	if err := pdf.Output(&buf); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+var translitTable = map[rune]string{
+	'а': "a", 'б': "b", 'в': "v", 'г': "g", 'д': "d", 'е': "e", 'ё': "yo", 'ж': "zh", 'з': "z", 'и': "i",
+	'й': "y", 'к': "k", 'л': "l", 'м': "m", 'н': "n", 'о': "o", 'п': "p", 'р': "r", 'с': "s", 'т': "t",
+	'у': "u", 'ф': "f", 'х': "kh", 'ц': "ts", 'ч': "ch", 'ш': "sh", 'щ': "shch", 'ъ': "", 'ы': "y", 'ь': "",
+	'э': "e", 'ю': "yu", 'я': "ya", 'ў': "o'", 'қ': "q", 'ғ': "g'", 'ҳ': "h", '—': "-", '«': "\"", '»': "\"",
+}
+
+// transliterate заменяет кириллицу латиницей для встроенного шрифта PDF
+func transliterate(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		lower := []rune(strings.ToLower(string(r)))[0]
+		latin, ok := translitTable[lower]
+		switch {
+		case !ok && r < 128:
+			b.WriteRune(r)
+		case !ok:
+			b.WriteByte('?')
+		case lower != r && latin != "":
+			b.WriteString(strings.ToUpper(latin[:1]) + latin[1:])
+		default:
+			b.WriteString(latin)
+		}
+	}
+	return b.String()
+}
+
+// formatInt - число с пробелами между разрядами: 1 234 567
+func formatInt(n int64) string {
+	s := strconv.FormatInt(n, 10)
+	sign := ""
+	if n < 0 {
+		sign, s = "-", s[1:]
+	}
+	for i := len(s) - 3; i > 0; i -= 3 {
+		s = s[:i] + " " + s[i:]
+	}
+	return sign + s
+}
+
+func (r *Report) formatMoney(v float64) string {
+	return formatInt(int64(math.Round(v))) + " " + r.Currency
+}
+
+func formatPercent(v float64) string {
+	return strconv.FormatFloat(v, 'f', 2, 64) + "%"
+}
+
+// change - изменение к предыдущему значению в процентах
+func change(cur, prev float64) string {
+	if prev == 0 {
+		if cur == 0 {
+			return "0%"
+		}
+		return "новое"
+	}
+	diff := (cur - prev) / prev * 100
+	sign := ""
+	if diff > 0 {
+		sign = "+"
+	}
+	return sign + strconv.FormatFloat(diff, 'f', 1, 64) + "%"
+}
+
+func rate(part, total int64) float64 {
+	if total == 0 {
+		return 0
+	}
+	return float64(part) / float64(total) * 100
+}
+
+func average(sum float64, n int64) float64 {
+	if n == 0 {
+		return 0
+	}
+	return sum / float64(n)
+}
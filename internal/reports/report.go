@@ -0,0 +1,179 @@
+// Package reports собирает сводный отчет о работе магазина за период
+// (посещения, клики по телефону, обращения, заказы и выручка), оформляет
+// его в HTML и PDF и рассылает по расписанию из базы данных.
+package reports
+
+import (
+	"fmt"
+	"time"
+
+	"texnousta-backend/internal/analytics"
+	"texnousta-backend/internal/config"
+	"texnousta-backend/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// Периоды отчета
+const (
+	PeriodDay   = "day"
+	PeriodWeek  = "week"
+	PeriodMonth = "month"
+)
+
+// Metrics - показатели за период
+type Metrics struct {
+	Visitors      int64   `json:"visitors"`       // уникальные посетители (за месяц - по месяцу, иначе сумма по дням)
+	PageViews     int64   `json:"page_views"`     // просмотры страниц
+	PhoneClicks   int64   `json:"phone_clicks"`   // клики по телефону
+	Contacts      int64   `json:"contacts"`       // новые обращения
+	PhoneContacts int64   `json:"phone_contacts"` // из них оставили только телефон
+	Orders        int64   `json:"orders"`         // заказы, кроме отмененных
+	Revenue       float64 `json:"revenue"`        // сумма заказов, кроме отмененных
+}
+
+// Report - отчет за период и такой же предыдущий период для сравнения
+type Report struct {
+	Shop        string    `json:"shop"`
+	Period      string    `json:"period"`
+	From        time.Time `json:"from"`
+	To          time.Time `json:"to"` // не включая
+	Current     Metrics   `json:"current"`
+	Previous    Metrics   `json:"previous"`
+	Currency    string    `json:"currency"`
+	GeneratedAt time.Time `json:"generated_at"`
+}
+
+// Title - заголовок отчета
+func (r *Report) Title() string {
+	names := map[string]string{PeriodDay: "Отчет за день", PeriodWeek: "Отчет за неделю", PeriodMonth: "Отчет за месяц"}
+	return fmt.Sprintf("%s %s: %s", names[r.Period], r.Shop, r.PeriodLabel())
+}
+
+// PeriodLabel - период отчета в виде дат
+func (r *Report) PeriodLabel() string {
+	last := r.To.AddDate(0, 0, -1)
+	if r.Period == PeriodDay {
+		return r.From.Format("02.01.2006")
+	}
+	return r.From.Format("02.01.2006") + " — " + last.Format("02.01.2006")
+}
+
+// PeriodRange - последний завершенный период перед now: вчера, прошлая
+// неделя (с понедельника) или прошлый месяц. to не входит в период.
+func PeriodRange(period string, now time.Time) (from, to time.Time, err error) {
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	switch period {
+	case PeriodDay:
+		return today.AddDate(0, 0, -1), today, nil
+	case PeriodWeek:
+		// Weekday: воскресенье - 0, неделя начинается с понедельника
+		monday := today.AddDate(0, 0, -((int(today.Weekday()) + 6) % 7))
+		return monday.AddDate(0, 0, -7), monday, nil
+	case PeriodMonth:
+		first := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, now.Location())
+		return first.AddDate(0, -1, 0), first, nil
+	}
+	return time.Time{}, time.Time{}, fmt.Errorf("неизвестный период %q", period)
+}
+
+// previousRange - такой же период перед from
+func previousRange(period string, from time.Time) (time.Time, time.Time) {
+	switch period {
+	case PeriodMonth:
+		return from.AddDate(0, -1, 0), from
+	case PeriodWeek:
+		return from.AddDate(0, 0, -7), from
+	}
+	return from.AddDate(0, 0, -1), from
+}
+
+// Build собирает отчет за последний завершенный период перед now
+func Build(db *gorm.DB, period string, now time.Time) (*Report, error) {
+	from, to, err := PeriodRange(period, now)
+	if err != nil {
+		return nil, err
+	}
+	report := &Report{
+		Shop:        config.ShopName(),
+		Period:      period,
+		From:        from,
+		To:          to,
+		Currency:    config.Currency(),
+		GeneratedAt: now,
+	}
+
+	// Сводные данные за период пересчитываются сразу: фоновая задача
+	// обновляет их раз в ANALYTICS_ROLLUP_INTERVAL
+	if err := analytics.RefreshSince(db, from, now); err != nil {
+		return nil, err
+	}
+	if err := collect(db, period, from, to, &report.Current); err != nil {
+		return nil, err
+	}
+	prevFrom, prevTo := previousRange(period, from)
+	if err := collect(db, period, prevFrom, prevTo, &report.Previous); err != nil {
+		return nil, err
+	}
+	return report, nil
+}
+
+// collect считает показатели за [from, to). Посещения и клики берутся из
+// сводных таблиц без ботов, поэтому не зависят от срока хранения сырых записей.
+func collect(db *gorm.DB, period string, from, to time.Time, m *Metrics) error {
+	fromDay, lastDay := from.Format("2006-01-02"), to.AddDate(0, 0, -1).Format("2006-01-02")
+
+	rollups := func(metric, rollupPeriod, fromKey, toKey string) (unique, total int64, err error) {
+		var sums struct {
+			Uniques int64
+			Total   int64
+		}
+		err = db.Model(&models.AnalyticsRollup{}).
+			Select("COALESCE(SUM(unique_human), 0) AS uniques, COALESCE(SUM(total_human), 0) AS total").
+			Where("metric = ? AND period = ? AND period_key BETWEEN ? AND ?", metric, rollupPeriod, fromKey, toKey).
+			Scan(&sums).Error
+		return sums.Uniques, sums.Total, err
+	}
+
+	var err error
+	if period == PeriodMonth {
+		month := from.Format("2006-01")
+		m.Visitors, m.PageViews, err = rollups(analytics.MetricVisitors, analytics.PeriodMonth, month, month)
+	} else {
+		m.Visitors, m.PageViews, err = rollups(analytics.MetricVisitors, analytics.PeriodDay, fromDay, lastDay)
+	}
+	if err != nil {
+		return err
+	}
+	// До появления просмотров страниц учитывался только первый визит за день
+	if m.PageViews < m.Visitors {
+		m.PageViews = m.Visitors
+	}
+	if _, m.PhoneClicks, err = rollups(analytics.MetricPhoneClicks, analytics.PeriodDay, fromDay, lastDay); err != nil {
+		return err
+	}
+
+	if err := db.Model(&models.ContactForm{}).
+		Where("created_at >= ? AND created_at < ?", from, to).
+		Count(&m.Contacts).Error; err != nil {
+		return err
+	}
+	if err := db.Model(&models.PhoneContact{}).
+		Where("created_at >= ? AND created_at < ?", from, to).
+		Count(&m.PhoneContacts).Error; err != nil {
+		return err
+	}
+
+	var orders struct {
+		Count   int64
+		Revenue float64
+	}
+	if err := db.Model(&models.Order{}).
+		Select("COUNT(*) AS count, COALESCE(SUM(total), 0) AS revenue").
+		Where("created_at >= ? AND created_at < ? AND status <> ?", from, to, "cancelled").
+		Scan(&orders).Error; err != nil {
+		return err
+	}
+	m.Orders, m.Revenue = orders.Count, orders.Revenue
+	return nil
+}
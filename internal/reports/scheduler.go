@@ -0,0 +1,125 @@
+package reports
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"texnousta-backend/internal/models"
+	"texnousta-backend/internal/notify"
+
+	"gorm.io/gorm"
+)
+
+// schedulerTick - как часто проверяются расписания (cron с точностью до минуты)
+const schedulerTick = time.Minute
+
+// sendTimeout - ограничение времени на формирование и отправку одного отчета
+const sendTimeout = 2 * time.Minute
+
+// NextRun - следующее время запуска расписания после now
+func NextRun(expr string, now time.Time) (*time.Time, error) {
+	cron, err := ParseCron(expr)
+	if err != nil {
+		return nil, err
+	}
+	next := cron.Next(now)
+	if next.IsZero() {
+		return nil, fmt.Errorf("по расписанию %q нет запусков в ближайшие годы", expr)
+	}
+	return &next, nil
+}
+
+// StartScheduler раз в минуту отправляет отчеты, время которых наступило
+func StartScheduler(db *gorm.DB) {
+	go func() {
+		ticker := time.NewTicker(schedulerTick)
+		defer ticker.Stop()
+		for now := range ticker.C {
+			if err := RunDue(db, now); err != nil {
+				log.Printf("Ошибка запуска отчетов по расписанию: %v", err)
+			}
+		}
+	}()
+}
+
+// RunDue отправляет отчеты, время которых наступило к now
+func RunDue(db *gorm.DB, now time.Time) error {
+	var due []models.ReportSchedule
+	if err := db.Where("is_active = ? AND next_run_at IS NOT NULL AND next_run_at <= ?", true, now).
+		Find(&due).Error; err != nil {
+		return err
+	}
+
+	for _, schedule := range due {
+		next, err := NextRun(schedule.Cron, now)
+		if err != nil {
+			db.Model(&models.ReportSchedule{}).Where("id = ?", schedule.ID).
+				Updates(map[string]interface{}{"next_run_at": nil, "last_error": err.Error()})
+			continue
+		}
+
+		// Время следующего запуска ставится до отправки и условно, чтобы
+		// другой экземпляр сервера не отправил тот же отчет
+		claim := db.Model(&models.ReportSchedule{}).
+			Where("id = ? AND next_run_at <= ?", schedule.ID, now).
+			Update("next_run_at", next)
+		if claim.Error != nil || claim.RowsAffected == 0 {
+			continue
+		}
+
+		Run(db, &schedule, now)
+	}
+	return nil
+}
+
+// Run формирует и отправляет отчет по расписанию и сохраняет результат
+func Run(db *gorm.DB, schedule *models.ReportSchedule, now time.Time) error {
+	ctx, cancel := context.WithTimeout(context.Background(), sendTimeout)
+	defer cancel()
+
+	err := Deliver(ctx, db, schedule.Period, schedule.Channel, schedule.Recipient, now)
+	lastError := ""
+	if err != nil {
+		lastError = err.Error()
+		log.Printf("❌ Ошибка отправки отчета %q: %v", schedule.Name, err)
+	} else {
+		log.Printf("✅ Отчет %q отправлен (%s: %s)", schedule.Name, schedule.Channel, schedule.Recipient)
+	}
+
+	db.Model(&models.ReportSchedule{}).Where("id = ?", schedule.ID).
+		Updates(map[string]interface{}{"last_run_at": now, "last_error": lastError})
+	schedule.LastRunAt, schedule.LastError = &now, lastError
+	return err
+}
+
+// Deliver собирает отчет за последний завершенный период и отправляет его:
+// текст и HTML в теле сообщения, PDF - вложением
+func Deliver(ctx context.Context, db *gorm.DB, period, channel, recipient string, now time.Time) error {
+	report, err := Build(db, period, now)
+	if err != nil {
+		return err
+	}
+	html, err := report.HTML()
+	if err != nil {
+		return err
+	}
+	pdf, err := report.PDF()
+	if err != nil {
+		return err
+	}
+
+	return notify.Send(ctx, notify.Message{
+		Channel: channel,
+		To:      recipient,
+		Subject: report.Title(),
+		Text:    report.Text(),
+		HTML:    html,
+		Attachments: []notify.Attachment{{
+			Name:        fmt.Sprintf("report-%s-%s.pdf", period, report.From.Format("2006-01-02")),
+			ContentType: "application/pdf",
+			Data:        pdf,
+		}},
+	})
+}
@@ -0,0 +1,154 @@
+package seo
+
+import (
+	"time"
+
+	"texnousta-backend/internal/config"
+	"texnousta-backend/internal/models"
+)
+
+// Разметка schema.org для карточки товара:
+// https://developers.google.com/search/docs/appearance/structured-data/product
+
+const schemaContext = "https://schema.org"
+
+// JSONLD - документ разметки с товаром и хлебными крошками
+type JSONLD struct {
+	Context string        `json:"@context"`
+	Graph   []interface{} `json:"@graph"`
+}
+
+// ProductLD - schema.org/Product
+type ProductLD struct {
+	Type        string   `json:"@type"`
+	ID          string   `json:"@id"`
+	Name        string   `json:"name"`
+	Description string   `json:"description,omitempty"`
+	Image       []string `json:"image,omitempty"`
+	SKU         string   `json:"sku,omitempty"`
+	MPN         string   `json:"mpn,omitempty"`
+	Brand       *ThingLD `json:"brand,omitempty"`
+	Category    string   `json:"category,omitempty"`
+	Offers      OfferLD  `json:"offers"`
+}
+
+// ThingLD - сущность schema.org с названием (Brand, Organization)
+type ThingLD struct {
+	Type string `json:"@type"`
+	Name string `json:"name"`
+}
+
+// OfferLD - schema.org/Offer
+type OfferLD struct {
+	Type               string               `json:"@type"`
+	URL                string               `json:"url"`
+	Price              float64              `json:"price"`
+	PriceCurrency      string               `json:"priceCurrency"`
+	PriceValidUntil    string               `json:"priceValidUntil,omitempty"`
+	Availability       string               `json:"availability"`
+	ItemCondition      string               `json:"itemCondition"`
+	InventoryLevel     *QuantityLD          `json:"inventoryLevel,omitempty"`
+	PriceSpecification []PriceSpecification `json:"priceSpecification,omitempty"`
+	Seller             ThingLD              `json:"seller"`
+}
+
+// QuantityLD - schema.org/QuantitativeValue
+type QuantityLD struct {
+	Type  string `json:"@type"`
+	Value int    `json:"value"`
+}
+
+// PriceSpecification - цена до скидки (StrikethroughPrice)
+type PriceSpecification struct {
+	Type          string  `json:"@type"`
+	PriceType     string  `json:"priceType"`
+	Price         float64 `json:"price"`
+	PriceCurrency string  `json:"priceCurrency"`
+}
+
+// BreadcrumbListLD - schema.org/BreadcrumbList
+type BreadcrumbListLD struct {
+	Type            string         `json:"@type"`
+	ItemListElement []BreadcrumbLD `json:"itemListElement"`
+}
+
+// BreadcrumbLD - элемент хлебных крошек
+type BreadcrumbLD struct {
+	Type     string `json:"@type"`
+	Position int    `json:"position"`
+	Name     string `json:"name"`
+	Item     string `json:"item,omitempty"`
+}
+
+// ProductJSONLD строит разметку товара. Ожидает загруженную категорию
+// и цену с учетом акций (promotions.ApplyToProduct).
+func ProductJSONLD(product *models.Product) JSONLD {
+	url := config.ProductURL(product.ID)
+	currency := config.Currency()
+
+	price, oldPrice := product.DisplayPrices()
+
+	offer := OfferLD{
+		Type:          "Offer",
+		URL:           url,
+		Price:         price,
+		PriceCurrency: currency,
+		Availability:  "https://schema.org/OutOfStock",
+		ItemCondition: "https://schema.org/NewCondition",
+		Seller:        ThingLD{Type: "Organization", Name: config.ShopName()},
+	}
+	if product.Stock > 0 {
+		offer.Availability = "https://schema.org/InStock"
+		offer.InventoryLevel = &QuantityLD{Type: "QuantitativeValue", Value: product.Stock}
+	}
+	if product.Promotion != nil && product.Promotion.EndsAt != nil {
+		offer.PriceValidUntil = product.Promotion.EndsAt.Format(time.DateOnly)
+	}
+
+	if oldPrice > 0 {
+		offer.PriceSpecification = []PriceSpecification{{
+			Type:          "UnitPriceSpecification",
+			PriceType:     "https://schema.org/StrikethroughPrice",
+			Price:         oldPrice,
+			PriceCurrency: currency,
+		}}
+	}
+
+	ld := ProductLD{
+		Type:        "Product",
+		ID:          url + "#product",
+		Name:        product.Name,
+		Description: product.Description,
+		SKU:         product.SKU,
+		MPN:         product.Model,
+		Category:    product.Category.Name,
+		Offers:      offer,
+	}
+	if product.Image != "" {
+		ld.Image = []string{config.AbsoluteURL(product.Image)}
+	}
+	if product.Brand != "" {
+		ld.Brand = &ThingLD{Type: "Brand", Name: product.Brand}
+	}
+	// aggregateRating не выводится: Google требует, чтобы рейтинг был
+	// рассчитан по опубликованным отзывам, а отзывов в магазине пока нет
+
+	crumbs := []BreadcrumbLD{{Type: "ListItem", Position: 1, Name: "Главная", Item: config.SiteURL() + "/"}}
+	if product.Category.ID != 0 {
+		crumbs = append(crumbs, BreadcrumbLD{
+			Type:     "ListItem",
+			Position: len(crumbs) + 1,
+			Name:     product.Category.Name,
+			Item:     config.SiteURL() + config.CategoryPath(product.Category.ID),
+		})
+	}
+	crumbs = append(crumbs, BreadcrumbLD{Type: "ListItem", Position: len(crumbs) + 1, Name: product.Name})
+
+	return JSONLD{
+		Context: schemaContext,
+		Graph: []interface{}{
+			ld,
+			BreadcrumbListLD{Type: "BreadcrumbList", ItemListElement: crumbs},
+		},
+	}
+}
@@ -0,0 +1,56 @@
+package seo
+
+import (
+	"io"
+	"os"
+	"strings"
+
+	"texnousta-backend/internal/config"
+)
+
+// defaultDisallow - закрытые от индексации разделы сайта
+const defaultDisallow = "/admin,/api/,/cart,/checkout,/profile,/orders"
+
+// WriteRobots пишет robots.txt.
+//
+// Настройки окружения:
+//
+//	ROBOTS_TXT_PATH     - файл, который отдается как есть
+//	ROBOTS_DISALLOW_ALL - true закрывает весь сайт (тестовые стенды)
+//	ROBOTS_DISALLOW     - закрытые разделы через запятую
+//	ROBOTS_EXTRA        - дополнительные строки (\n - перевод строки)
+func WriteRobots(w io.Writer) error {
+	if path := os.Getenv("ROBOTS_TXT_PATH"); path != "" {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		_, err = w.Write(data)
+		return err
+	}
+
+	var b strings.Builder
+	b.WriteString("User-agent: *\n")
+	if os.Getenv("ROBOTS_DISALLOW_ALL") == "true" {
+		b.WriteString("Disallow: /\n")
+	} else {
+		disallow := os.Getenv("ROBOTS_DISALLOW")
+		if disallow == "" {
+			disallow = defaultDisallow
+		}
+		for _, path := range strings.Split(disallow, ",") {
+			if path = strings.TrimSpace(path); path != "" {
+				b.WriteString("Disallow: " + path + "\n")
+			}
+		}
+		b.WriteString("Allow: /\n")
+	}
+
+	if extra := os.Getenv("ROBOTS_EXTRA"); extra != "" {
+		b.WriteString("\n" + strings.ReplaceAll(extra, `\n`, "\n") + "\n")
+	}
+
+	b.WriteString("\nSitemap: " + config.SiteURL() + "/sitemap.xml\n")
+	_, err := io.WriteString(w, b.String())
+	return err
+}
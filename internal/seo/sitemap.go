@@ -0,0 +1,250 @@
+// Package seo формирует sitemap.xml и robots.txt по каталогу.
+//
+// Небольшой каталог отдается одним файлом sitemap.xml. Если адресов больше,
+// чем помещается в один файл, sitemap.xml становится индексом, который
+// ссылается на части /sitemaps/static.xml и /sitemaps/products-N.xml.
+package seo
+
+import (
+	"encoding/xml"
+	"errors"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"texnousta-backend/internal/config"
+	"texnousta-backend/internal/database"
+	"texnousta-backend/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// defaultMaxURLs - ограничение протокола Sitemaps на число адресов в файле
+const defaultMaxURLs = 50000
+
+// ErrNotFound - запрошенной части sitemap нет
+var ErrNotFound = errors.New("Файл sitemap не найден")
+
+const (
+	sitemapNamespace = "http://www.sitemaps.org/schemas/sitemap/0.9"
+	xhtmlNamespace   = "http://www.w3.org/1999/xhtml"
+)
+
+type urlSet struct {
+	XMLName xml.Name `xml:"urlset"`
+	XMLNS   string   `xml:"xmlns,attr"`
+	XHTML   string   `xml:"xmlns:xhtml,attr"`
+	URLs    []url    `xml:"url"`
+}
+
+type url struct {
+	Loc        string      `xml:"loc"`
+	LastMod    string      `xml:"lastmod,omitempty"`
+	Alternates []alternate `xml:"xhtml:link"`
+}
+
+type alternate struct {
+	Rel      string `xml:"rel,attr"`
+	Hreflang string `xml:"hreflang,attr"`
+	Href     string `xml:"href,attr"`
+}
+
+type sitemapIndex struct {
+	XMLName  xml.Name  `xml:"sitemapindex"`
+	XMLNS    string    `xml:"xmlns,attr"`
+	Sitemaps []sitemap `xml:"sitemap"`
+}
+
+type sitemap struct {
+	Loc     string `xml:"loc"`
+	LastMod string `xml:"lastmod,omitempty"`
+}
+
+// page - страница сайта для sitemap
+type page struct {
+	path    string
+	updated time.Time
+}
+
+// maxURLs - число адресов в одном файле (SITEMAP_MAX_URLS для отладки)
+func maxURLs() int {
+	if v, err := strconv.Atoi(os.Getenv("SITEMAP_MAX_URLS")); err == nil && v > 0 && v < defaultMaxURLs {
+		return v
+	}
+	return defaultMaxURLs
+}
+
+// perPage - число страниц в одном файле: каждая страница дает адрес на каждом языке
+func perPage() int {
+	n := maxURLs() / len(config.Locales())
+	if n < 1 {
+		n = 1
+	}
+	return n
+}
+
+// WriteSitemap пишет sitemap.xml: весь каталог или индекс, если каталог большой
+func WriteSitemap(w io.Writer) error {
+	static, err := staticPages()
+	if err != nil {
+		return err
+	}
+
+	var productCount int64
+	if err := activeProducts().Count(&productCount).Error; err != nil {
+		return err
+	}
+
+	if len(static)+int(productCount) <= perPage() {
+		products, err := productPages(0, int(productCount))
+		if err != nil {
+			return err
+		}
+		return writeURLSet(w, append(static, products...))
+	}
+
+	index := sitemapIndex{XMLNS: sitemapNamespace}
+	index.Sitemaps = append(index.Sitemaps, sitemap{
+		Loc:     config.SiteURL() + "/sitemaps/static.xml",
+		LastMod: lastMod(static),
+	})
+
+	chunks := (int(productCount) + perPage() - 1) / perPage()
+	for i := 0; i < chunks; i++ {
+		products, err := productPages(i*perPage(), perPage())
+		if err != nil {
+			return err
+		}
+		index.Sitemaps = append(index.Sitemaps, sitemap{
+			Loc:     config.SiteURL() + "/sitemaps/products-" + strconv.Itoa(i+1) + ".xml",
+			LastMod: lastMod(products),
+		})
+	}
+	return encode(w, index)
+}
+
+// WritePart пишет часть sitemap по имени файла из индекса
+func WritePart(w io.Writer, name string) error {
+	if name == "static.xml" {
+		static, err := staticPages()
+		if err != nil {
+			return err
+		}
+		return writeURLSet(w, static)
+	}
+
+	number := strings.TrimSuffix(strings.TrimPrefix(name, "products-"), ".xml")
+	n, err := strconv.Atoi(number)
+	if err != nil || n < 1 || name != "products-"+number+".xml" {
+		return ErrNotFound
+	}
+
+	products, err := productPages((n-1)*perPage(), perPage())
+	if err != nil {
+		return err
+	}
+	if len(products) == 0 {
+		return ErrNotFound
+	}
+	return writeURLSet(w, products)
+}
+
+// staticPages - главная страница и активные категории
+func staticPages() ([]page, error) {
+	var categories []models.Category
+	if err := database.DB.Select("id, updated_at").
+		Where("is_active = ?", true).
+		Order("id ASC").
+		Find(&categories).Error; err != nil {
+		return nil, err
+	}
+
+	// Главная меняется вместе с каталогом
+	var latest models.Product
+	home := page{path: "/"}
+	if err := activeProducts().Select("products.updated_at").Order("products.updated_at DESC").First(&latest).Error; err == nil {
+		home.updated = latest.UpdatedAt
+	}
+
+	pages := []page{home}
+	for _, category := range categories {
+		pages = append(pages, page{path: config.CategoryPath(category.ID), updated: category.UpdatedAt})
+	}
+	return pages, nil
+}
+
+// productPages - активные товары активных категорий, по порядку ID
+func productPages(offset, limit int) ([]page, error) {
+	var products []models.Product
+	if err := activeProducts().Select("products.id, products.updated_at").
+		Order("products.id ASC").
+		Offset(offset).
+		Limit(limit).
+		Find(&products).Error; err != nil {
+		return nil, err
+	}
+
+	pages := make([]page, 0, len(products))
+	for _, product := range products {
+		pages = append(pages, page{path: config.ProductPath(product.ID), updated: product.UpdatedAt})
+	}
+	return pages, nil
+}
+
+func activeProducts() *gorm.DB {
+	return database.DB.Model(&models.Product{}).
+		Joins("JOIN categories ON categories.id = products.category_id").
+		Where("products.is_active = ? AND categories.is_active = ?", true, true)
+}
+
+// writeURLSet пишет страницы на всех языках с взаимными ссылками hreflang
+func writeURLSet(w io.Writer, pages []page) error {
+	locales := config.Locales()
+	set := urlSet{XMLNS: sitemapNamespace, XHTML: xhtmlNamespace}
+
+	for _, p := range pages {
+		alternates := make([]alternate, 0, len(locales)+1)
+		for _, locale := range locales {
+			alternates = append(alternates, alternate{Rel: "alternate", Hreflang: locale, Href: config.LocalizedURL(locale, p.path)})
+		}
+		alternates = append(alternates, alternate{Rel: "alternate", Hreflang: "x-default", Href: config.LocalizedURL("", p.path)})
+
+		for _, locale := range locales {
+			entry := url{Loc: config.LocalizedURL(locale, p.path), Alternates: alternates}
+			if !p.updated.IsZero() {
+				entry.LastMod = p.updated.UTC().Format(time.RFC3339)
+			}
+			set.URLs = append(set.URLs, entry)
+		}
+	}
+	return encode(w, set)
+}
+
+// lastMod - время последнего изменения среди страниц
+func lastMod(pages []page) string {
+	var latest time.Time
+	for _, p := range pages {
+		if p.updated.After(latest) {
+			latest = p.updated
+		}
+	}
+	if latest.IsZero() {
+		return ""
+	}
+	return latest.UTC().Format(time.RFC3339)
+}
+
+func encode(w io.Writer, doc interface{}) error {
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(doc); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
@@ -0,0 +1,140 @@
+// Package stockalerts следит за остатками товаров: оповещает администратора
+// о низком остатке и покупателей - о поступлении товара, на который они
+// подписались. Check вызывается после фиксации изменения остатка.
+package stockalerts
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"texnousta-backend/internal/database"
+	"texnousta-backend/internal/models"
+	"texnousta-backend/internal/notify"
+)
+
+// TypeLowStock - тип уведомления администратора о низком остатке
+const TypeLowStock = "low_stock"
+
+// sendTimeout - ограничение времени на отправку одного уведомления
+const sendTimeout = 30 * time.Second
+
+// Check проверяет остаток товара после изменения и рассылает оповещения
+func Check(productID uint) {
+	var product models.Product
+	if err := database.DB.First(&product, productID).Error; err != nil {
+		return
+	}
+
+	checkLowStock(&product)
+	if product.Stock > 0 {
+		notifySubscribers(&product)
+	}
+}
+
+// CheckAsync запускает Check в фоне, чтобы не задерживать ответ клиенту
+func CheckAsync(productIDs ...uint) {
+	go func() {
+		for _, id := range productIDs {
+			Check(id)
+		}
+	}()
+}
+
+// checkLowStock создает уведомление, когда остаток опустился до порога.
+// Повторное уведомление возможно только после пополнения выше порога.
+func checkLowStock(product *models.Product) {
+	if product.LowStockThreshold <= 0 {
+		return
+	}
+
+	if product.Stock > product.LowStockThreshold {
+		if product.LowStockAlerted {
+			database.DB.Model(product).Update("low_stock_alerted", false)
+		}
+		return
+	}
+	if product.LowStockAlerted {
+		return
+	}
+
+	// Флаг ставится условно, чтобы параллельные проверки не создали дубликат
+	result := database.DB.Model(&models.Product{}).
+		Where("id = ? AND low_stock_alerted = ?", product.ID, false).
+		Update("low_stock_alerted", true)
+	if result.Error != nil || result.RowsAffected == 0 {
+		return
+	}
+
+	notification := models.AdminNotification{
+		Type:      TypeLowStock,
+		Title:     fmt.Sprintf("Заканчивается товар: %s", product.Name),
+		Message:   fmt.Sprintf("Остаток %d шт., порог %d шт.", product.Stock, product.LowStockThreshold),
+		ProductID: &product.ID,
+	}
+	if err := database.DB.Create(&notification).Error; err != nil {
+		log.Printf("❌ Ошибка сохранения уведомления о низком остатке: %v", err)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), sendTimeout)
+	defer cancel()
+	if err := notify.Send(ctx, notify.Message{
+		Channel: notify.ChannelAdmin,
+		Subject: notification.Title,
+		Text:    notification.Message,
+	}); err != nil {
+		log.Printf("❌ Ошибка отправки уведомления о низком остатке: %v", err)
+	}
+}
+
+// notifySubscribers сообщает подписчикам, что товар снова в наличии
+func notifySubscribers(product *models.Product) {
+	var subscriptions []models.StockSubscription
+	if err := database.DB.Where("product_id = ? AND notified_at IS NULL", product.ID).
+		Find(&subscriptions).Error; err != nil {
+		return
+	}
+
+	subject := "Товар снова в наличии"
+	text := fmt.Sprintf("%s снова в наличии в TexnoUsta. Успейте заказать!", product.Name)
+
+	for _, sub := range subscriptions {
+		// Отмечаем подписку заранее, чтобы параллельная проверка не отправила повторно
+		now := time.Now()
+		result := database.DB.Model(&models.StockSubscription{}).
+			Where("id = ? AND notified_at IS NULL", sub.ID).
+			Update("notified_at", now)
+		if result.Error != nil || result.RowsAffected == 0 {
+			continue
+		}
+
+		var messages []notify.Message
+		if sub.Email != "" {
+			messages = append(messages, notify.Message{Channel: notify.ChannelEmail, To: sub.Email, Subject: subject, Text: text})
+		}
+		if sub.Phone != "" {
+			messages = append(messages, notify.Message{Channel: notify.ChannelSMS, To: sub.Phone, Subject: subject, Text: text})
+		}
+
+		delivered := false
+		for _, msg := range messages {
+			ctx, cancel := context.WithTimeout(context.Background(), sendTimeout)
+			err := notify.Send(ctx, msg)
+			cancel()
+			if err != nil {
+				log.Printf("❌ Ошибка отправки уведомления о поступлении (подписка %d): %v", sub.ID, err)
+				continue
+			}
+			delivered = true
+		}
+
+		// Ни одно сообщение не ушло - повторим при следующей проверке
+		if !delivered {
+			database.DB.Model(&models.StockSubscription{}).
+				Where("id = ?", sub.ID).
+				Update("notified_at", nil)
+		}
+	}
+}
@@ -0,0 +1,131 @@
+// Package useragent определяет тип устройства, операционную систему,
+// браузер и ботов по строке User-Agent.
+//
+// Разбор упрощенный: распознаются платформы и браузеры, которые реально
+// встречаются у посетителей магазина, все остальное попадает в "Other".
+package useragent
+
+import (
+	"strings"
+)
+
+// Типы устройств
+const (
+	Desktop = "desktop"
+	Mobile  = "mobile"
+	Tablet  = "tablet"
+	Bot     = "bot"
+	Unknown = "unknown"
+)
+
+// Other - ОС или браузер не распознаны
+const Other = "Other"
+
+// Info - результат разбора User-Agent
+type Info struct {
+	DeviceType string
+	OS         string
+	Browser    string
+	IsBot      bool
+}
+
+// botMarkers - подстроки User-Agent поисковых роботов, сервисов предпросмотра
+// ссылок, мониторинга и HTTP-библиотек
+var botMarkers = []string{
+	"bot", "crawl", "spider", "slurp", "mediapartners", "facebookexternalhit",
+	"preview", "monitor", "lighthouse", "headlesschrome", "phantomjs",
+	"curl/", "wget/", "python-requests", "python-urllib", "go-http-client",
+	"java/", "okhttp", "axios/", "node-fetch", "postman",
+}
+
+type rule struct {
+	marker string
+	name   string
+}
+
+// osRules проверяются по порядку: iOS раньше macOS, Android раньше Linux
+var osRules = []rule{
+	{"windows phone", "Windows Phone"},
+	{"windows", "Windows"},
+	{"iphone", "iOS"},
+	{"ipad", "iOS"},
+	{"ipod", "iOS"},
+	{"android", "Android"},
+	{"cros", "ChromeOS"},
+	{"mac os x", "macOS"},
+	{"macintosh", "macOS"},
+	{"linux", "Linux"},
+}
+
+// browserRules проверяются по порядку: встроенные браузеры приложений
+// и браузеры на Chromium раньше Chrome, Chrome раньше Safari
+var browserRules = []rule{
+	{"instagram", "Instagram"},
+	{"fban", "Facebook"},
+	{"fbav", "Facebook"},
+	{"telegram", "Telegram"},
+	{"yabrowser", "Yandex Browser"},
+	{"edg", "Edge"},
+	{"opr/", "Opera"},
+	{"opera", "Opera"},
+	{"samsungbrowser", "Samsung Internet"},
+	{"ucbrowser", "UC Browser"},
+	{"firefox", "Firefox"},
+	{"fxios", "Firefox"},
+	{"crios", "Chrome"},
+	{"chrome", "Chrome"},
+	{"chromium", "Chrome"},
+	{"safari", "Safari"},
+	{"msie", "Internet Explorer"},
+	{"trident", "Internet Explorer"},
+}
+
+// Parse разбирает строку User-Agent
+func Parse(ua string) Info {
+	lower := strings.ToLower(strings.TrimSpace(ua))
+	if lower == "" {
+		return Info{DeviceType: Unknown, OS: Other, Browser: Other}
+	}
+
+	info := Info{
+		OS:      match(lower, osRules),
+		Browser: match(lower, browserRules),
+		IsBot:   IsBot(lower),
+	}
+
+	switch {
+	case info.IsBot:
+		info.DeviceType = Bot
+	case strings.Contains(lower, "ipad") || strings.Contains(lower, "tablet") ||
+		(info.OS == "Android" && !strings.Contains(lower, "mobile")):
+		info.DeviceType = Tablet
+	case strings.Contains(lower, "mobi") || strings.Contains(lower, "iphone") ||
+		strings.Contains(lower, "ipod") || info.OS == "Android" || info.OS == "Windows Phone":
+		info.DeviceType = Mobile
+	case info.OS == Other && info.Browser == Other:
+		info.DeviceType = Unknown
+	default:
+		info.DeviceType = Desktop
+	}
+	return info
+}
+
+// IsBot проверяет, похож ли User-Agent на робота
+func IsBot(ua string) bool {
+	lower := strings.ToLower(ua)
+	for _, marker := range botMarkers {
+		if strings.Contains(lower, marker) {
+			return true
+		}
+	}
+	return false
+}
+
+func match(lower string, rules []rule) string {
+	for _, r := range rules {
+		if strings.Contains(lower, r.marker) {
+			return r.name
+		}
+	}
+	return Other
+}
@@ -19,9 +19,13 @@ package main
 import (
 	"log"
 	"os"
+	"texnousta-backend/internal/analytics"
 	"texnousta-backend/internal/database"
 	"texnousta-backend/internal/handlers"
 	"texnousta-backend/internal/middleware"
+	"texnousta-backend/internal/notify"
+	"texnousta-backend/internal/recommend"
+	"texnousta-backend/internal/reports"
 
 	_ "texnousta-backend/docs"
 
@@ -41,6 +45,16 @@ func main() {
 	// Инициализация базы данных
 	database.Init()
 
+	// Фоновое обслуживание статистики: сводные таблицы и срок хранения
+	analytics.StartMaintenance(database.DB)
+
+	// Периодический пересчет рекомендаций товаров
+	recommend.StartRefresher(database.DB)
+
+	// Транспорт уведомлений (SMTP, Telegram или файлы) и отчеты по расписанию
+	notify.SetNotifier(notify.FromEnv())
+	reports.StartScheduler(database.DB)
+
 	// Настройка Gin режима
 	if os.Getenv("GIN_MODE") == "release" {
 		gin.SetMode(gin.ReleaseMode)
@@ -53,7 +67,7 @@ func main() {
 	config := cors.DefaultConfig()
 	config.AllowAllOrigins = true
 	config.AllowMethods = []string{"GET", "POST", "PUT", "PATCH", "DELETE", "HEAD", "OPTIONS"}
-	config.AllowHeaders = []string{"Origin", "Content-Length", "Content-Type", "Authorization"}
+	config.AllowHeaders = []string{"Origin", "Content-Length", "Content-Type", "Authorization", "X-Session-ID"}
 	config.AllowCredentials = true
 	r.Use(cors.New(config))
 
@@ -64,6 +78,11 @@ func main() {
 	// Статические файлы
 	r.Static("/uploads", "./uploads")
 
+	// SEO: sitemap и robots.txt по каталогу
+	r.GET("/sitemap.xml", handlers.GetSitemap)
+	r.GET("/sitemaps/:file", handlers.GetSitemapPart)
+	r.GET("/robots.txt", handlers.GetRobots)
+
 	// API роуты
 	api := r.Group("/api/v1")
 	{
@@ -74,7 +93,21 @@ func main() {
 		// Роуты для товаров (публичные)
 		api.GET("/products", handlers.GetProducts)
 		api.GET("/products/:id", handlers.GetProduct)
+		api.GET("/products/:id/availability", handlers.GetProductAvailability)
+		api.GET("/products/:id/jsonld", handlers.GetProductJSONLD)
+		api.GET("/products/:id/related", handlers.GetRelatedProducts)
+		api.POST("/products/:id/subscribe", handlers.SubscribeToStock)
 		api.GET("/categories", handlers.GetCategories)
+		api.GET("/promotions", handlers.GetActivePromotions)
+		api.GET("/pickup-points", handlers.GetPickupPoints)
+		
+		// Товарные фиды для маркетплейсов (публичные)
+		api.GET("/feeds/yandex.yml", handlers.GetYandexFeed)
+		api.GET("/feeds/google.xml", handlers.GetGoogleFeed)
+		
+		// Обмен с 1С (CommerceML, собственная авторизация через checkauth)
+		api.GET("/1c/exchange", handlers.OneCExchange)
+		api.POST("/1c/exchange", handlers.OneCExchange)
 		
 		// Контактная форма (публичная)
 		api.POST("/contact", handlers.CreateContact)
@@ -84,15 +117,30 @@ func main() {
 		// Аналитика (публичные эндпоинты)
 		api.POST("/track-visitor", handlers.TrackVisitor)
 		api.POST("/track-phone-click", handlers.TrackPhoneClick)
+		api.POST("/events", handlers.TrackEvents)
+		api.GET("/events/schemas", handlers.GetEventSchemas)
 		
 		// Админ логин (без авторизации)
 		api.POST("/admin/login", handlers.AdminLogin)
 		
+		// Поток событий для админ-панели: EventSource передает билет параметром
+		api.GET("/admin/events/stream",
+			middleware.StreamTicketMiddleware(),
+			middleware.AdminMiddleware(),
+			handlers.StreamAdminEvents,
+		)
+		
 		// Админ аналитика (с простым токеном)
 		adminAnalytics := api.Group("/admin")
 		{
 			adminAnalytics.GET("/visitor-stats", handlers.GetVisitorStats)
+			adminAnalytics.GET("/device-stats", handlers.GetDeviceStats)
 			adminAnalytics.GET("/phone-click-stats", handlers.GetPhoneClickStats)
+			adminAnalytics.GET("/product-stats", handlers.GetProductPopularity)
+			adminAnalytics.GET("/page-stats", handlers.GetPageStats)
+			adminAnalytics.GET("/attribution-stats", handlers.GetAttributionStats)
+			adminAnalytics.GET("/event-stats", handlers.GetEventStats)
+			adminAnalytics.GET("/funnel-stats", handlers.GetFunnelStats)
 			adminAnalytics.GET("/phone-contacts", handlers.GetPhoneContacts)
 			adminAnalytics.DELETE("/phone-contacts/:id", handlers.DeletePhoneContact)
 			adminAnalytics.GET("/database-status", handlers.GetDatabaseStatus)
@@ -106,6 +154,10 @@ func main() {
 			protected.GET("/profile", handlers.GetProfile)
 			protected.PUT("/profile", handlers.UpdateProfile)
 			
+			// Заказы
+			protected.POST("/orders", handlers.CreateOrder)
+			protected.GET("/orders", handlers.GetMyOrders)
+			
 			// Админские роуты
 			admin := protected.Group("/admin")
 			admin.Use(middleware.AdminMiddleware())
@@ -114,6 +166,26 @@ func main() {
 				admin.POST("/products", handlers.CreateProduct)
 				admin.PUT("/products/:id", handlers.UpdateProduct)
 				admin.DELETE("/products/:id", handlers.DeleteProduct)
+				admin.POST("/products/import", handlers.ImportProducts)
+				
+				// Складской учет
+				admin.GET("/products/low-stock", handlers.GetLowStockProducts)
+				admin.GET("/products/:id/stock-movements", handlers.GetStockMovements)
+				admin.POST("/products/:id/stock-movements", handlers.PostStockMovement)
+				admin.GET("/warehouses", handlers.GetWarehouses)
+				admin.POST("/warehouses", handlers.CreateWarehouse)
+				admin.PUT("/warehouses/:id", handlers.UpdateWarehouse)
+				admin.DELETE("/warehouses/:id", handlers.DeleteWarehouse)
+				
+				// Управление заказами
+				admin.GET("/orders", handlers.GetOrders)
+				admin.PUT("/orders/:id/status", handlers.UpdateOrderStatus)
+				
+				// Управление акциями
+				admin.GET("/promotions", handlers.GetPromotions)
+				admin.POST("/promotions", handlers.CreatePromotion)
+				admin.PUT("/promotions/:id", handlers.UpdatePromotion)
+				admin.DELETE("/promotions/:id", handlers.DeletePromotion)
 				
 				// Управление категориями
 				admin.POST("/categories", handlers.CreateCategory)
@@ -130,6 +202,38 @@ func main() {
 				admin.GET("/contacts/:id", handlers.GetContact)
 				admin.PUT("/contacts/:id/read", handlers.MarkContactAsRead)
 				admin.DELETE("/contacts/:id", handlers.DeleteContact)
+				
+				// Билет на поток событий админ-панели
+				admin.POST("/events/stream-ticket", handlers.CreateStreamTicket)
+				
+				// Уведомления администратора
+				admin.GET("/notifications", handlers.GetAdminNotifications)
+				admin.PUT("/notifications/:id/read", handlers.MarkAdminNotificationAsRead)
+				
+				// Маркетплейсы
+				admin.GET("/marketplaces", handlers.GetMarketplaces)
+				admin.GET("/marketplaces/:name/categories", handlers.GetMarketplaceCategories)
+				admin.PUT("/marketplaces/:name/categories", handlers.SetMarketplaceCategory)
+				admin.DELETE("/marketplaces/:name/categories/:category_id", handlers.DeleteMarketplaceCategory)
+				admin.GET("/marketplaces/:name/bulk-file", handlers.GetMarketplaceBulkFile)
+				admin.POST("/marketplaces/:name/push-products", handlers.PushMarketplaceProducts)
+				admin.POST("/marketplaces/:name/push-stock", handlers.PushMarketplaceStock)
+				admin.POST("/marketplaces/:name/pull-orders", handlers.PullMarketplaceOrders)
+				admin.GET("/marketplaces/:name/orders", handlers.GetMarketplaceOrders)
+				
+				// Выгрузка статистики и обращений (содержит персональные данные)
+				admin.GET("/export/:dataset", handlers.ExportStats)
+				
+				// Обезличивание и удаление статистики (необратимо)
+				admin.POST("/stats/anonymize", handlers.AnonymizeStats)
+				
+				// Отчеты по расписанию
+				admin.GET("/reports/schedules", handlers.GetReportSchedules)
+				admin.POST("/reports/schedules", handlers.CreateReportSchedule)
+				admin.PUT("/reports/schedules/:id", handlers.UpdateReportSchedule)
+				admin.DELETE("/reports/schedules/:id", handlers.DeleteReportSchedule)
+				admin.POST("/reports/schedules/:id/send", handlers.SendReportSchedule)
+				admin.GET("/reports/preview", handlers.PreviewReport)
 			}
 		}
 	}